@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ========== 一致性测试 ==========
+//
+// testdata/conformance 下的每个 .long 程序分别用字节码虚拟机（run）和
+// AST 解释器（interpret）执行，比较 stdout、stderr 和退出状态。
+//
+// 期望输出保存在同名的 .golden 文件中，两个引擎共用。
+// 某个引擎与期望输出不一致且暂时无法修复时，用 <name>.<engine>.golden 记录该引擎的实际输出，
+// 测试结束时会列出所有这样的已知差异；修复后该文件会导致测试失败，提示将其删除。
+//
+// 更新期望输出：go test -run TestConformance -update
+
+var updateGolden = flag.Bool("update", false, "用当前输出更新一致性测试的期望输出文件")
+
+const (
+	conformanceDir = "testdata/conformance"
+	conformanceEnv = "LONGLANG_CONFORMANCE_MAIN" // 设置后测试二进制直接作为 longlang 命令运行
+)
+
+// engines 参与比较的执行引擎（对应命令行子命令），第一个是参考引擎
+var engines = []string{"run", "interpret"}
+
+// TestMain 允许测试二进制充当 longlang 命令，用于在子进程中执行程序
+func TestMain(m *testing.M) {
+	if os.Getenv(conformanceEnv) == "1" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// execution 一次执行的结果
+type execution struct {
+	Exit   int
+	Stdout string
+	Stderr string
+}
+
+// String 序列化为 golden 文件格式
+func (e execution) String() string {
+	var sb strings.Builder
+	sb.WriteString("-- exit --\n")
+	sb.WriteString(strconv.Itoa(e.Exit))
+	sb.WriteString("\n-- stdout --\n")
+	sb.WriteString(e.Stdout)
+	sb.WriteString("-- stderr --\n")
+	sb.WriteString(e.Stderr)
+	return sb.String()
+}
+
+// parseGolden 解析 golden 文件：-- exit --、-- stdout --、-- stderr -- 三个部分
+func parseGolden(content string) (execution, error) {
+	var result execution
+	sections := map[string]*strings.Builder{}
+	var current *strings.Builder
+	for _, line := range strings.SplitAfter(content, "\n") {
+		trimmed := strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(trimmed, "-- ") && strings.HasSuffix(trimmed, " --") {
+			name := strings.TrimSuffix(strings.TrimPrefix(trimmed, "-- "), " --")
+			current = &strings.Builder{}
+			sections[name] = current
+			continue
+		}
+		if current != nil {
+			current.WriteString(line)
+		}
+	}
+
+	exit, ok := sections["exit"]
+	if !ok {
+		return result, fmt.Errorf("缺少 -- exit -- 部分")
+	}
+	code, err := strconv.Atoi(strings.TrimSpace(exit.String()))
+	if err != nil {
+		return result, fmt.Errorf("无效的退出状态: %v", err)
+	}
+	result.Exit = code
+	if stdout, ok := sections["stdout"]; ok {
+		result.Stdout = stdout.String()
+	}
+	if stderr, ok := sections["stderr"]; ok {
+		result.Stderr = stderr.String()
+	}
+	return result, nil
+}
+
+// runEngine 在子进程中用指定引擎执行程序
+func runEngine(t *testing.T, engine, file string) execution {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], engine, file)
+	// 禁用字节码缓存，避免测试向用户缓存目录写入文件
+	cmd.Env = append(os.Environ(), conformanceEnv+"=1", "LONGLANG_CACHE_DIR=off")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	result := execution{}
+	if err := cmd.Run(); err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			t.Fatalf("执行 %s %s 失败: %v", engine, file, err)
+		}
+		result.Exit = exitErr.ExitCode()
+	}
+	result.Stdout = stdout.String()
+	result.Stderr = stderr.String()
+	return result
+}
+
+// TestConformance 比较两个引擎在语料程序上的行为
+func TestConformance(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(conformanceDir, "*.long"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatalf("%s 中没有测试程序", conformanceDir)
+	}
+	sort.Strings(files)
+
+	// 已知差异：构造名 -> 与期望输出不一致的引擎
+	divergences := map[string][]string{}
+
+	for _, file := range files {
+		file := filepath.ToSlash(file)
+		construct := strings.TrimSuffix(filepath.Base(file), ".long")
+		goldenPath := strings.TrimSuffix(file, ".long") + ".golden"
+
+		t.Run(construct, func(t *testing.T) {
+			for _, engine := range engines {
+				engine := engine
+				t.Run(engine, func(t *testing.T) {
+					got := runEngine(t, engine, file)
+
+					enginePath := strings.TrimSuffix(file, ".long") + "." + engine + ".golden"
+					_, statErr := os.Stat(enginePath)
+					hasOverride := statErr == nil
+					if hasOverride {
+						divergences[construct] = append(divergences[construct], engine)
+					}
+
+					if *updateGolden {
+						switch {
+						case hasOverride:
+							writeGolden(t, enginePath, got)
+						case engine == engines[0]:
+							writeGolden(t, goldenPath, got)
+						}
+					}
+
+					want := readGolden(t, goldenPath)
+					if hasOverride {
+						if got == want {
+							t.Fatalf("%s 现在与期望输出一致，请删除 %s", engine, enginePath)
+						}
+						want = readGolden(t, enginePath)
+					}
+					compareExecution(t, want, got)
+				})
+			}
+		})
+	}
+
+	if len(divergences) > 0 {
+		var names []string
+		for name := range divergences {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		var sb strings.Builder
+		sb.WriteString("已知引擎差异（与期望输出不一致的引擎）:")
+		for _, name := range names {
+			sb.WriteString(fmt.Sprintf("\n  %-24s %s", name, strings.Join(divergences[name], ", ")))
+		}
+		t.Log(sb.String())
+	}
+}
+
+// compareExecution 比较退出状态、stdout 和 stderr，分别报告差异
+func compareExecution(t *testing.T, want, got execution) {
+	t.Helper()
+	if got.Exit != want.Exit {
+		t.Errorf("退出状态不一致: 期望 %d，实际 %d", want.Exit, got.Exit)
+	}
+	if got.Stdout != want.Stdout {
+		t.Errorf("stdout 不一致:\n--- 期望\n%s--- 实际\n%s", want.Stdout, got.Stdout)
+	}
+	if got.Stderr != want.Stderr {
+		t.Errorf("stderr 不一致:\n--- 期望\n%s--- 实际\n%s", want.Stderr, got.Stderr)
+	}
+}
+
+// readGolden 读取并解析 golden 文件
+func readGolden(t *testing.T, path string) execution {
+	t.Helper()
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取期望输出失败（可使用 -update 生成）: %v", err)
+	}
+	result, err := parseGolden(string(content))
+	if err != nil {
+		t.Fatalf("解析 %s 失败: %v", path, err)
+	}
+	return result
+}
+
+// writeGolden 写入 golden 文件
+func writeGolden(t *testing.T, path string, result execution) {
+	t.Helper()
+	if err := ioutil.WriteFile(path, []byte(result.String()), 0644); err != nil {
+		t.Fatalf("写入 %s 失败: %v", path, err)
+	}
+}
@@ -0,0 +1,384 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/tangzhangming/longlang/internal/parser"
+)
+
+// ========== 检查上下文 ==========
+
+// variable 局部变量
+type variable struct {
+	typ      *Type
+	declared bool // 是否显式声明了类型（只有显式声明的变量才检查赋值）
+}
+
+// varScope 变量作用域（块级）
+type varScope struct {
+	vars   map[string]*variable
+	parent *varScope
+}
+
+// newVarScope 创建子作用域
+func newVarScope(parent *varScope) *varScope {
+	return &varScope{vars: make(map[string]*variable), parent: parent}
+}
+
+// lookup 沿作用域链查找变量
+func (s *varScope) lookup(name string) *variable {
+	for scope := s; scope != nil; scope = scope.parent {
+		if v, ok := scope.vars[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// context 函数体的检查上下文
+type context struct {
+	scope      *fileScope // 所在文件
+	class      *classInfo // 所在的类（顶层代码和函数为 nil）
+	static     bool       // 是否在静态方法中
+	returnType *Type      // 声明的返回类型（未声明时为 nil，不检查 return）
+	funcName   string     // 函数的显示名称（用于错误消息）
+	vars       *varScope  // 当前变量作用域
+}
+
+// child 返回进入新块后的上下文
+func (ctx *context) child() *context {
+	copied := *ctx
+	copied.vars = newVarScope(ctx.vars)
+	return &copied
+}
+
+// define 在当前作用域中定义变量
+func (ctx *context) define(name string, typ *Type, declared bool) {
+	if name == "" || name == "_" {
+		return
+	}
+	ctx.vars.vars[name] = &variable{typ: typ, declared: declared}
+}
+
+// ========== 文件与声明 ==========
+
+// checkFile 检查文件中的所有顶层语句、函数、类和枚举
+func (c *Checker) checkFile(scope *fileScope) {
+	for _, fn := range scope.functions {
+		fn.params = c.paramsFromNodes(fn.node.Parameters, scope)
+		fn.returnType = c.returnTypeFromNodes(fn.node.ReturnType, scope)
+	}
+
+	ctx := &context{scope: scope, funcName: "顶层代码", vars: newVarScope(nil)}
+	for _, stmt := range scope.program.Statements {
+		switch s := stmt.(type) {
+		case *parser.ClassStatement:
+			c.checkClass(scope, s)
+		case *parser.EnumStatement:
+			c.checkEnum(scope, s)
+		default:
+			c.checkStatement(ctx, stmt)
+		}
+	}
+}
+
+// classOf 返回文件中声明的类信息
+func (c *Checker) classOf(scope *fileScope, name string) *classInfo {
+	fullName := name
+	if scope.namespace != "" {
+		fullName = scope.namespace + "." + name
+	}
+	return c.members(c.classes[fullName])
+}
+
+// checkClass 检查类：接口实现、字段初始值和方法体
+// 重复声明的类（登记的是另一个声明）已在 declare 中报告，不再检查
+func (c *Checker) checkClass(scope *fileScope, stmt *parser.ClassStatement) {
+	info := c.classOf(scope, stmt.Name.Value)
+	if info == nil || info.node != stmt {
+		return
+	}
+	c.checkImplementation(info, stmt.Name)
+
+	for _, member := range stmt.Members {
+		switch m := member.(type) {
+		case *parser.ClassVariable:
+			c.checkFieldInit(scope, info, m)
+		case *parser.ClassMethod:
+			c.checkMethod(scope, info, m)
+		}
+	}
+}
+
+// checkEnum 检查枚举：接口实现、字段初始值和方法体
+func (c *Checker) checkEnum(scope *fileScope, stmt *parser.EnumStatement) {
+	info := c.classOf(scope, stmt.Name.Value)
+	if info == nil || info.node != stmt {
+		return
+	}
+	c.checkImplementation(info, stmt.Name)
+
+	for _, v := range stmt.Variables {
+		c.checkFieldInit(scope, info, v)
+	}
+	for _, m := range stmt.Methods {
+		c.checkMethod(scope, info, m)
+	}
+}
+
+// checkFieldInit 检查字段的初始值与声明类型是否兼容
+func (c *Checker) checkFieldInit(scope *fileScope, info *classInfo, field *parser.ClassVariable) {
+	if field.Value == nil {
+		return
+	}
+	ctx := &context{scope: scope, class: info, static: field.IsStatic, funcName: "字段初始值", vars: newVarScope(nil)}
+	valueType := c.checkExpression(ctx, field.Value)
+	fieldType := info.fields[field.Name.Value].typ
+	if !c.assignable(fieldType, valueType) {
+		c.errorAt(scope, field.Name, "字段 %s 声明为 %s 类型，不能用 %s 类型的值初始化", field.Name.Value, fieldType, valueType)
+	}
+}
+
+// checkMethod 检查方法体
+func (c *Checker) checkMethod(scope *fileScope, info *classInfo, m *parser.ClassMethod) {
+	if m.Body == nil {
+		return
+	}
+	method := info.methods[m.Name.Value]
+	ctx := &context{
+		scope:      scope,
+		class:      info,
+		static:     m.IsStatic,
+		returnType: method.returnType,
+		funcName:   fmt.Sprintf("方法 %s::%s", info.name, m.Name.Value),
+		vars:       newVarScope(nil),
+	}
+	c.checkFunctionBody(ctx, method.params, m.Body)
+}
+
+// checkFunctionBody 定义参数并检查函数体
+func (c *Checker) checkFunctionBody(ctx *context, params []*paramInfo, body *parser.BlockStatement) {
+	for _, p := range params {
+		if p.variadic {
+			ctx.define(p.name, &Type{Kind: KindArray, Elem: p.typ}, true)
+		} else {
+			ctx.define(p.name, p.typ, true)
+		}
+	}
+	c.checkBlock(ctx, body)
+}
+
+// ========== 语句 ==========
+
+// checkBlock 在新作用域中检查语句块
+func (c *Checker) checkBlock(ctx *context, block *parser.BlockStatement) {
+	if block == nil {
+		return
+	}
+	inner := ctx.child()
+	for _, stmt := range block.Statements {
+		c.checkStatement(inner, stmt)
+	}
+}
+
+// checkStatement 检查单条语句
+func (c *Checker) checkStatement(ctx *context, stmt parser.Statement) {
+	switch s := stmt.(type) {
+	case *parser.LetStatement:
+		c.checkLet(ctx, s)
+
+	case *parser.AssignStatement:
+		valueType := c.checkExpression(ctx, s.Value)
+		if valueType.Kind == KindNull {
+			valueType = unknownType
+		}
+		ctx.define(s.Name.Value, valueType, false)
+
+	case *parser.ReturnStatement:
+		c.checkReturn(ctx, s)
+
+	case *parser.ExpressionStatement:
+		if fn, ok := s.Expression.(*parser.FunctionLiteral); ok && fn.Name != nil {
+			c.checkNamedFunction(ctx, fn)
+			return
+		}
+		c.checkExpression(ctx, s.Expression)
+
+	case *parser.BlockStatement:
+		c.checkBlock(ctx, s)
+
+	case *parser.IfStatement:
+		c.checkIf(ctx, s)
+
+	case *parser.ForStatement:
+		inner := ctx.child()
+		if s.Init != nil {
+			c.checkStatement(inner, s.Init)
+		}
+		if s.Condition != nil {
+			c.checkExpression(inner, s.Condition)
+		}
+		if s.Post != nil {
+			c.checkStatement(inner, s.Post)
+		}
+		c.checkBlock(inner, s.Body)
+
+	case *parser.ForRangeStatement:
+		c.checkForRange(ctx, s)
+
+	case *parser.TryStatement:
+		c.checkBlock(ctx, s.TryBlock)
+		for _, clause := range s.CatchClauses {
+			inner := ctx.child()
+			if clause.ExceptionVar != nil {
+				typ := unknownType
+				if clause.ExceptionType != nil {
+					typ = c.typeFromName(clause.ExceptionType.Value, ctx.scope)
+				}
+				inner.define(clause.ExceptionVar.Value, typ, false)
+			}
+			c.checkBlock(inner, clause.Body)
+		}
+		c.checkBlock(ctx, s.FinallyBlock)
+
+	case *parser.ThrowStatement:
+		c.checkExpression(ctx, s.Value)
+
+	case *parser.GoStatement:
+		c.checkExpression(ctx, s.Call)
+
+	case *parser.SwitchStatement:
+		inner := ctx.child()
+		if s.Init != nil {
+			c.checkStatement(inner, s.Init)
+		}
+		if s.Value != nil {
+			c.checkExpression(inner, s.Value)
+		}
+		for _, clause := range s.Cases {
+			for _, value := range clause.Values {
+				c.checkExpression(inner, value)
+			}
+			if clause.Condition != nil {
+				c.checkExpression(inner, clause.Condition)
+			}
+			c.checkBlock(inner, clause.Body)
+		}
+		c.checkBlock(inner, s.Default)
+
+	case *parser.SelectStatement:
+		// 只检查通道和操作数：after(ms) 不是普通函数调用
+		for _, selectCase := range s.Cases {
+			if selectCase.Channel != nil {
+				c.checkExpression(ctx, selectCase.Channel)
+			}
+			if selectCase.Value != nil {
+				c.checkExpression(ctx, selectCase.Value)
+			}
+			inner := ctx.child()
+			if selectCase.Binding != nil {
+				inner.define(selectCase.Binding.Value, unknownType, false)
+			}
+			c.checkBlock(inner, selectCase.Body)
+		}
+		c.checkBlock(ctx, s.Default)
+
+	case *parser.ClassStatement:
+		c.checkClass(ctx.scope, s)
+
+	case *parser.EnumStatement:
+		c.checkEnum(ctx.scope, s)
+	}
+}
+
+// checkLet 检查变量声明：初始值必须与声明的类型兼容
+func (c *Checker) checkLet(ctx *context, s *parser.LetStatement) {
+	valueType := unknownType
+	if s.Value != nil {
+		valueType = c.checkExpression(ctx, s.Value)
+	}
+	if s.Type == nil {
+		if valueType.Kind == KindNull {
+			valueType = unknownType
+		}
+		ctx.define(s.Name.Value, valueType, false)
+		return
+	}
+
+	declared := c.typeFromExpr(s.Type, ctx.scope)
+	if s.Value != nil && !c.assignable(declared, valueType) {
+		c.errorAt(ctx.scope, s.Name, "不能将 %s 类型的值赋给 %s 类型的变量 %s", valueType, declared, s.Name.Value)
+	}
+	ctx.define(s.Name.Value, declared, true)
+}
+
+// checkReturn 检查返回值与声明的返回类型是否兼容
+func (c *Checker) checkReturn(ctx *context, s *parser.ReturnStatement) {
+	valueType := unknownType
+	if s.ReturnValue != nil {
+		valueType = c.checkExpression(ctx, s.ReturnValue)
+	}
+	if ctx.returnType == nil || s.ReturnValue == nil {
+		return
+	}
+	if ctx.returnType.Kind == KindVoid {
+		c.errorAt(ctx.scope, s, "%s 声明为 void，不能返回值", ctx.funcName)
+		return
+	}
+	if !c.assignable(ctx.returnType, valueType) {
+		c.errorAt(ctx.scope, s, "%s 应返回 %s 类型，实际返回 %s 类型", ctx.funcName, ctx.returnType, valueType)
+	}
+}
+
+// checkIf 检查 if / else if / else 链
+func (c *Checker) checkIf(ctx *context, s *parser.IfStatement) {
+	for stmt := s; stmt != nil; stmt = stmt.ElseIf {
+		c.checkExpression(ctx, stmt.Condition)
+		c.checkBlock(ctx, stmt.Consequence)
+		if stmt.ElseIf == nil {
+			c.checkBlock(ctx, stmt.Alternative)
+		}
+	}
+}
+
+// checkForRange 检查 for range 循环，根据集合类型推导键和值的类型
+func (c *Checker) checkForRange(ctx *context, s *parser.ForRangeStatement) {
+	iterable := c.checkExpression(ctx, s.Iterable)
+	keyType, valueType := unknownType, unknownType
+	switch iterable.Kind {
+	case KindArray:
+		keyType, valueType = intType, iterable.Elem
+	case KindMap:
+		keyType, valueType = iterable.Key, iterable.Value
+	case KindString:
+		keyType = intType
+	}
+
+	inner := ctx.child()
+	if s.Key != nil {
+		inner.define(s.Key.Value, keyType, false)
+	}
+	if s.Value != nil {
+		inner.define(s.Value.Value, valueType, false)
+	}
+	c.checkBlock(inner, s.Body)
+}
+
+// checkNamedFunction 检查具名函数的函数体
+func (c *Checker) checkNamedFunction(ctx *context, fn *parser.FunctionLiteral) {
+	info := ctx.scope.functions[fn.Name.Value]
+	if info == nil || info.node != fn {
+		// 嵌套的具名函数
+		info = &funcInfo{
+			name:       fn.Name.Value,
+			node:       fn,
+			params:     c.paramsFromNodes(fn.Parameters, ctx.scope),
+			returnType: c.returnTypeFromNodes(fn.ReturnType, ctx.scope),
+		}
+		ctx.define(fn.Name.Value, functionType, false)
+	}
+	inner := ctx.child()
+	inner.returnType = info.returnType
+	inner.funcName = "函数 " + fn.Name.Value
+	c.checkFunctionBody(inner, info.params, fn.Body)
+}
@@ -0,0 +1,275 @@
+package checker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/tangzhangming/longlang/internal/config"
+	"github.com/tangzhangming/longlang/internal/diagnostic"
+	"github.com/tangzhangming/longlang/internal/interpreter"
+	"github.com/tangzhangming/longlang/internal/lexer"
+	"github.com/tangzhangming/longlang/internal/parser"
+)
+
+// ========== 检查器 ==========
+
+// Checker 静态类型检查器
+// 在执行之前检查源文件：沿 use 导入关系加载依赖的类，报告类型不匹配、
+// 未定义的方法或字段、参数个数错误以及未实现的接口方法
+// 检查是保守的：无法静态确定的类型一律视为兼容，只报告确定的错误
+type Checker struct {
+	stdlibPath    string                // 标准库路径
+	projectRoot   string                // 项目根目录
+	projectConfig *config.ProjectConfig // 项目配置（可为 nil）
+
+	files   map[string]*fileScope // 已加载的文件（按路径）
+	classes map[string]*classInfo // 已知的类、接口和枚举（按完整名称）
+	tried   map[string]bool       // 已尝试按命名空间加载的类（完整名称）
+	diags   diagnostic.List       // 检查结果
+}
+
+// New 创建类型检查器
+func New(stdlibPath, projectRoot string, projectConfig *config.ProjectConfig) *Checker {
+	return &Checker{
+		stdlibPath:    stdlibPath,
+		projectRoot:   projectRoot,
+		projectConfig: projectConfig,
+		files:         make(map[string]*fileScope),
+		classes:       make(map[string]*classInfo),
+		tried:         make(map[string]bool),
+	}
+}
+
+// CheckFiles 检查指定的文件，返回所有诊断信息（没有错误时为空）
+// 通过 use 加载的依赖文件只用于解析类型，不报告其中的类型错误（语法错误除外）
+func (c *Checker) CheckFiles(paths []string) diagnostic.List {
+	var scopes []*fileScope
+	for _, path := range paths {
+		scope, err := c.loadFile(path)
+		if err != nil {
+			c.diags = append(c.diags, diagnostic.New(path, 0, 0, "%s", err))
+			continue
+		}
+		if scope != nil {
+			scopes = append(scopes, scope)
+		}
+	}
+	for _, scope := range scopes {
+		c.checkFile(scope)
+	}
+	c.diags.Sort()
+	return c.diags
+}
+
+// errorAt 在节点位置报告错误
+func (c *Checker) errorAt(scope *fileScope, node parser.Node, format string, args ...interface{}) {
+	line, col := parser.NodePosition(node)
+	c.diags = append(c.diags, diagnostic.New(scope.path, line, col, format, args...))
+}
+
+// ========== 文件与命名空间 ==========
+
+// fileScope 一个源文件的解析结果与名称环境
+type fileScope struct {
+	path      string               // 文件路径
+	program   *parser.Program      // 语法树
+	namespace string               // 命名空间（已按 root_namespace 解析）
+	stdlib    bool                 // 是否是标准库文件
+	imports   map[string]string    // use 导入：本地名称 -> 完整名称
+	functions map[string]*funcInfo // 顶层函数
+}
+
+// funcInfo 顶层函数签名
+type funcInfo struct {
+	name       string
+	node       *parser.FunctionLiteral
+	params     []*paramInfo
+	returnType *Type // 未声明返回类型时为 nil
+}
+
+// paramInfo 参数签名
+type paramInfo struct {
+	name     string
+	typ      *Type
+	optional bool // 有默认值
+	variadic bool // 可变参数（typ 为元素类型）
+}
+
+// loadFile 解析文件并登记其中的类和函数；已加载的文件直接返回
+// 有语法错误时报告诊断信息并返回 nil
+func (c *Checker) loadFile(path string) (*fileScope, error) {
+	if abs, err := filepath.Abs(path); err == nil {
+		if scope, ok := c.files[abs]; ok {
+			return scope, nil
+		}
+		c.files[abs] = nil
+		scope, err := c.parseFile(path)
+		c.files[abs] = scope
+		return scope, err
+	}
+	return c.parseFile(path)
+}
+
+// parseFile 解析文件，登记声明并处理 use 导入
+func (c *Checker) parseFile(path string) (*fileScope, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	l := lexer.NewFromFile(string(content), path)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if diags := p.Diagnostics(); len(diags) != 0 {
+		c.diags = append(c.diags, diags...)
+		return nil, nil
+	}
+
+	scope := &fileScope{
+		path:      path,
+		program:   program,
+		stdlib:    c.isStdlibFile(path),
+		imports:   make(map[string]string),
+		functions: make(map[string]*funcInfo),
+	}
+
+	// 先登记本文件的声明，use 导入的文件可能反过来引用它们
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *parser.NamespaceStatement:
+			scope.namespace = s.Name.Value
+			if cfg := scope.config(c); cfg != nil {
+				scope.namespace = cfg.ResolveNamespace(scope.namespace)
+			}
+		case *parser.ClassStatement:
+			c.declare(scope, s.Name, classKindClass, s)
+		case *parser.InterfaceStatement:
+			c.declare(scope, s.Name, classKindInterface, s)
+		case *parser.EnumStatement:
+			c.declare(scope, s.Name, classKindEnum, s)
+		case *parser.ExpressionStatement:
+			if fn, ok := s.Expression.(*parser.FunctionLiteral); ok && fn.Name != nil {
+				scope.functions[fn.Name.Value] = &funcInfo{name: fn.Name.Value, node: fn}
+			}
+		}
+	}
+
+	for _, stmt := range program.Statements {
+		if use, ok := stmt.(*parser.UseStatement); ok {
+			c.processUse(scope, use)
+		}
+	}
+	return scope, nil
+}
+
+// config 返回文件使用的项目配置（标准库文件不使用项目配置）
+func (s *fileScope) config(c *Checker) *config.ProjectConfig {
+	if s.stdlib {
+		return nil
+	}
+	return c.projectConfig
+}
+
+// isStdlibFile 检查路径是否位于标准库目录
+func (c *Checker) isStdlibFile(path string) bool {
+	if c.stdlibPath == "" {
+		return false
+	}
+	absPath, err1 := filepath.Abs(path)
+	absStdlib, err2 := filepath.Abs(c.stdlibPath)
+	if err1 != nil || err2 != nil {
+		return strings.HasPrefix(path, c.stdlibPath)
+	}
+	return strings.HasPrefix(absPath, absStdlib+string(filepath.Separator))
+}
+
+// processUse 处理 use 导入：加载对应文件并记录本地名称
+func (c *Checker) processUse(scope *fileScope, use *parser.UseStatement) {
+	fullPath := use.Path.Value
+	localName := shortName(fullPath)
+	if use.Alias != nil {
+		localName = use.Alias.Value
+	}
+
+	namespace, className, err := interpreter.ResolveClassName(fullPath)
+	if err != nil {
+		c.errorAt(scope, use, "无效的 use 路径: %s", fullPath)
+		return
+	}
+
+	// 与虚拟机一致：先按原始命名空间查找，再按 root_namespace 解析后的命名空间查找
+	candidates := []string{fullPath}
+	if cfg := scope.config(c); cfg != nil {
+		if resolved := cfg.ResolveNamespace(namespace); resolved != namespace {
+			candidates = append(candidates, resolved+"."+className)
+		}
+	}
+	for _, candidate := range candidates {
+		if c.lookupClass(scope, candidate) != nil {
+			scope.imports[localName] = candidate
+			return
+		}
+	}
+
+	// 找不到类（可能是注解或找不到文件），记录原始名称，使用处按未知类型处理
+	scope.imports[localName] = fullPath
+}
+
+// lookupClass 按完整名称查找类，找不到时按命名空间路径加载对应文件
+func (c *Checker) lookupClass(scope *fileScope, fullName string) *classInfo {
+	if info, ok := c.classes[fullName]; ok {
+		return info
+	}
+	if c.tried[fullName] {
+		return nil
+	}
+	c.tried[fullName] = true
+
+	namespace, className, err := interpreter.ResolveClassName(fullName)
+	if err != nil {
+		return nil
+	}
+	for _, path := range config.NamespaceFilePaths(c.projectRoot, scope.config(c), c.stdlibPath, namespace, className) {
+		if _, statErr := ioutil.ReadFile(path); statErr != nil {
+			continue
+		}
+		if _, loadErr := c.loadFile(path); loadErr != nil {
+			return nil
+		}
+		break
+	}
+	return c.classes[fullName]
+}
+
+// resolveClass 在文件的名称环境中解析类名，找不到时返回 nil
+// 查找顺序：完整名称、use 导入、当前命名空间、全局命名空间
+func (c *Checker) resolveClass(name string, scope *fileScope) *classInfo {
+	if name == "" || scope == nil {
+		return nil
+	}
+	if strings.Contains(name, ".") {
+		return c.lookupClass(scope, name)
+	}
+	if fullName, ok := scope.imports[name]; ok {
+		return c.classes[fullName]
+	}
+	if scope.namespace != "" {
+		if info := c.lookupClass(scope, scope.namespace+"."+name); info != nil {
+			return info
+		}
+	}
+	return c.classes[name]
+}
+
+// knownClassName 检查任何已加载的文件中是否声明过该简单名称的类
+// 虚拟机的 use 导入是全局的，其他文件导入的类在运行时也可能可见
+func (c *Checker) knownClassName(name string) bool {
+	for _, info := range c.classes {
+		if info.name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,250 @@
+package checker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// stdlibPath 测试在包目录下执行，标准库位于仓库根目录
+const stdlibPath = "../../stdlib"
+
+// checkSources 将源文件写入临时目录并检查，返回 "文件:行:列: 消息" 形式的诊断
+// 消息中出现的临时目录路径会被去掉
+func checkSources(t *testing.T, files map[string]string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var result []string
+	for _, d := range New(stdlibPath, dir, nil).CheckFiles(paths) {
+		message := strings.ReplaceAll(d.Message, dir+string(filepath.Separator), "")
+		result = append(result, fmt.Sprintf("%s:%d:%d: %s", filepath.Base(d.File), d.Line, d.Column, message))
+	}
+	return result
+}
+
+func TestDiagnostics(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "没有错误",
+			files: map[string]string{"main.long": `class Point {
+    public x int = 0
+
+    public function __construct(x: int) {
+        this.x = x
+    }
+
+    public function double() int {
+        return this.x * 2
+    }
+}
+
+p := new Point(1)
+var n int = p.double()
+var f float = 1
+`},
+		},
+		{
+			name: "变量声明和赋值",
+			files: map[string]string{"main.long": `var n int = "hello"
+var s string = "ok"
+s = 1
+`},
+			want: []string{
+				"main.long:1:5: 不能将 string 类型的值赋给 int 类型的变量 n",
+				"main.long:3:1: 不能将 int 类型的值赋给 string 类型的变量 s",
+			},
+		},
+		{
+			name: "字段初始值和字段赋值",
+			files: map[string]string{"main.long": `class Config {
+    public port int = "80"
+    public static host string = "localhost"
+}
+
+c := new Config()
+c.port = "8080"
+Config::host = 1
+`},
+			want: []string{
+				"main.long:2:12: 字段 port 声明为 int 类型，不能用 string 类型的值初始化",
+				"main.long:7:3: 不能将 string 类型的值赋给 int 类型的字段 port",
+				"main.long:8:9: 不能将 int 类型的值赋给 string 类型的静态字段 host",
+			},
+		},
+		{
+			name: "返回值",
+			files: map[string]string{"main.long": `class Greeter {
+    public function name() string {
+        return 1
+    }
+
+    public function log() void {
+        return "done"
+    }
+}
+`},
+			want: []string{
+				"main.long:3:9: 方法 Greeter::name 应返回 string 类型，实际返回 int 类型",
+				"main.long:7:9: 方法 Greeter::log 声明为 void，不能返回值",
+			},
+		},
+		{
+			name: "调用参数",
+			files: map[string]string{"main.long": `function add(a: int, b: int) int {
+    return a + b
+}
+
+add(1, "two")
+add(1)
+add(1, 2, 3)
+add(b: 2)
+add(a: 1, b: 2, c: 3)
+`},
+			want: []string{
+				"main.long:5:8: 函数 add 的参数 b 需要 int 类型，实际传入 string 类型",
+				"main.long:6:4: 函数 add 需要 2 个参数，实际传入 1 个",
+				"main.long:7:4: 函数 add 需要 2 个参数，实际传入 3 个",
+				"main.long:8:4: 函数 add 缺少参数 a",
+				"main.long:9:17: 函数 add 没有名为 c 的参数",
+			},
+		},
+		{
+			name: "未定义的成员",
+			files: map[string]string{"main.long": `class Box {
+    public const SIZE = 1
+    public value int = 0
+
+    public static function make() Box {
+        return new Box()
+    }
+}
+
+b := Box::make()
+b.open()
+b.missing
+Box::create()
+Box::COLOR
+`},
+			want: []string{
+				"main.long:11:3: 类 Box 没有方法 open",
+				"main.long:12:3: 类 Box 没有字段或方法 missing",
+				"main.long:13:6: 类 Box 没有静态方法 create",
+				"main.long:14:6: 类 Box 没有常量或静态字段 COLOR",
+			},
+		},
+		{
+			name: "数组和 Map 元素",
+			files: map[string]string{"main.long": `items := []int{1, "x"}
+ages := map[string]int{"a": "b"}
+`},
+			want: []string{
+				"main.long:1:19: 数组元素需要 int 类型，实际为 string 类型",
+				"main.long:2:29: Map 的值需要 int 类型，实际为 string 类型",
+			},
+		},
+		{
+			name: "接口和抽象方法",
+			files: map[string]string{"main.long": `interface Shape {
+    function area() int
+}
+
+abstract class Base {
+    abstract public function name() string
+}
+
+class Circle implements Shape {
+}
+
+class Square extends Base {
+}
+`},
+			want: []string{
+				"main.long:9:7: 类 Circle 没有实现接口 Shape 的方法 area",
+				"main.long:12:7: 类 Square 没有实现父类 Base 的抽象方法 name",
+			},
+		},
+		{
+			name: "实例化",
+			files: map[string]string{"main.long": `interface Shape {
+    function area() int
+}
+
+abstract class Base {
+}
+
+enum Color {
+    Red
+}
+
+class Plain {
+}
+
+new Shape()
+new Base()
+new Color()
+new Missing()
+new Plain(1)
+Color::Blue
+`},
+			want: []string{
+				"main.long:15:5: 不能实例化接口 Shape",
+				"main.long:16:5: 不能实例化抽象类 Base",
+				"main.long:17:5: 不能实例化枚举 Color",
+				"main.long:18:5: 未定义的类 Missing",
+				"main.long:19:1: 类 Plain 没有构造方法，不接受参数，实际传入 1 个",
+				"main.long:20:8: 枚举 Color 没有常量或静态字段 Blue",
+			},
+		},
+		{
+			// 两个文件在同一命名空间中声明同名类：报告重复声明，不能崩溃
+			name: "重复声明",
+			files: map[string]string{
+				"a.long": `namespace App
+
+class Base {
+    public function name() string {
+        return "a"
+    }
+}
+`,
+				"b.long": `namespace App
+
+class Base {
+    public function label() string {
+        return 1
+    }
+}
+`,
+			},
+			want: []string{
+				"b.long:3:7: 类 App.Base 重复声明（已在 a.long:3 声明为类）",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkSources(t, tt.files)
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("诊断不一致:\n--- 期望\n%s\n--- 实际\n%s", strings.Join(tt.want, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
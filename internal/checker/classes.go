@@ -0,0 +1,388 @@
+package checker
+
+import (
+	"github.com/tangzhangming/longlang/internal/parser"
+)
+
+// ========== 类信息 ==========
+
+// classKind 类的种类
+type classKind int
+
+const (
+	classKindClass     classKind = iota // 类
+	classKindInterface                  // 接口
+	classKindEnum                       // 枚举
+)
+
+// String 返回种类的显示名称
+func (k classKind) String() string {
+	switch k {
+	case classKindInterface:
+		return "接口"
+	case classKindEnum:
+		return "枚举"
+	default:
+		return "类"
+	}
+}
+
+// classInfo 类、接口或枚举的静态信息
+// 父类、接口和成员在首次使用时解析（见 members）
+type classInfo struct {
+	fullName   string      // 完整名称（命名空间.类名）
+	name       string      // 简单名称
+	kind       classKind   // 种类
+	scope      *fileScope  // 声明所在的文件
+	node       parser.Node // 声明语句
+	isAbstract bool        // 是否是抽象类
+	resolved   bool        // 成员是否已解析
+
+	parent     *classInfo   // 父类（未声明或无法解析时为 nil）
+	interfaces []*classInfo // 实现的接口（只含能解析的）
+	incomplete bool         // 父类或某个接口无法解析，继承关系不完整
+
+	fields    map[string]*fieldInfo  // 字段
+	methods   map[string]*methodInfo // 方法
+	constants map[string]*Type       // 常量
+	members   map[string]bool        // 枚举成员
+}
+
+// fieldInfo 字段信息
+type fieldInfo struct {
+	typ    *Type
+	static bool
+}
+
+// methodInfo 方法信息
+type methodInfo struct {
+	name       string
+	owner      *classInfo
+	params     []*paramInfo
+	returnType *Type // 未声明返回类型时为 nil
+	static     bool
+	abstract   bool
+}
+
+// 枚举值的内置实例方法和枚举类型的内置静态方法
+var (
+	enumInstanceMethods = map[string]bool{"name": true, "ordinal": true, "value": true}
+	enumStaticMethods   = map[string]bool{"cases": true, "from": true, "tryFrom": true, "valueOf": true, "count": true}
+)
+
+// declare 登记文件中声明的类、接口或枚举
+// 同一命名空间中重复声明的名称报告错误，保留先登记的声明
+func (c *Checker) declare(scope *fileScope, ident *parser.Identifier, kind classKind, node parser.Node) {
+	name := ident.Value
+	fullName := name
+	if scope.namespace != "" {
+		fullName = scope.namespace + "." + name
+	}
+	if existing, ok := c.classes[fullName]; ok {
+		line, _ := parser.NodePosition(existing.node)
+		c.errorAt(scope, ident, "%s %s 重复声明（已在 %s:%d 声明为%s）", kind, fullName, existing.scope.path, line, existing.kind)
+		return
+	}
+	info := &classInfo{fullName: fullName, name: name, kind: kind, scope: scope, node: node}
+	if class, ok := node.(*parser.ClassStatement); ok {
+		info.isAbstract = class.IsAbstract
+	}
+	c.classes[fullName] = info
+}
+
+// members 解析类的父类、接口和成员（只解析一次）
+func (c *Checker) members(info *classInfo) *classInfo {
+	if info == nil || info.resolved {
+		return info
+	}
+	info.resolved = true
+	info.fields = make(map[string]*fieldInfo)
+	info.methods = make(map[string]*methodInfo)
+	info.constants = make(map[string]*Type)
+	info.members = make(map[string]bool)
+
+	var interfaces []*parser.Identifier
+	switch node := info.node.(type) {
+	case *parser.ClassStatement:
+		if node.Parent != nil {
+			info.parent = c.resolveClass(node.Parent.Value, info.scope)
+			if info.parent == nil {
+				info.incomplete = true
+			}
+		}
+		interfaces = node.Interfaces
+		for _, member := range node.Members {
+			switch m := member.(type) {
+			case *parser.ClassVariable:
+				info.fields[m.Name.Value] = &fieldInfo{typ: c.declaredType(m.Type, info.scope), static: m.IsStatic}
+			case *parser.ClassConstant:
+				typ := unknownType
+				if m.Type != nil {
+					typ = c.typeFromName(m.Type.Value, info.scope)
+				}
+				info.constants[m.Name.Value] = typ
+			case *parser.ClassMethod:
+				info.methods[m.Name.Value] = c.methodFromNode(info, m)
+			}
+		}
+	case *parser.InterfaceStatement:
+		for _, m := range node.Methods {
+			info.methods[m.Name.Value] = &methodInfo{
+				name:       m.Name.Value,
+				owner:      info,
+				params:     c.paramsFromNodes(m.Parameters, info.scope),
+				returnType: c.returnTypeFromNodes(m.ReturnType, info.scope),
+				abstract:   true,
+			}
+		}
+	case *parser.EnumStatement:
+		interfaces = node.Interfaces
+		for _, m := range node.Members {
+			info.members[m.Name.Value] = true
+		}
+		for _, v := range node.Variables {
+			info.fields[v.Name.Value] = &fieldInfo{typ: c.declaredType(v.Type, info.scope), static: v.IsStatic}
+		}
+		for _, m := range node.Methods {
+			info.methods[m.Name.Value] = c.methodFromNode(info, m)
+		}
+	}
+
+	for _, iface := range interfaces {
+		resolved := c.resolveClass(iface.Value, info.scope)
+		if resolved == nil {
+			info.incomplete = true
+			continue
+		}
+		info.interfaces = append(info.interfaces, resolved)
+	}
+	return info
+}
+
+// methodFromNode 从方法声明构造方法信息
+func (c *Checker) methodFromNode(owner *classInfo, m *parser.ClassMethod) *methodInfo {
+	return &methodInfo{
+		name:       m.Name.Value,
+		owner:      owner,
+		params:     c.paramsFromNodes(m.Parameters, owner.scope),
+		returnType: c.returnTypeFromNodes(m.ReturnType, owner.scope),
+		static:     m.IsStatic,
+		abstract:   m.IsAbstract || m.Body == nil,
+	}
+}
+
+// paramsFromNodes 从参数声明构造参数签名
+func (c *Checker) paramsFromNodes(params []*parser.FunctionParameter, scope *fileScope) []*paramInfo {
+	result := make([]*paramInfo, 0, len(params))
+	for _, p := range params {
+		result = append(result, &paramInfo{
+			name:     p.Name.Value,
+			typ:      c.declaredType(p.Type, scope),
+			optional: p.DefaultValue != nil,
+			variadic: p.IsVariadic,
+		})
+	}
+	return result
+}
+
+// returnTypeFromNodes 解析返回类型声明：未声明时返回 nil，多返回值不做检查
+func (c *Checker) returnTypeFromNodes(types []*parser.Identifier, scope *fileScope) *Type {
+	switch len(types) {
+	case 0:
+		return nil
+	case 1:
+		return c.typeFromName(types[0].Value, scope)
+	}
+	return unknownType
+}
+
+// declaredType 解析可选的类型标注，未标注时为 unknown
+func (c *Checker) declaredType(ident *parser.Identifier, scope *fileScope) *Type {
+	if ident == nil {
+		return unknownType
+	}
+	return c.typeFromName(ident.Value, scope)
+}
+
+// ========== 成员查找 ==========
+
+// complete 检查类的整个继承链是否都能解析（否则无法断定成员不存在）
+func (c *Checker) complete(info *classInfo) bool {
+	visited := map[*classInfo]bool{}
+	var walk func(*classInfo) bool
+	walk = func(ci *classInfo) bool {
+		if ci == nil || visited[ci] {
+			return true
+		}
+		visited[ci] = true
+		c.members(ci)
+		if ci.incomplete {
+			return false
+		}
+		if !walk(ci.parent) {
+			return false
+		}
+		for _, iface := range ci.interfaces {
+			if !walk(iface) {
+				return false
+			}
+		}
+		return true
+	}
+	return walk(info)
+}
+
+// findMethod 沿继承链查找方法（接口和抽象类还会查找实现的接口）
+func (c *Checker) findMethod(info *classInfo, name string) *methodInfo {
+	visited := map[*classInfo]bool{}
+	var walk func(*classInfo) *methodInfo
+	walk = func(ci *classInfo) *methodInfo {
+		if ci == nil || visited[ci] {
+			return nil
+		}
+		visited[ci] = true
+		c.members(ci)
+		if m, ok := ci.methods[name]; ok {
+			return m
+		}
+		if m := walk(ci.parent); m != nil {
+			return m
+		}
+		for _, iface := range ci.interfaces {
+			if m := walk(iface); m != nil {
+				return m
+			}
+		}
+		return nil
+	}
+	return walk(info)
+}
+
+// findField 沿继承链查找字段
+func (c *Checker) findField(info *classInfo, name string) *fieldInfo {
+	for _, ci := range c.chain(info) {
+		if f, ok := ci.fields[name]; ok {
+			return f
+		}
+	}
+	return nil
+}
+
+// chain 返回类本身及其所有父类（已解析成员，防止循环继承）
+func (c *Checker) chain(info *classInfo) []*classInfo {
+	var result []*classInfo
+	visited := map[*classInfo]bool{}
+	for ci := info; ci != nil && !visited[ci]; ci = ci.parent {
+		visited[ci] = true
+		result = append(result, c.members(ci))
+	}
+	return result
+}
+
+// findConstant 沿继承链和接口查找常量
+func (c *Checker) findConstant(info *classInfo, name string) (*Type, bool) {
+	visited := map[*classInfo]bool{}
+	var walk func(*classInfo) (*Type, bool)
+	walk = func(ci *classInfo) (*Type, bool) {
+		if ci == nil || visited[ci] {
+			return nil, false
+		}
+		visited[ci] = true
+		c.members(ci)
+		if t, ok := ci.constants[name]; ok {
+			return t, true
+		}
+		if t, ok := walk(ci.parent); ok {
+			return t, true
+		}
+		for _, iface := range ci.interfaces {
+			if t, ok := walk(iface); ok {
+				return t, true
+			}
+		}
+		return nil, false
+	}
+	return walk(info)
+}
+
+// ========== 接口实现检查 ==========
+
+// checkImplementation 检查非抽象类（或枚举）是否实现了所有接口方法和继承的抽象方法
+// 继承链不完整时无法断定，不做检查
+func (c *Checker) checkImplementation(info *classInfo, nameNode parser.Node) {
+	if info.isAbstract || info.kind == classKindInterface || !c.complete(info) {
+		return
+	}
+
+	reported := map[string]bool{}
+	visited := map[*classInfo]bool{}
+	var required func(ci *classInfo)
+	required = func(ci *classInfo) {
+		if visited[ci] {
+			return
+		}
+		visited[ci] = true
+		for _, iface := range ci.interfaces {
+			c.members(iface)
+			for _, name := range sortedMethodNames(iface) {
+				if reported[name] {
+					continue
+				}
+				if impl := c.findConcreteMethod(info, name); impl == nil {
+					reported[name] = true
+					c.errorAt(info.scope, nameNode, "%s %s 没有实现接口 %s 的方法 %s", info.kind, info.name, iface.name, name)
+				}
+			}
+			required(iface)
+		}
+		if ci.parent != nil {
+			for _, name := range sortedMethodNames(ci.parent) {
+				m := ci.parent.methods[name]
+				if !m.abstract || reported[name] {
+					continue
+				}
+				if impl := c.findConcreteMethod(info, name); impl == nil {
+					reported[name] = true
+					c.errorAt(info.scope, nameNode, "类 %s 没有实现父类 %s 的抽象方法 %s", info.name, ci.parent.name, name)
+				}
+			}
+			required(ci.parent)
+		}
+	}
+	required(info)
+}
+
+// findConcreteMethod 沿继承链查找有方法体的方法（枚举的内置方法也算）
+func (c *Checker) findConcreteMethod(info *classInfo, name string) *methodInfo {
+	for _, ci := range c.chain(info) {
+		if m, ok := ci.methods[name]; ok && !m.abstract {
+			return m
+		}
+		if ci.kind == classKindEnum && enumInstanceMethods[name] {
+			return &methodInfo{name: name, owner: ci}
+		}
+	}
+	return nil
+}
+
+// sortedMethodNames 按声明顺序返回类或接口的方法名（保证报告顺序稳定）
+func sortedMethodNames(info *classInfo) []string {
+	var names []string
+	switch node := info.node.(type) {
+	case *parser.ClassStatement:
+		for _, member := range node.Members {
+			if m, ok := member.(*parser.ClassMethod); ok {
+				names = append(names, m.Name.Value)
+			}
+		}
+	case *parser.InterfaceStatement:
+		for _, m := range node.Methods {
+			names = append(names, m.Name.Value)
+		}
+	case *parser.EnumStatement:
+		for _, m := range node.Methods {
+			names = append(names, m.Name.Value)
+		}
+	}
+	return names
+}
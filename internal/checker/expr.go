@@ -0,0 +1,560 @@
+package checker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tangzhangming/longlang/internal/parser"
+)
+
+// ========== 表达式 ==========
+
+// checkExpression 检查表达式并推导其静态类型（无法确定时为 unknown）
+func (c *Checker) checkExpression(ctx *context, expr parser.Expression) *Type {
+	switch e := expr.(type) {
+	case nil:
+		return unknownType
+	case *parser.IntegerLiteral:
+		return intType
+	case *parser.FloatLiteral:
+		return floatType
+	case *parser.StringLiteral:
+		return stringType
+	case *parser.InterpolatedStringLiteral:
+		for _, part := range e.Parts {
+			if part.IsExpr {
+				c.checkExpression(ctx, part.Expr)
+			}
+		}
+		return stringType
+	case *parser.BooleanLiteral:
+		return boolType
+	case *parser.NullLiteral:
+		return nullType
+	case *parser.ClassLiteralExpression:
+		return stringType
+
+	case *parser.Identifier:
+		if v := ctx.vars.lookup(e.Value); v != nil {
+			return v.typ
+		}
+		if _, ok := ctx.scope.functions[e.Value]; ok {
+			return functionType
+		}
+		return unknownType
+
+	case *parser.ThisExpression:
+		if ctx.class != nil && !ctx.static {
+			return &Type{Kind: KindClass, Name: ctx.class.fullName}
+		}
+		return unknownType
+
+	case *parser.PrefixExpression:
+		operand := c.checkExpression(ctx, e.Right)
+		switch e.Operator {
+		case "!":
+			return boolType
+		case "-":
+			if operand.isNumeric() {
+				return operand
+			}
+		}
+		return unknownType
+
+	case *parser.InfixExpression:
+		return c.checkInfix(ctx, e)
+
+	case *parser.TernaryExpression:
+		c.checkExpression(ctx, e.Condition)
+		trueType := c.checkExpression(ctx, e.TrueExpr)
+		falseType := c.checkExpression(ctx, e.FalseExpr)
+		if trueType.known() && falseType.known() && trueType.String() == falseType.String() {
+			return trueType
+		}
+		return unknownType
+
+	case *parser.TypeAssertionExpression:
+		c.checkExpression(ctx, e.Left)
+		return c.typeFromExpr(e.TargetType, ctx.scope)
+
+	case *parser.FunctionLiteral:
+		inner := ctx.child()
+		inner.returnType = c.returnTypeFromNodes(e.ReturnType, ctx.scope)
+		inner.funcName = "闭包"
+		if e.Name != nil {
+			inner.funcName = "函数 " + e.Name.Value
+		}
+		c.checkFunctionBody(inner, c.paramsFromNodes(e.Parameters, ctx.scope), e.Body)
+		return functionType
+
+	case *parser.CallExpression:
+		return c.checkCall(ctx, e)
+
+	case *parser.NewExpression:
+		return c.checkNew(ctx, e)
+
+	case *parser.StaticCallExpression:
+		return c.checkStaticCall(ctx, e)
+
+	case *parser.StaticAccessExpression:
+		return c.checkStaticAccess(ctx, e)
+
+	case *parser.EnumAccessExpression:
+		info := c.members(c.resolveClass(e.EnumName.Value, ctx.scope))
+		if info == nil {
+			return unknownType
+		}
+		if info.kind == classKindEnum && !info.members[e.Member.Value] {
+			c.errorAt(ctx.scope, e.Member, "枚举 %s 没有成员 %s", info.name, e.Member.Value)
+		}
+		return &Type{Kind: KindClass, Name: info.fullName}
+
+	case *parser.MemberAccessExpression:
+		return c.checkMemberAccess(ctx, e)
+
+	case *parser.AssignmentExpression:
+		return c.checkAssignment(ctx, e)
+
+	case *parser.CompoundAssignmentExpression:
+		c.checkExpression(ctx, e.Left)
+		c.checkExpression(ctx, e.Right)
+		return unknownType
+
+	case *parser.IndexExpression:
+		left := c.checkExpression(ctx, e.Left)
+		c.checkExpression(ctx, e.Index)
+		switch left.Kind {
+		case KindArray:
+			return left.Elem
+		case KindMap:
+			return left.Value
+		}
+		return unknownType
+
+	case *parser.SliceExpression:
+		left := c.checkExpression(ctx, e.Left)
+		c.checkExpression(ctx, e.Start)
+		c.checkExpression(ctx, e.End)
+		if left.Kind == KindArray || left.Kind == KindString {
+			return left
+		}
+		return unknownType
+
+	case *parser.ArrayLiteral:
+		for _, element := range e.Elements {
+			c.checkExpression(ctx, element)
+		}
+		return &Type{Kind: KindArray, Elem: unknownType}
+
+	case *parser.TypedArrayLiteral:
+		arrayType := c.typeFromExpr(e.Type, ctx.scope)
+		for _, element := range e.Elements {
+			elemType := c.checkExpression(ctx, element)
+			if arrayType.Kind == KindArray && !c.assignable(arrayType.Elem, elemType) {
+				c.errorAt(ctx.scope, element, "数组元素需要 %s 类型，实际为 %s 类型", arrayType.Elem, elemType)
+			}
+		}
+		return arrayType
+
+	case *parser.MapLiteral:
+		return c.checkMapLiteral(ctx, e)
+
+	case *parser.GoExpression:
+		c.checkExpression(ctx, e.Call)
+		return unknownType
+
+	case *parser.MatchExpression:
+		c.checkExpression(ctx, e.Value)
+		for _, arm := range e.Arms {
+			inner := ctx.child()
+			for _, pattern := range arm.Patterns {
+				c.checkExpression(inner, pattern)
+			}
+			if arm.Binding != nil {
+				inner.define(arm.Binding.Value, unknownType, false)
+			}
+			c.checkExpression(inner, arm.Guard)
+			c.checkExpression(inner, arm.Result)
+			c.checkBlock(inner, arm.Body)
+		}
+		return unknownType
+	}
+	return unknownType
+}
+
+// checkInfix 检查中缀表达式并推导结果类型
+func (c *Checker) checkInfix(ctx *context, e *parser.InfixExpression) *Type {
+	left := c.checkExpression(ctx, e.Left)
+	right := c.checkExpression(ctx, e.Right)
+	switch e.Operator {
+	case "==", "!=", "<", ">", "<=", ">=", "&&", "||", "instanceof":
+		return boolType
+	case "+":
+		if left.Kind == KindString || right.Kind == KindString {
+			return stringType
+		}
+		fallthrough
+	case "-", "*", "/":
+		if left.Kind == KindInt && right.Kind == KindInt {
+			return intType
+		}
+		if left.isNumeric() && right.isNumeric() {
+			return floatType
+		}
+	case "%", "&", "|", "^", "<<", ">>":
+		if left.Kind == KindInt && right.Kind == KindInt {
+			return intType
+		}
+	}
+	return unknownType
+}
+
+// checkMapLiteral 检查 Map 字面量的键和值类型
+func (c *Checker) checkMapLiteral(ctx *context, e *parser.MapLiteral) *Type {
+	mapType := &Type{Kind: KindMap, Key: unknownType, Value: unknownType}
+	if e.Type != nil {
+		mapType = c.typeFromExpr(e.Type, ctx.scope)
+	}
+	for i, key := range e.Keys {
+		keyType := c.checkExpression(ctx, key)
+		if !c.assignable(mapType.Key, keyType) {
+			c.errorAt(ctx.scope, key, "Map 的键需要 %s 类型，实际为 %s 类型", mapType.Key, keyType)
+		}
+		if i < len(e.Values) {
+			valueType := c.checkExpression(ctx, e.Values[i])
+			if !c.assignable(mapType.Value, valueType) {
+				c.errorAt(ctx.scope, e.Values[i], "Map 的值需要 %s 类型，实际为 %s 类型", mapType.Value, valueType)
+			}
+		}
+	}
+	return mapType
+}
+
+// ========== 赋值 ==========
+
+// checkAssignment 检查赋值：变量、字段、静态字段和数组元素的类型必须兼容
+func (c *Checker) checkAssignment(ctx *context, e *parser.AssignmentExpression) *Type {
+	valueType := c.checkExpression(ctx, e.Right)
+
+	switch left := e.Left.(type) {
+	case *parser.Identifier:
+		v := ctx.vars.lookup(left.Value)
+		if v == nil {
+			return valueType
+		}
+		if v.declared {
+			if !c.assignable(v.typ, valueType) {
+				c.errorAt(ctx.scope, left, "不能将 %s 类型的值赋给 %s 类型的变量 %s", valueType, v.typ, left.Value)
+			}
+		} else if v.typ.String() != valueType.String() {
+			// 类型推导的变量被赋予其他类型的值，之后不再据此检查
+			v.typ = unknownType
+		}
+
+	case *parser.MemberAccessExpression:
+		objectType := c.checkExpression(ctx, left.Object)
+		if info := c.classOfType(objectType); info != nil {
+			if field := c.findField(info, left.Member.Value); field != nil && !c.assignable(field.typ, valueType) {
+				c.errorAt(ctx.scope, left.Member, "不能将 %s 类型的值赋给 %s 类型的字段 %s", valueType, field.typ, left.Member.Value)
+			}
+		}
+
+	case *parser.StaticAccessExpression:
+		if info := c.staticTarget(ctx, left.ClassName); info != nil {
+			if field := c.findField(info, left.Name.Value); field != nil && !c.assignable(field.typ, valueType) {
+				c.errorAt(ctx.scope, left.Name, "不能将 %s 类型的值赋给 %s 类型的静态字段 %s", valueType, field.typ, left.Name.Value)
+			}
+		}
+
+	case *parser.IndexExpression:
+		container := c.checkExpression(ctx, left.Left)
+		c.checkExpression(ctx, left.Index)
+		switch container.Kind {
+		case KindArray:
+			if !c.assignable(container.Elem, valueType) {
+				c.errorAt(ctx.scope, left, "不能将 %s 类型的值存入 %s", valueType, container)
+			}
+		case KindMap:
+			if !c.assignable(container.Value, valueType) {
+				c.errorAt(ctx.scope, left, "不能将 %s 类型的值存入 %s", valueType, container)
+			}
+		}
+
+	default:
+		c.checkExpression(ctx, e.Left)
+	}
+	return valueType
+}
+
+// ========== 调用 ==========
+
+// checkCall 检查函数调用和实例方法调用
+func (c *Checker) checkCall(ctx *context, e *parser.CallExpression) *Type {
+	switch fn := e.Function.(type) {
+	case *parser.Identifier:
+		// 局部变量（闭包）优先于同名函数
+		if ctx.vars.lookup(fn.Value) == nil {
+			if info, ok := ctx.scope.functions[fn.Value]; ok {
+				c.checkArguments(ctx, e, "函数 "+fn.Value, info.params, e.Arguments)
+				return returnTypeOrUnknown(info.returnType)
+			}
+		}
+
+	case *parser.MemberAccessExpression:
+		objectType := c.checkExpression(ctx, fn.Object)
+		info := c.classOfType(objectType)
+		if info == nil {
+			break
+		}
+		name := fn.Member.Value
+		if method := c.findMethod(info, name); method != nil {
+			c.checkArguments(ctx, e, fmt.Sprintf("方法 %s::%s", method.owner.name, name), method.params, e.Arguments)
+			return returnTypeOrUnknown(method.returnType)
+		}
+		if c.findField(info, name) != nil || !c.canReportMissing(ctx, fn.Object, info) {
+			// 字段中保存的闭包，或继承关系不完整
+			break
+		}
+		if info.kind == classKindEnum && enumInstanceMethods[name] {
+			break
+		}
+		c.errorAt(ctx.scope, fn.Member, "%s %s 没有方法 %s", info.kind, info.name, name)
+
+	default:
+		c.checkExpression(ctx, e.Function)
+	}
+
+	c.checkArgumentValues(ctx, e.Arguments)
+	return unknownType
+}
+
+// builtinNewTypes 可以用 new 创建的内置类型（协程相关，不是类）
+var builtinNewTypes = map[string]bool{"Channel": true, "WaitGroup": true, "Mutex": true, "Atomic": true}
+
+// checkNew 检查 new 表达式：类必须存在且可以实例化，参数与构造方法匹配
+func (c *Checker) checkNew(ctx *context, e *parser.NewExpression) *Type {
+	name := e.ClassName.Value
+	info := c.members(c.resolveClass(name, ctx.scope))
+	if info == nil {
+		if !builtinNewTypes[name] && !c.knownClassName(shortName(name)) {
+			c.errorAt(ctx.scope, e.ClassName, "未定义的类 %s", name)
+		}
+		c.checkArgumentValues(ctx, e.Arguments)
+		return unknownType
+	}
+
+	switch {
+	case info.kind == classKindInterface:
+		c.errorAt(ctx.scope, e.ClassName, "不能实例化接口 %s", info.name)
+	case info.kind == classKindEnum:
+		c.errorAt(ctx.scope, e.ClassName, "不能实例化枚举 %s", info.name)
+	case info.isAbstract:
+		c.errorAt(ctx.scope, e.ClassName, "不能实例化抽象类 %s", info.name)
+	}
+
+	if ctor := c.findMethod(info, "__construct"); ctor != nil {
+		c.checkArguments(ctx, e, "构造方法 "+info.name, ctor.params, e.Arguments)
+	} else {
+		if len(e.Arguments) > 0 && c.complete(info) {
+			c.errorAt(ctx.scope, e, "类 %s 没有构造方法，不接受参数，实际传入 %d 个", info.name, len(e.Arguments))
+		}
+		c.checkArgumentValues(ctx, e.Arguments)
+	}
+	return &Type{Kind: KindClass, Name: info.fullName}
+}
+
+// checkStaticCall 检查静态方法调用（含 self::、static:: 和 super::）
+func (c *Checker) checkStaticCall(ctx *context, e *parser.StaticCallExpression) *Type {
+	info := c.staticTarget(ctx, e.ClassName)
+	if info == nil {
+		c.checkArgumentValues(ctx, e.Arguments)
+		return unknownType
+	}
+
+	name := e.Method.Value
+	if method := c.findMethod(info, name); method != nil {
+		c.checkArguments(ctx, e, fmt.Sprintf("方法 %s::%s", method.owner.name, name), method.params, e.Arguments)
+		return returnTypeOrUnknown(method.returnType)
+	}
+	c.checkArgumentValues(ctx, e.Arguments)
+
+	if info.kind == classKindEnum && enumStaticMethods[name] {
+		switch name {
+		case "cases":
+			return &Type{Kind: KindArray, Elem: &Type{Kind: KindClass, Name: info.fullName}}
+		case "count":
+			return intType
+		case "from", "valueOf":
+			return &Type{Kind: KindClass, Name: info.fullName}
+		}
+		return unknownType
+	}
+	if c.complete(info) && e.ClassName.Value != "static" {
+		c.errorAt(ctx.scope, e.Method, "%s %s 没有静态方法 %s", info.kind, info.name, name)
+	}
+	return unknownType
+}
+
+// checkStaticAccess 检查常量、静态字段和枚举成员访问
+func (c *Checker) checkStaticAccess(ctx *context, e *parser.StaticAccessExpression) *Type {
+	info := c.staticTarget(ctx, e.ClassName)
+	if info == nil {
+		return unknownType
+	}
+	name := e.Name.Value
+	if info.kind == classKindEnum && info.members[name] {
+		return &Type{Kind: KindClass, Name: info.fullName}
+	}
+	if t, ok := c.findConstant(info, name); ok {
+		return t
+	}
+	if field := c.findField(info, name); field != nil {
+		return field.typ
+	}
+	if c.complete(info) && e.ClassName.Value != "static" {
+		c.errorAt(ctx.scope, e.Name, "%s %s 没有常量或静态字段 %s", info.kind, info.name, name)
+	}
+	return unknownType
+}
+
+// checkMemberAccess 检查实例字段访问
+func (c *Checker) checkMemberAccess(ctx *context, e *parser.MemberAccessExpression) *Type {
+	objectType := c.checkExpression(ctx, e.Object)
+	info := c.classOfType(objectType)
+	if info == nil {
+		return unknownType
+	}
+	name := e.Member.Value
+	if field := c.findField(info, name); field != nil {
+		return field.typ
+	}
+	if c.findMethod(info, name) != nil {
+		return functionType
+	}
+	if info.kind != classKindEnum && c.canReportMissing(ctx, e.Object, info) {
+		c.errorAt(ctx.scope, e.Member, "%s %s 没有字段或方法 %s", info.kind, info.name, name)
+	}
+	return unknownType
+}
+
+// staticTarget 解析 :: 左侧的类名（self、static 指当前类，super 指父类）
+func (c *Checker) staticTarget(ctx *context, className *parser.Identifier) *classInfo {
+	switch className.Value {
+	case "self", "static":
+		return ctx.class
+	case "super", "parent":
+		if ctx.class == nil {
+			return nil
+		}
+		return c.members(ctx.class).parent
+	}
+	return c.members(c.resolveClass(className.Value, ctx.scope))
+}
+
+// classOfType 返回类类型对应的类信息（非类类型返回 nil）
+func (c *Checker) classOfType(t *Type) *classInfo {
+	if t == nil || t.Kind != KindClass {
+		return nil
+	}
+	return c.members(c.classes[t.Name])
+}
+
+// canReportMissing 能否断定成员不存在：继承链必须完整，
+// 抽象类中通过 this 访问的成员可能由子类提供
+func (c *Checker) canReportMissing(ctx *context, object parser.Expression, info *classInfo) bool {
+	if !c.complete(info) {
+		return false
+	}
+	if _, isThis := object.(*parser.ThisExpression); isThis && info.isAbstract {
+		return false
+	}
+	return true
+}
+
+// returnTypeOrUnknown 未声明返回类型时按 unknown 处理
+func returnTypeOrUnknown(t *Type) *Type {
+	if t == nil {
+		return unknownType
+	}
+	return t
+}
+
+// ========== 参数 ==========
+
+// checkArgumentValues 只检查参数表达式本身（被调用者未知时）
+func (c *Checker) checkArgumentValues(ctx *context, args []parser.CallArgument) {
+	for _, arg := range args {
+		c.checkExpression(ctx, arg.Value)
+	}
+}
+
+// checkArguments 检查参数个数、命名参数和参数类型
+func (c *Checker) checkArguments(ctx *context, node parser.Node, callee string, params []*paramInfo, args []parser.CallArgument) {
+	// 统计必需参数和最大参数个数
+	required, max, variadic := 0, len(params), (*paramInfo)(nil)
+	for _, p := range params {
+		switch {
+		case p.variadic:
+			variadic = p
+			max--
+		case !p.optional:
+			required++
+		}
+	}
+
+	positional := 0
+	supplied := make(map[string]bool)
+	for _, arg := range args {
+		argType := c.checkExpression(ctx, arg.Value)
+
+		var param *paramInfo
+		if arg.Name != nil {
+			for _, p := range params {
+				if p.name == arg.Name.Value {
+					param = p
+					break
+				}
+			}
+			if param == nil {
+				c.errorAt(ctx.scope, arg.Name, "%s 没有名为 %s 的参数", callee, arg.Name.Value)
+				continue
+			}
+			supplied[param.name] = true
+		} else {
+			if positional < max {
+				param = params[positional]
+				supplied[param.name] = true
+			} else if variadic != nil {
+				param = variadic
+			}
+			positional++
+		}
+
+		if param != nil && !c.assignable(param.typ, argType) {
+			c.errorAt(ctx.scope, arg.Value, "%s 的参数 %s 需要 %s 类型，实际传入 %s 类型", callee, param.name, param.typ, argType)
+		}
+	}
+
+	// 参数个数：位置参数过多，或有必需参数未提供
+	var missing []string
+	for _, p := range params {
+		if !p.variadic && !p.optional && !supplied[p.name] {
+			missing = append(missing, p.name)
+		}
+	}
+	if (variadic == nil && positional > max) || (len(missing) > 0 && positional == len(args)) {
+		var expected string
+		switch {
+		case variadic != nil:
+			expected = fmt.Sprintf("至少 %d 个", required)
+		case required == max:
+			expected = fmt.Sprintf("%d 个", max)
+		default:
+			expected = fmt.Sprintf("%d 到 %d 个", required, max)
+		}
+		c.errorAt(ctx.scope, node, "%s 需要 %s参数，实际传入 %d 个", callee, expected, len(args))
+	} else if len(missing) > 0 {
+		// 使用了命名参数时，直接指出缺少哪些参数
+		c.errorAt(ctx.scope, node, "%s 缺少参数 %s", callee, strings.Join(missing, ", "))
+	}
+}
@@ -0,0 +1,203 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/tangzhangming/longlang/internal/parser"
+)
+
+// ========== 类型表示 ==========
+
+// Kind 类型种类
+type Kind int
+
+const (
+	KindUnknown  Kind = iota // 无法静态确定（不做检查）
+	KindAny                  // any：可接受任意值
+	KindNull                 // null 字面量
+	KindVoid                 // 无返回值
+	KindInt                  // 整数（int、i8 ~ i64、uint、u8 ~ u64、byte）
+	KindFloat                // 浮点数（float、f32、f64）
+	KindString               // 字符串
+	KindBool                 // 布尔值
+	KindArray                // 数组（含切片）
+	KindMap                  // Map
+	KindClass                // 类、接口或枚举的实例
+	KindFunction             // 函数或闭包
+)
+
+// Type 静态类型
+type Type struct {
+	Kind  Kind
+	Name  string // 声明时的类型名（基本类型名或类的完整名称）
+	Elem  *Type  // 数组元素类型
+	Key   *Type  // Map 键类型
+	Value *Type  // Map 值类型
+}
+
+// 常用类型
+var (
+	unknownType  = &Type{Kind: KindUnknown}
+	anyType      = &Type{Kind: KindAny, Name: "any"}
+	nullType     = &Type{Kind: KindNull, Name: "null"}
+	voidType     = &Type{Kind: KindVoid, Name: "void"}
+	intType      = &Type{Kind: KindInt, Name: "int"}
+	floatType    = &Type{Kind: KindFloat, Name: "float"}
+	stringType   = &Type{Kind: KindString, Name: "string"}
+	boolType     = &Type{Kind: KindBool, Name: "bool"}
+	functionType = &Type{Kind: KindFunction, Name: "function"}
+)
+
+// primitiveTypes 基本类型名到类型的映射
+var primitiveTypes = map[string]*Type{
+	"any": anyType, "void": voidType, "null": nullType,
+	"int": intType, "i8": intType, "i16": intType, "i32": intType, "i64": intType,
+	"uint": intType, "u8": intType, "u16": intType, "u32": intType, "u64": intType, "byte": intType,
+	"float": floatType, "f32": floatType, "f64": floatType,
+	"string": stringType, "bool": boolType,
+	"function": functionType,
+}
+
+// String 返回类型的显示名称
+func (t *Type) String() string {
+	if t == nil {
+		return "unknown"
+	}
+	switch t.Kind {
+	case KindArray:
+		return "[]" + t.Elem.String()
+	case KindMap:
+		return "map[" + t.Key.String() + "]" + t.Value.String()
+	case KindUnknown:
+		return "unknown"
+	}
+	if t.Name != "" {
+		return t.Name
+	}
+	return "unknown"
+}
+
+// known 类型能否参与检查（非 nil、非 unknown）
+func (t *Type) known() bool {
+	return t != nil && t.Kind != KindUnknown
+}
+
+// isNumeric 是否是数值类型
+func (t *Type) isNumeric() bool {
+	return t != nil && (t.Kind == KindInt || t.Kind == KindFloat)
+}
+
+// shortName 返回类的简单名称（去掉命名空间）
+func shortName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
+
+// ========== 类型解析 ==========
+
+// typeFromName 将类型名解析为类型，类名通过 resolve 解析为完整名称
+func (c *Checker) typeFromName(name string, scope *fileScope) *Type {
+	if name == "" {
+		return unknownType
+	}
+	if t, ok := primitiveTypes[name]; ok {
+		return t
+	}
+	if strings.HasPrefix(name, "[]") {
+		return &Type{Kind: KindArray, Elem: c.typeFromName(strings.TrimPrefix(name, "[]"), scope)}
+	}
+	if info := c.resolveClass(name, scope); info != nil {
+		return &Type{Kind: KindClass, Name: info.fullName}
+	}
+	// 无法解析的类型名（可能来自未加载的文件），不做检查
+	return unknownType
+}
+
+// typeFromExpr 将类型表达式（Identifier、ArrayType、MapType）解析为类型
+func (c *Checker) typeFromExpr(expr parser.Expression, scope *fileScope) *Type {
+	switch e := expr.(type) {
+	case nil:
+		return unknownType
+	case *parser.Identifier:
+		return c.typeFromName(e.Value, scope)
+	case *parser.ArrayType:
+		return &Type{Kind: KindArray, Elem: c.typeFromExpr(e.ElementType, scope)}
+	case *parser.MapType:
+		key := unknownType
+		if e.KeyType != nil {
+			key = c.typeFromName(e.KeyType.Value, scope)
+		}
+		return &Type{Kind: KindMap, Key: key, Value: c.typeFromExpr(e.ValueType, scope)}
+	}
+	return unknownType
+}
+
+// ========== 类型兼容性 ==========
+
+// assignable 检查 value 类型的值能否赋给 target 类型
+// 任一方无法确定时视为兼容，只报告确定的错误
+func (c *Checker) assignable(target, value *Type) bool {
+	if !target.known() || !value.known() {
+		return true
+	}
+	if target.Kind == KindAny || value.Kind == KindAny || value.Kind == KindNull {
+		return true
+	}
+
+	switch target.Kind {
+	case KindInt, KindString, KindBool:
+		return value.Kind == target.Kind
+	case KindFloat:
+		// 整数可以隐式转换为浮点数
+		return value.Kind == KindFloat || value.Kind == KindInt
+	case KindArray:
+		return value.Kind == KindArray && c.assignable(target.Elem, value.Elem)
+	case KindMap:
+		return value.Kind == KindMap && c.assignable(target.Key, value.Key) && c.assignable(target.Value, value.Value)
+	case KindClass:
+		if value.Kind != KindClass {
+			return false
+		}
+		return c.isSubtype(value.Name, target.Name)
+	case KindFunction:
+		return value.Kind == KindFunction
+	case KindVoid:
+		return false
+	}
+	return true
+}
+
+// isSubtype 检查类 sub 是否是 super 本身、其子类或实现了接口 super
+// 继承链中有无法解析的类时视为兼容
+func (c *Checker) isSubtype(sub, super string) bool {
+	if sub == super {
+		return true
+	}
+	info := c.classes[sub]
+	if info == nil || !c.complete(info) {
+		return true
+	}
+	visited := map[*classInfo]bool{}
+	var walk func(*classInfo) bool
+	walk = func(ci *classInfo) bool {
+		if ci == nil || visited[ci] {
+			return false
+		}
+		visited[ci] = true
+		if ci.fullName == super {
+			return true
+		}
+		if walk(ci.parent) {
+			return true
+		}
+		for _, iface := range ci.interfaces {
+			if walk(iface) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(info)
+}
@@ -137,3 +137,61 @@ func (c *ProjectConfig) GetVendorPath(projectRoot string) string {
 
 
 
+
+// NamespaceFilePaths 返回命名空间中某个类可能所在的文件路径（按查找优先级排序）
+// 依次查找：src 目录（去掉 root_namespace 前缀 / 完整路径）、项目根目录、vendor 目录、标准库目录
+// cfg 可以为 nil（如加载标准库文件时）；projectRoot 或 stdlibPath 为空时跳过对应目录
+func NamespaceFilePaths(projectRoot string, cfg *ProjectConfig, stdlibPath string, namespace string, className string) []string {
+	// 将命名空间转换为文件路径
+	// 例如：Mycompany.Myapp.Models -> Mycompany/Myapp/Models
+	namespacePath := strings.ReplaceAll(namespace, ".", string(filepath.Separator))
+
+	var filePaths []string
+
+	// 如果有项目根目录，搜索项目目录
+	if projectRoot != "" {
+		// 如果有 root_namespace，计算相对路径
+		relativeNamespacePath := namespacePath
+		if cfg != nil && cfg.RootNamespace != "" {
+			rootNsPath := strings.ReplaceAll(cfg.RootNamespace, ".", string(filepath.Separator))
+			// 确保 rootNsPath 以路径分隔符结尾，以便正确匹配前缀
+			rootNsPathWithSep := rootNsPath + string(filepath.Separator)
+			if strings.HasPrefix(namespacePath, rootNsPathWithSep) {
+				// 去掉 root_namespace 前缀
+				relativeNamespacePath = strings.TrimPrefix(namespacePath, rootNsPathWithSep)
+			} else if namespacePath == rootNsPath {
+				// 命名空间正好是 root_namespace
+				relativeNamespacePath = ""
+			}
+		}
+
+		// 1. 使用相对路径在 src 目录下查找（优先）
+		if relativeNamespacePath != "" {
+			filePaths = append(filePaths, filepath.Join(projectRoot, "src", relativeNamespacePath, className+".long"))
+		} else {
+			// 如果相对路径为空，直接在 src 下查找
+			filePaths = append(filePaths, filepath.Join(projectRoot, "src", className+".long"))
+		}
+
+		// 2. 使用完整路径在 src 目录下查找
+		filePaths = append(filePaths, filepath.Join(projectRoot, "src", namespacePath, className+".long"))
+
+		// 3. 在项目根目录下查找（相对路径）
+		if relativeNamespacePath != "" {
+			filePaths = append(filePaths, filepath.Join(projectRoot, relativeNamespacePath, className+".long"))
+		}
+
+		// 4. 在项目根目录下查找（完整路径）
+		filePaths = append(filePaths, filepath.Join(projectRoot, namespacePath, className+".long"))
+
+		// 5. 在 vendor 目录下查找
+		filePaths = append(filePaths, filepath.Join(projectRoot, "vendor", namespacePath, className+".long"))
+	}
+
+	// 6. 在标准库目录下查找
+	if stdlibPath != "" {
+		filePaths = append(filePaths, filepath.Join(stdlibPath, namespacePath, className+".long"))
+	}
+
+	return filePaths
+}
@@ -0,0 +1,186 @@
+package diagnostic
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+)
+
+// ========== 严重级别 ==========
+
+// Severity 诊断信息的严重级别
+type Severity int
+
+const (
+	Error   Severity = iota // 错误：无法继续编译或运行
+	Warning                 // 警告：可以继续，但很可能有问题
+	Note                    // 提示：补充说明
+)
+
+// String 返回严重级别的显示名称
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "警告"
+	case Note:
+		return "提示"
+	default:
+		return "错误"
+	}
+}
+
+// ========== 诊断信息 ==========
+
+// Diagnostic 结构化的诊断信息
+// 由词法分析器、语法分析器、字节码编译器和虚拟机运行时统一使用
+type Diagnostic struct {
+	File     string   // 源文件路径（未知时为空）
+	Line     int      // 行号（从1开始，0 表示未知）
+	Column   int      // 列号（从1开始，0 表示未知）
+	Severity Severity // 严重级别
+	Message  string   // 诊断消息
+	Source   string   // 出错行的源代码（为空时按需从文件读取）
+	Details  []string // 附加信息（如堆栈跟踪），输出在源码片段之后
+}
+
+// New 创建错误级别的诊断信息
+func New(file string, line, column int, format string, args ...interface{}) *Diagnostic {
+	return &Diagnostic{
+		File:     file,
+		Line:     line,
+		Column:   column,
+		Severity: Error,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// WithSource 从完整源代码中提取出错行作为源码片段
+func (d *Diagnostic) WithSource(source string) *Diagnostic {
+	d.Source = SourceLine(source, d.Line)
+	return d
+}
+
+// Position 返回编译器风格的位置：file:line:col（未知部分省略）
+func (d *Diagnostic) Position() string {
+	file := d.File
+	if file == "" {
+		file = "<input>"
+	}
+	if d.Line <= 0 {
+		return file
+	}
+	if d.Column <= 0 {
+		return fmt.Sprintf("%s:%d", file, d.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", file, d.Line, d.Column)
+}
+
+// Error 实现 error 接口，返回单行描述：file:line:col: 错误: 消息
+func (d *Diagnostic) Error() string {
+	return fmt.Sprintf("%s: %s: %s", d.Position(), d.Severity, d.Message)
+}
+
+// Format 返回完整描述：单行描述、带插入符的源码片段以及附加信息
+func (d *Diagnostic) Format() string {
+	var sb strings.Builder
+	sb.WriteString(d.Error())
+
+	source := d.Source
+	if source == "" && d.File != "" && d.Line > 0 {
+		if content, err := ioutil.ReadFile(d.File); err == nil {
+			source = SourceLine(string(content), d.Line)
+		}
+	}
+
+	if strings.TrimSpace(source) != "" {
+		gutter := fmt.Sprintf("%d", d.Line)
+		sb.WriteString(fmt.Sprintf("\n %s | %s", gutter, source))
+		if d.Column > 0 {
+			sb.WriteString(fmt.Sprintf("\n %s | %s^", strings.Repeat(" ", len(gutter)), caretPadding(source, d.Column)))
+		}
+	}
+
+	for _, detail := range d.Details {
+		sb.WriteString("\n")
+		sb.WriteString(detail)
+	}
+	return sb.String()
+}
+
+// caretPadding 生成插入符前的缩进，保留源码中的制表符以保证对齐
+func caretPadding(source string, column int) string {
+	var sb strings.Builder
+	for i := 0; i < column-1 && i < len(source); i++ {
+		if source[i] == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+	}
+	return sb.String()
+}
+
+// SourceLine 返回源代码中指定行的内容（不含换行符），行号超出范围时返回空字符串
+func SourceLine(source string, line int) string {
+	if line <= 0 {
+		return ""
+	}
+	lines := strings.Split(source, "\n")
+	if line > len(lines) {
+		return ""
+	}
+	return strings.TrimRight(lines[line-1], "\r")
+}
+
+// ========== 诊断列表 ==========
+
+// List 诊断信息列表（同样实现 error 接口）
+type List []*Diagnostic
+
+// Error 返回第一条诊断的描述，多条时附带总数
+func (l List) Error() string {
+	if len(l) == 0 {
+		return "没有错误"
+	}
+	if len(l) == 1 {
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%s（另有 %d 个错误）", l[0].Error(), len(l)-1)
+}
+
+// Format 返回所有诊断的完整描述，每条之间空一行
+func (l List) Format() string {
+	parts := make([]string, len(l))
+	for i, d := range l {
+		parts[i] = d.Format()
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// Sort 按文件、行、列排序（稳定排序，同一位置保留原有顺序）
+func (l List) Sort() {
+	sort.SliceStable(l, func(i, j int) bool {
+		if l[i].File != l[j].File {
+			return l[i].File < l[j].File
+		}
+		if l[i].Line != l[j].Line {
+			return l[i].Line < l[j].Line
+		}
+		return l[i].Column < l[j].Column
+	})
+}
+
+// ========== 辅助函数 ==========
+
+// FormatError 输出错误的完整描述：诊断信息（含列表）使用 Format，其余错误直接输出
+func FormatError(err error) string {
+	switch e := err.(type) {
+	case *Diagnostic:
+		return e.Format()
+	case List:
+		return e.Format()
+	default:
+		return err.Error()
+	}
+}
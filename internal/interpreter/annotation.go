@@ -1,6 +1,8 @@
 package interpreter
 
 import (
+	"strings"
+
 	"github.com/tangzhangming/longlang/internal/parser"
 )
 
@@ -231,27 +233,7 @@ func registerAnnotationBuiltins(env *Environment) {
 			return &Map{Pairs: make(map[string]Object), Keys: []string{}, KeyType: "string", ValueType: "any"}
 		}
 
-		// 返回字段信息
-		fieldsMap := &Map{
-			Pairs:     make(map[string]Object),
-			Keys:      []string{},
-			KeyType:   "string",
-			ValueType: "any",
-		}
-
-		for name, field := range class.Variables {
-			fieldInfo := &Map{
-				Pairs:     make(map[string]Object),
-				Keys:      []string{},
-				KeyType:   "string",
-				ValueType: "any",
-			}
-			fieldInfo.Set("type", &String{Value: field.Type})
-			fieldInfo.Set("access", &String{Value: field.AccessModifier})
-			fieldsMap.Set(name, fieldInfo)
-		}
-
-		return fieldsMap
+		return ClassFieldsToMap(class)
 	}})
 
 	// __get_field_annotations - 获取字段的注解列表
@@ -282,7 +264,7 @@ func registerAnnotationBuiltins(env *Environment) {
 		}
 
 		// 获取字段
-		field, ok := class.Variables[fieldName.Value]
+		field, ok := class.GetVariable(fieldName.Value)
 		if !ok {
 			return &Array{Elements: []Object{}}
 		}
@@ -324,7 +306,7 @@ func registerAnnotationBuiltins(env *Environment) {
 		}
 
 		// 获取字段
-		field, ok := class.Variables[fieldName.Value]
+		field, ok := class.GetVariable(fieldName.Value)
 		if !ok {
 			return &Boolean{Value: false}
 		}
@@ -372,7 +354,7 @@ func registerAnnotationBuiltins(env *Environment) {
 		}
 
 		// 获取字段
-		field, ok := class.Variables[fieldName.Value]
+		field, ok := class.GetVariable(fieldName.Value)
 		if !ok {
 			return &Null{}
 		}
@@ -428,22 +410,31 @@ func registerAnnotationBuiltins(env *Environment) {
 			return newError("%s 不是一个类", className.Value)
 		}
 
-		// 创建实例
-		instance := &Instance{
-			Class:  class,
-			Fields: make(map[string]Object),
+		return NewBareInstance(class)
+	}})
+
+	// __resolve_class_name - 解析成员变量类型中的类名，返回可用于其他反射函数的类名，找不到时返回空字符串
+	// 第二个参数是声明该成员变量的类名（解释器按简单类名查找，不需要它）
+	env.Set("__resolve_class_name", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 2 {
+			return newError("__resolve_class_name 需要2个参数")
 		}
 
-		// 初始化字段默认值
-		for name, field := range class.Variables {
-			if field.DefaultValue != nil {
-				instance.Fields[name] = field.DefaultValue
-			} else {
-				instance.Fields[name] = &Null{}
-			}
+		typeName, ok := args[0].(*String)
+		if !ok {
+			return newError("__resolve_class_name 第一个参数必须是字符串（类型名）")
 		}
 
-		return instance
+		name := typeName.Value
+		if dot := strings.LastIndex(name, "."); dot >= 0 {
+			name = name[dot+1:]
+		}
+		if classObj, ok := globalEnv.Get(name); ok {
+			if _, ok := classObj.(*Class); ok {
+				return &String{Value: name}
+			}
+		}
+		return &String{Value: ""}
 	}})
 
 	// __get_field_value - 获取实例字段的值
@@ -542,6 +533,37 @@ func annotationsToArray(annotations []*AnnotationInstance) *Array {
 	return &Array{Elements: elements}
 }
 
+// ClassFieldsToMap 把类的实例成员变量（包括继承的变量，按声明顺序）转换为
+// map[string]any，值为 {"type": 类型, "access": 访问修饰符}
+func ClassFieldsToMap(class *Class) *Map {
+	fieldsMap := &Map{Pairs: make(map[string]Object), Keys: []string{}, KeyType: "string", ValueType: "any"}
+	for _, name := range class.FieldNames() {
+		field, _ := class.GetVariable(name)
+		fieldInfo := &Map{Pairs: make(map[string]Object), Keys: []string{}, KeyType: "string", ValueType: "any"}
+		fieldInfo.Set("type", &String{Value: field.Type})
+		fieldInfo.Set("access", &String{Value: field.AccessModifier})
+		fieldsMap.Set(name, fieldInfo)
+	}
+	return fieldsMap
+}
+
+// NewBareInstance 创建类的实例但不调用构造函数，全部成员变量（包括继承的变量）取默认值
+func NewBareInstance(class *Class) *Instance {
+	instance := &Instance{
+		Class:  class,
+		Fields: make(map[string]Object),
+	}
+	for _, name := range class.FieldNames() {
+		field, _ := class.GetVariable(name)
+		if field.DefaultValue != nil {
+			instance.Fields[name] = field.DefaultValue
+		} else {
+			instance.Fields[name] = &Null{}
+		}
+	}
+	return instance
+}
+
 // 全局环境引用（用于内置函数访问）
 var globalEnv *Environment
 
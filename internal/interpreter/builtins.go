@@ -14,12 +14,16 @@ func RegisterAllBuiltins(env *Environment) {
 	registerBuiltins(env)
 	registerIOBuiltins(env)
 	registerNetBuiltins(env)
+	registerHttpBuiltins(env)
+	registerContextBuiltins(env)
 	registerBytesBuiltins(env)
 	registerCryptoBuiltins(env)
+	registerJsonBuiltins(env)
 	registerRegexBuiltins(env)
 	registerDateTimeBuiltins(env)
 	registerConsoleBuiltins(env)
 	registerAnnotationBuiltins(env)
+	registerAssertBuiltins(env)
 }
 
 // GetAllBuiltins 返回一个包含所有内置函数的 map
@@ -336,6 +340,11 @@ func registerBuiltins(env *Environment) {
 			return newError("__get_class_name 函数需要1个参数，得到 %d 个", len(args))
 		}
 
+		// 参数是类本身时返回它的类名
+		if class, ok := args[0].(*Class); ok {
+			return &String{Value: class.Name}
+		}
+
 		instance, ok := args[0].(*Instance)
 		if !ok {
 			return newError("__get_class_name 参数必须是类实例，得到 %s", args[0].Type())
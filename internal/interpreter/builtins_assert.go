@@ -0,0 +1,84 @@
+package interpreter
+
+// registerAssertBuiltins 注册断言相关内置函数（供 System.Test 使用）
+func registerAssertBuiltins(env *Environment) {
+	// __deep_equals(a, b) - 深度比较两个值
+	// 数组按元素逐个比较，Map 按键值比较，实例按类和字段比较，其余按值比较
+	env.Set("__deep_equals", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 2 {
+			return newError("__deep_equals 需要2个参数，得到 %d 个", len(args))
+		}
+		return &Boolean{Value: deepEqual(args[0], args[1])}
+	}})
+
+	// __instance_of(value, className) - 检查值是否是指定类（或其子类）的实例
+	// className 可以是简单类名或带命名空间的完整类名
+	env.Set("__instance_of", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 2 {
+			return newError("__instance_of 需要2个参数，得到 %d 个", len(args))
+		}
+		className, ok := args[1].(*String)
+		if !ok {
+			return newError("__instance_of 第二个参数必须是字符串，得到 %s", args[1].Type())
+		}
+		instance, ok := args[0].(*Instance)
+		if !ok {
+			return &Boolean{Value: false}
+		}
+		for class := instance.Class; class != nil; class = class.Parent {
+			if class.Name == className.Value || (class.Namespace != "" && class.Namespace+"."+class.Name == className.Value) {
+				return &Boolean{Value: true}
+			}
+		}
+		return &Boolean{Value: false}
+	}})
+}
+
+// deepEqual 深度比较两个对象
+func deepEqual(a, b Object) bool {
+	switch av := a.(type) {
+	case *Array:
+		bv, ok := b.(*Array)
+		if !ok || len(av.Elements) != len(bv.Elements) {
+			return false
+		}
+		for i := range av.Elements {
+			if !deepEqual(av.Elements[i], bv.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *Map:
+		bv, ok := b.(*Map)
+		if !ok || len(av.Pairs) != len(bv.Pairs) {
+			return false
+		}
+		for key, value := range av.Pairs {
+			other, exists := bv.Pairs[key]
+			if !exists || !deepEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	case *Instance:
+		bv, ok := b.(*Instance)
+		if !ok {
+			return false
+		}
+		if av == bv {
+			return true
+		}
+		if av.Class != bv.Class || len(av.Fields) != len(bv.Fields) {
+			return false
+		}
+		for name, value := range av.Fields {
+			other, exists := bv.Fields[name]
+			if !exists || !deepEqual(value, other) {
+				return false
+			}
+		}
+		return true
+	default:
+		return isEqual(a, b)
+	}
+}
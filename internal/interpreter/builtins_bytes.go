@@ -3,6 +3,7 @@ package interpreter
 import (
 	"bytes"
 	"encoding/binary"
+	"math"
 	"strconv"
 	"strings"
 )
@@ -86,6 +87,10 @@ func registerBytesBuiltins(env *Environment) {
 			if !ok {
 				return newError("__bytes_to_hex 数组元素必须是整数，得到 %s", elem.Type())
 			}
+			// 每个字节固定两位，小于 0x10 的字节补前导 0
+			if byteInt.Value&0xFF < 0x10 {
+				sb.WriteByte('0')
+			}
 			sb.WriteString(strconv.FormatInt(byteInt.Value&0xFF, 16))
 		}
 		return &String{Value: sb.String()}
@@ -518,6 +523,45 @@ func registerBytesBuiltins(env *Environment) {
 		return &Integer{Value: val}
 	}})
 
+	// ===== 浮点数编码/解码（小端序，IEEE 754）=====
+
+	// __bytes_write_float64_le(value) - 写入 float64（小端序），返回 8 字节数组
+	env.Set("__bytes_write_float64_le", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 1 {
+			return newError("__bytes_write_float64_le 需要1个参数，得到 %d 个", len(args))
+		}
+		var val float64
+		switch v := args[0].(type) {
+		case *Float:
+			val = v.Value
+		case *Integer:
+			val = float64(v.Value)
+		default:
+			return newError("__bytes_write_float64_le 参数必须是数字，得到 %s", args[0].Type())
+		}
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint64(buf, math.Float64bits(val))
+		return bytesToArray(buf)
+	}})
+
+	// __bytes_read_float64_le(bytes, offset) - 读取 float64（小端序）
+	env.Set("__bytes_read_float64_le", &Builtin{Fn: func(args ...Object) Object {
+		data, errObj := readFixedBytes("__bytes_read_float64_le", args, 8)
+		if errObj != nil {
+			return errObj
+		}
+		return &Float{Value: math.Float64frombits(binary.LittleEndian.Uint64(data))}
+	}})
+
+	// __bytes_read_float32_le(bytes, offset) - 读取 float32（小端序），结果转为 float
+	env.Set("__bytes_read_float32_le", &Builtin{Fn: func(args ...Object) Object {
+		data, errObj := readFixedBytes("__bytes_read_float32_le", args, 4)
+		if errObj != nil {
+			return errObj
+		}
+		return &Float{Value: float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))}
+	}})
+
 	// ===== ByteBuffer 操作 =====
 
 	// __bytebuffer_new(capacity) - 创建 ByteBuffer
@@ -950,3 +994,23 @@ func bytesToArray(data []byte) *Array {
 
 
 
+
+// 辅助函数：检查 (bytes, offset) 参数并取出从 offset 开始的 size 个字节
+func readFixedBytes(name string, args []Object, size int) ([]byte, Object) {
+	if len(args) != 2 {
+		return nil, newError("%s 需要2个参数，得到 %d 个", name, len(args))
+	}
+	arr, ok := args[0].(*Array)
+	if !ok {
+		return nil, newError("%s 第一个参数必须是数组，得到 %s", name, args[0].Type())
+	}
+	offset, ok := args[1].(*Integer)
+	if !ok {
+		return nil, newError("%s 第二个参数必须是整数，得到 %s", name, args[1].Type())
+	}
+	off := int(offset.Value)
+	if off < 0 || off+size > len(arr.Elements) {
+		return nil, newError("%s 偏移量越界", name)
+	}
+	return arrayToBytes(arr)[off : off+size], nil
+}
@@ -0,0 +1,249 @@
+package interpreter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// registerContextBuiltins 注册取消上下文（System.Context）相关的内置函数
+func registerContextBuiltins(env *Environment) {
+	// __context_background() - 创建永不结束的根上下文
+	env.Set("__context_background", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 0 {
+			return newError("__context_background 不需要参数，得到 %d 个", len(args))
+		}
+		return &ContextObject{Ctx: context.Background()}
+	}})
+
+	// __context_with_cancel(parent) - 创建可手动取消的子上下文
+	env.Set("__context_with_cancel", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 1 {
+			return newError("__context_with_cancel 需要1个参数，得到 %d 个", len(args))
+		}
+		parent, ok := contextFromObject(args[0])
+		if !ok {
+			return newError("__context_with_cancel 参数必须是 Context，得到 %s", args[0].Type())
+		}
+		ctx, cancel := context.WithCancel(parent)
+		return &ContextObject{Ctx: ctx, cancel: cancel}
+	}})
+
+	// __context_with_timeout(parent, timeout_ms) - 创建在指定毫秒后自动结束的子上下文
+	env.Set("__context_with_timeout", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 2 {
+			return newError("__context_with_timeout 需要2个参数，得到 %d 个", len(args))
+		}
+		parent, ok := contextFromObject(args[0])
+		if !ok {
+			return newError("__context_with_timeout 第一个参数必须是 Context，得到 %s", args[0].Type())
+		}
+		timeoutInt, ok := args[1].(*Integer)
+		if !ok {
+			return newError("__context_with_timeout 第二个参数必须是整数，得到 %s", args[1].Type())
+		}
+		ctx, cancel := context.WithTimeout(parent, time.Duration(timeoutInt.Value)*time.Millisecond)
+		return &ContextObject{Ctx: ctx, cancel: cancel}
+	}})
+
+	// __context_with_deadline(parent, deadline_ms) - 创建在指定时刻（Unix 毫秒时间戳）自动结束的子上下文
+	env.Set("__context_with_deadline", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 2 {
+			return newError("__context_with_deadline 需要2个参数，得到 %d 个", len(args))
+		}
+		parent, ok := contextFromObject(args[0])
+		if !ok {
+			return newError("__context_with_deadline 第一个参数必须是 Context，得到 %s", args[0].Type())
+		}
+		deadlineInt, ok := args[1].(*Integer)
+		if !ok {
+			return newError("__context_with_deadline 第二个参数必须是整数，得到 %s", args[1].Type())
+		}
+		ctx, cancel := context.WithDeadline(parent, time.UnixMilli(deadlineInt.Value))
+		return &ContextObject{Ctx: ctx, cancel: cancel}
+	}})
+
+	// __context_cancel(ctx) - 取消上下文（根上下文和已结束的上下文上无效果）
+	env.Set("__context_cancel", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 1 {
+			return newError("__context_cancel 需要1个参数，得到 %d 个", len(args))
+		}
+		ctx, ok := args[0].(*ContextObject)
+		if !ok {
+			return newError("__context_cancel 参数必须是 Context，得到 %s", args[0].Type())
+		}
+		ctx.Cancel()
+		return &Null{}
+	}})
+
+	// __context_done(ctx) - 返回上下文结束时关闭的 Channel，可用于 select
+	env.Set("__context_done", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 1 {
+			return newError("__context_done 需要1个参数，得到 %d 个", len(args))
+		}
+		ctx, ok := args[0].(*ContextObject)
+		if !ok {
+			return newError("__context_done 参数必须是 Context，得到 %s", args[0].Type())
+		}
+		return ctx.Done()
+	}})
+
+	// __context_err(ctx) - 返回上下文结束的原因，未结束时返回 null
+	env.Set("__context_err", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 1 {
+			return newError("__context_err 需要1个参数，得到 %d 个", len(args))
+		}
+		ctx, ok := args[0].(*ContextObject)
+		if !ok {
+			return newError("__context_err 参数必须是 Context，得到 %s", args[0].Type())
+		}
+		if ctx.Ctx.Err() == nil {
+			return &Null{}
+		}
+		return &String{Value: contextErrorMessage(ctx.Ctx)}
+	}})
+}
+
+// ========== Context 对象 ==========
+
+// ContextObject 取消上下文（System.Context 持有的原生句柄）
+type ContextObject struct {
+	Ctx    context.Context
+	cancel context.CancelFunc // 根上下文为 nil
+
+	doneOnce sync.Once
+	done     *ChannelObject
+}
+
+func (c *ContextObject) Type() ObjectType { return "CONTEXT" }
+func (c *ContextObject) Inspect() string {
+	if c.Ctx.Err() != nil {
+		return "Context(" + contextErrorMessage(c.Ctx) + ")"
+	}
+	return "Context"
+}
+
+// Cancel 取消上下文，并立即关闭 done() 返回的 Channel
+func (c *ContextObject) Cancel() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	c.Done().Close()
+}
+
+// Done 返回上下文结束时关闭的 Channel（首次调用时创建）
+// 已结束的上下文返回已关闭的 Channel；由父上下文引起的结束异步关闭
+func (c *ContextObject) Done() *ChannelObject {
+	c.doneOnce.Do(func() {
+		c.done = NewChannel(0)
+		done := c.Ctx.Done()
+		switch {
+		case done == nil:
+			// 根上下文永不结束
+		case c.Ctx.Err() != nil:
+			c.done.Close()
+		default:
+			go func() {
+				<-done
+				c.done.Close()
+			}()
+		}
+	})
+	return c.done
+}
+
+// contextFromObject 从内置函数参数取出上下文
+// 接受原生句柄、System.Context 实例（取其 handle 字段）以及表示"不限制"的 null
+func contextFromObject(obj Object) (context.Context, bool) {
+	switch v := obj.(type) {
+	case *ContextObject:
+		return v.Ctx, true
+	case *Instance:
+		if handle, ok := v.Fields["handle"].(*ContextObject); ok {
+			return handle.Ctx, true
+		}
+	case *Null:
+		return context.Background(), true
+	}
+	return nil, false
+}
+
+// contextErrorMessage 返回上下文结束原因的描述
+func contextErrorMessage(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return "操作超时：上下文已超过截止时间"
+	}
+	return "操作已取消"
+}
+
+// NewCancelledException 创建 CancelledException 异常（上下文结束或任务被取消时抛出）
+func NewCancelledException(message string) *ThrownException {
+	return createBuiltinException("CancelledException", message)
+}
+
+// ========== 阻塞操作的取消 ==========
+
+// interruptedDeadline 用于打断阻塞读写的过去时刻
+var interruptedDeadline = time.Unix(1, 0)
+
+// deadlineSetter 支持截止时间的连接、监听器和文件
+type deadlineSetter interface {
+	SetDeadline(t time.Time) error
+}
+
+// trailingContext 检查内置函数的参数个数（n 个，或多一个可选的 Context 参数）并取出上下文
+// 没有传入上下文时返回永不结束的根上下文
+func trailingContext(name string, args []Object, n int) (context.Context, *Error) {
+	if len(args) != n && len(args) != n+1 {
+		return nil, newError("%s 需要%d或%d个参数，得到 %d 个", name, n, n+1, len(args))
+	}
+	if len(args) == n {
+		return context.Background(), nil
+	}
+	ctx, ok := contextFromObject(args[n])
+	if !ok {
+		return nil, newError("%s 最后一个参数必须是 Context，得到 %s", name, args[n].Type())
+	}
+	return ctx, nil
+}
+
+// watchContext 开始一个受 ctx 控制的阻塞操作
+// ctx 已结束时直接返回 CancelledException；否则在 ctx 结束时把 target 的截止时间设为过去，
+// 打断正在进行的读写。操作完成后必须调用 finish：操作期间 ctx 结束时它调用 restore
+// 恢复原有的截止时间并返回 CancelledException，否则返回 nil
+// target 为 nil 时（不支持截止时间）只在操作前后检查 ctx
+func watchContext(ctx context.Context, target deadlineSetter, restore func()) (finish func() *ThrownException, cancelled *ThrownException) {
+	if ctx.Err() != nil {
+		return nil, NewCancelledException(contextErrorMessage(ctx))
+	}
+	if ctx.Done() == nil {
+		return func() *ThrownException { return nil }, nil
+	}
+	if target == nil {
+		return func() *ThrownException {
+			if ctx.Err() != nil {
+				return NewCancelledException(contextErrorMessage(ctx))
+			}
+			return nil
+		}, nil
+	}
+
+	interrupted := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		target.SetDeadline(interruptedDeadline)
+		close(interrupted)
+	})
+	return func() *ThrownException {
+		if stop() {
+			return nil
+		}
+		// 打断已经开始，等它设置完截止时间后再恢复
+		<-interrupted
+		if restore != nil {
+			restore()
+		}
+		return NewCancelledException(contextErrorMessage(ctx))
+	}, nil
+}
@@ -1,8 +1,14 @@
 package interpreter
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/sha1"
 	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"hash"
 )
 
 // registerCryptoBuiltins 注册加密相关内置函数
@@ -78,8 +84,135 @@ func registerCryptoBuiltins(env *Environment) {
 
 		return bytesToArray(result)
 	}})
-}
+	// __rsa_encrypt_oaep(publicKeyPem, data, [hash]) - 用 RSA 公钥加密（OAEP 填充）
+	// publicKeyPem 支持 "PUBLIC KEY"（PKIX）和 "RSA PUBLIC KEY"（PKCS#1）两种 PEM 格式
+	// hash 为 OAEP 使用的哈希算法，"sha1"（默认）或 "sha256"
+	env.Set("__rsa_encrypt_oaep", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 2 && len(args) != 3 {
+			return newError("__rsa_encrypt_oaep 需要2或3个参数，得到 %d 个", len(args))
+		}
+		keyPem, ok := args[0].(*String)
+		if !ok {
+			return newError("__rsa_encrypt_oaep 第一个参数必须是字符串，得到 %s", args[0].Type())
+		}
 
+		var data []byte
+		switch arg := args[1].(type) {
+		case *String:
+			data = []byte(arg.Value)
+		case *Array:
+			data = arrayToBytes(arg)
+		default:
+			return newError("__rsa_encrypt_oaep 第二个参数必须是字符串或字节数组，得到 %s", args[1].Type())
+		}
 
+		var h hash.Hash = sha1.New()
+		if len(args) == 3 {
+			name, ok := args[2].(*String)
+			if !ok {
+				return newError("__rsa_encrypt_oaep 第三个参数必须是字符串，得到 %s", args[2].Type())
+			}
+			if h = oaepHash(name.Value); h == nil {
+				return newError("__rsa_encrypt_oaep 不支持的哈希算法: %s", name.Value)
+			}
+		}
 
+		publicKey, err := parseRSAPublicKey(keyPem.Value)
+		if err != nil {
+			return newError("无效的 RSA 公钥: %s", err.Error())
+		}
+		encrypted, err := rsa.EncryptOAEP(h, rand.Reader, publicKey, data, nil)
+		if err != nil {
+			return newError("RSA 加密失败: %s", err.Error())
+		}
+		return bytesToArray(encrypted)
+	}})
 
+	// __rsa_decrypt_oaep(privateKeyPem, data, [hash]) - 用 RSA 私钥解密（OAEP 填充）
+	// privateKeyPem 支持 "RSA PRIVATE KEY"（PKCS#1）和 "PRIVATE KEY"（PKCS#8）两种 PEM 格式
+	env.Set("__rsa_decrypt_oaep", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 2 && len(args) != 3 {
+			return newError("__rsa_decrypt_oaep 需要2或3个参数，得到 %d 个", len(args))
+		}
+		keyPem, ok := args[0].(*String)
+		if !ok {
+			return newError("__rsa_decrypt_oaep 第一个参数必须是字符串，得到 %s", args[0].Type())
+		}
+		arr, ok := args[1].(*Array)
+		if !ok {
+			return newError("__rsa_decrypt_oaep 第二个参数必须是字节数组，得到 %s", args[1].Type())
+		}
+
+		var h hash.Hash = sha1.New()
+		if len(args) == 3 {
+			name, ok := args[2].(*String)
+			if !ok {
+				return newError("__rsa_decrypt_oaep 第三个参数必须是字符串，得到 %s", args[2].Type())
+			}
+			if h = oaepHash(name.Value); h == nil {
+				return newError("__rsa_decrypt_oaep 不支持的哈希算法: %s", name.Value)
+			}
+		}
+
+		privateKey, err := parseRSAPrivateKey(keyPem.Value)
+		if err != nil {
+			return newError("无效的 RSA 私钥: %s", err.Error())
+		}
+		decrypted, err := rsa.DecryptOAEP(h, nil, privateKey, arrayToBytes(arr), nil)
+		if err != nil {
+			return newError("RSA 解密失败: %s", err.Error())
+		}
+		return bytesToArray(decrypted)
+	}})
+}
+
+// oaepHash 返回 OAEP 使用的哈希算法，不支持的名称返回 nil
+func oaepHash(name string) hash.Hash {
+	switch name {
+	case "sha1":
+		return sha1.New()
+	case "sha256":
+		return sha256.New()
+	}
+	return nil
+}
+
+// parseRSAPublicKey 解析 PEM 格式的 RSA 公钥
+func parseRSAPublicKey(data string) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("不是 PEM 格式")
+	}
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("不是 RSA 公钥")
+	}
+	return publicKey, nil
+}
+
+// parseRSAPrivateKey 解析 PEM 格式的 RSA 私钥
+func parseRSAPrivateKey(data string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		return nil, fmt.Errorf("不是 PEM 格式")
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("不是 RSA 私钥")
+	}
+	return privateKey, nil
+}
@@ -0,0 +1,57 @@
+package interpreter
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+)
+
+// registerHttpBuiltins 注册 HTTP 相关内置函数
+func registerHttpBuiltins(env *Environment) {
+	// __http_parse_multipart(body, contentType) - 解析 multipart/form-data 请求体
+	// 返回各部分组成的数组，每个元素为 map：
+	//   name（字段名）、filename（文件名，普通字段为空字符串）、contentType、data（字节数组）
+	env.Set("__http_parse_multipart", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 2 {
+			return newError("__http_parse_multipart 需要2个参数，得到 %d 个", len(args))
+		}
+		body, ok := args[0].(*Array)
+		if !ok {
+			return newError("__http_parse_multipart 第一个参数必须是字节数组，得到 %s", args[0].Type())
+		}
+		contentType, ok := args[1].(*String)
+		if !ok {
+			return newError("__http_parse_multipart 第二个参数必须是字符串，得到 %s", args[1].Type())
+		}
+
+		_, params, err := mime.ParseMediaType(contentType.Value)
+		if err != nil || params["boundary"] == "" {
+			return newError("无效的 multipart Content-Type: %s", contentType.Value)
+		}
+
+		reader := multipart.NewReader(bytes.NewReader(arrayToBytes(body)), params["boundary"])
+		parts := &Array{Elements: []Object{}, ElementType: "any"}
+		for {
+			part, err := reader.NextPart()
+			// 只有遇到结束分隔符时才返回未包装的 io.EOF，截断或无效的请求体返回包装后的错误
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return newError("无效的 multipart 请求体: %s", err.Error())
+			}
+			data, err := io.ReadAll(part)
+			if err != nil {
+				return newError("无效的 multipart 请求体: %s", err.Error())
+			}
+			entry := &Map{Pairs: make(map[string]Object), KeyType: "string", ValueType: "any"}
+			entry.Set("name", &String{Value: part.FormName()})
+			entry.Set("filename", &String{Value: part.FileName()})
+			entry.Set("contentType", &String{Value: part.Header.Get("Content-Type")})
+			entry.Set("data", bytesToArray(data))
+			parts.Elements = append(parts.Elements, entry)
+		}
+		return parts
+	}})
+}
@@ -1,6 +1,7 @@
 package interpreter
 
 import (
+	"context"
 	"io"
 	"os"
 	"path/filepath"
@@ -756,6 +757,8 @@ func registerIOBuiltins(env *Environment) {
 	}})
 
 	// ===== 文件流操作函数 =====
+	// 读写函数接受一个可选的 Context 参数：管道、FIFO 等支持截止时间的文件上 ctx 结束会打断阻塞的读写，
+	// 普通文件只在操作前后检查 ctx；ctx 结束时抛出 CancelledException
 
 	// __stream_open(path, mode) - 打开文件流，返回句柄 ID
 	env.Set("__stream_open", &Builtin{Fn: func(args ...Object) Object {
@@ -800,10 +803,11 @@ func registerIOBuiltins(env *Environment) {
 		return &FileHandle{File: f, Path: pathStr.Value, Mode: modeStr.Value}
 	}})
 
-	// __stream_read(handle, count) - 读取指定字节数
+	// __stream_read(handle, count, [ctx]) - 读取指定字节数
 	env.Set("__stream_read", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 2 {
-			return newError("__stream_read 需要2个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__stream_read", args, 2)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		handle, ok := args[0].(*FileHandle)
 		if !ok {
@@ -817,8 +821,15 @@ func registerIOBuiltins(env *Environment) {
 			return newError("__stream_read 第二个参数必须是整数，得到 %s", args[1].Type())
 		}
 
+		finish, cancelled := handle.watch(ctx)
+		if cancelled != nil {
+			return cancelled
+		}
 		buf := make([]byte, countInt.Value)
 		n, err := handle.File.Read(buf)
+		if cancelled := finish(); cancelled != nil {
+			return cancelled
+		}
 		if err != nil && err != io.EOF {
 			return newError("IOException: %s", err.Error())
 		}
@@ -831,10 +842,11 @@ func registerIOBuiltins(env *Environment) {
 		return &Array{Elements: elements}
 	}})
 
-	// __stream_read_line(handle) - 读取一行
+	// __stream_read_line(handle, [ctx]) - 读取一行
 	env.Set("__stream_read_line", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 1 {
-			return newError("__stream_read_line 需要1个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__stream_read_line", args, 1)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		handle, ok := args[0].(*FileHandle)
 		if !ok {
@@ -844,8 +856,13 @@ func registerIOBuiltins(env *Environment) {
 			return newError("IOException: file is closed")
 		}
 
+		finish, cancelled := handle.watch(ctx)
+		if cancelled != nil {
+			return cancelled
+		}
 		// 逐字节读取直到换行符
 		var line []byte
+		var readErr error
 		buf := make([]byte, 1)
 		for {
 			n, err := handle.File.Read(buf)
@@ -853,22 +870,30 @@ func registerIOBuiltins(env *Environment) {
 				break
 			}
 			if err != nil {
-				return newError("IOException: %s", err.Error())
+				readErr = err
+				break
 			}
 			if buf[0] == '\n' {
 				break
 			}
 			line = append(line, buf[0])
 		}
+		if cancelled := finish(); cancelled != nil {
+			return cancelled
+		}
+		if readErr != nil {
+			return newError("IOException: %s", readErr.Error())
+		}
 		// 移除可能的 \r
 		result := strings.TrimSuffix(string(line), "\r")
 		return &String{Value: result}
 	}})
 
-	// __stream_write(handle, bytes) - 写入字节数组
+	// __stream_write(handle, bytes, [ctx]) - 写入字节数组
 	env.Set("__stream_write", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 2 {
-			return newError("__stream_write 需要2个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__stream_write", args, 2)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		handle, ok := args[0].(*FileHandle)
 		if !ok {
@@ -891,17 +916,25 @@ func registerIOBuiltins(env *Environment) {
 			buf[i] = byte(byteInt.Value)
 		}
 
+		finish, cancelled := handle.watch(ctx)
+		if cancelled != nil {
+			return cancelled
+		}
 		n, err := handle.File.Write(buf)
+		if cancelled := finish(); cancelled != nil {
+			return cancelled
+		}
 		if err != nil {
 			return newError("IOException: %s", err.Error())
 		}
 		return &Integer{Value: int64(n)}
 	}})
 
-	// __stream_write_text(handle, text) - 写入字符串
+	// __stream_write_text(handle, text, [ctx]) - 写入字符串
 	env.Set("__stream_write_text", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 2 {
-			return newError("__stream_write_text 需要2个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__stream_write_text", args, 2)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		handle, ok := args[0].(*FileHandle)
 		if !ok {
@@ -915,17 +948,25 @@ func registerIOBuiltins(env *Environment) {
 			return newError("__stream_write_text 第二个参数必须是字符串，得到 %s", args[1].Type())
 		}
 
+		finish, cancelled := handle.watch(ctx)
+		if cancelled != nil {
+			return cancelled
+		}
 		_, err := handle.File.WriteString(textStr.Value)
+		if cancelled := finish(); cancelled != nil {
+			return cancelled
+		}
 		if err != nil {
 			return newError("IOException: %s", err.Error())
 		}
 		return &Null{}
 	}})
 
-	// __stream_write_line(handle, line) - 写入一行（自动添加换行符）
+	// __stream_write_line(handle, line, [ctx]) - 写入一行（自动添加换行符）
 	env.Set("__stream_write_line", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 2 {
-			return newError("__stream_write_line 需要2个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__stream_write_line", args, 2)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		handle, ok := args[0].(*FileHandle)
 		if !ok {
@@ -939,7 +980,14 @@ func registerIOBuiltins(env *Environment) {
 			return newError("__stream_write_line 第二个参数必须是字符串，得到 %s", args[1].Type())
 		}
 
+		finish, cancelled := handle.watch(ctx)
+		if cancelled != nil {
+			return cancelled
+		}
 		_, err := handle.File.WriteString(lineStr.Value + "\n")
+		if cancelled := finish(); cancelled != nil {
+			return cancelled
+		}
 		if err != nil {
 			return newError("IOException: %s", err.Error())
 		}
@@ -1136,3 +1184,14 @@ type FileHandle struct {
 func (fh *FileHandle) Type() ObjectType { return "FILE_HANDLE" }
 func (fh *FileHandle) Inspect() string  { return "FileHandle(" + fh.Path + ")" }
 
+// watch 让文件上的一次阻塞读写受 ctx 控制（见 watchContext）
+// 普通文件不支持截止时间，此时只在操作前后检查 ctx
+func (fh *FileHandle) watch(ctx context.Context) (func() *ThrownException, *ThrownException) {
+	if err := fh.File.SetDeadline(time.Time{}); err != nil {
+		return watchContext(ctx, nil, nil)
+	}
+	return watchContext(ctx, fh.File, func() {
+		fh.File.SetDeadline(time.Time{})
+	})
+}
+
@@ -0,0 +1,464 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// jsonMaxDepth 编码时允许的最大嵌套层数（超过时通常是循环引用）
+const jsonMaxDepth = 1000
+
+// registerJsonBuiltins 注册 JSON 相关内置函数
+func registerJsonBuiltins(env *Environment) {
+	// ========== 编码 ==========
+
+	// __json_encode(value, pretty) - 把 map、数组和基本类型的值编码为 JSON 文本
+	// int 编码为整数，float 总是带小数点或指数（2.0 编码为 2.0），map 按插入顺序输出键
+	env.Set("__json_encode", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 2 {
+			return newError("__json_encode 需要2个参数，得到 %d 个", len(args))
+		}
+		pretty, ok := args[1].(*Boolean)
+		if !ok {
+			return newError("__json_encode 第二个参数必须是布尔值，得到 %s", args[1].Type())
+		}
+		var buf bytes.Buffer
+		if err := encodeJSON(&buf, args[0], pretty.Value, 0); err != nil {
+			return newError("JSON 编码失败: %s", err.Error())
+		}
+		return &String{Value: buf.String()}
+	}})
+
+	// ========== 解码 ==========
+	// 解码通过解码器句柄进行：Json::decode 一次读取整个文本，JsonDecoder 逐个读取大输入中的元素
+	// 解码失败时返回错误，并把位置记录在句柄上，由 __json_decoder_error 取回
+
+	// __json_decoder_open(source) - 创建解码器，source 是字符串或文件句柄
+	env.Set("__json_decoder_open", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 1 {
+			return newError("__json_decoder_open 需要1个参数，得到 %d 个", len(args))
+		}
+		switch source := args[0].(type) {
+		case *String:
+			return newJsonDecoder(strings.NewReader(source.Value))
+		case *FileHandle:
+			if source.Closed {
+				return newError("IOException: file is closed")
+			}
+			return newJsonDecoder(source.File)
+		}
+		return newError("__json_decoder_open 参数必须是字符串或文件句柄，得到 %s", args[0].Type())
+	}})
+
+	// __json_decoder_value(decoder) - 读取下一个完整的值
+	// 对象解析为保持键顺序的 map[string]any，数组解析为 []any，
+	// 不含小数点和指数的数字解析为 int（超出 int 范围时为 float），其余数字解析为 float
+	env.Set("__json_decoder_value", &Builtin{Fn: func(args ...Object) Object {
+		d, errObj := jsonDecoderArg("__json_decoder_value", args, 1)
+		if errObj != nil {
+			return errObj
+		}
+		value, err := decodeJSONValue(d.dec)
+		if err != nil {
+			return d.fail(err)
+		}
+		d.advance()
+		return value
+	}})
+
+	// __json_decoder_more(decoder) - 当前数组或对象中是否还有元素（顶层时表示是否还有值）
+	env.Set("__json_decoder_more", &Builtin{Fn: func(args ...Object) Object {
+		d, errObj := jsonDecoderArg("__json_decoder_more", args, 1)
+		if errObj != nil {
+			return errObj
+		}
+		return &Boolean{Value: d.dec.More()}
+	}})
+
+	// __json_decoder_delim(decoder, delim) - 读取一个分隔符，delim 是 "["、"]"、"{" 或 "}"
+	env.Set("__json_decoder_delim", &Builtin{Fn: func(args ...Object) Object {
+		d, errObj := jsonDecoderArg("__json_decoder_delim", args, 2)
+		if errObj != nil {
+			return errObj
+		}
+		want, ok := args[1].(*String)
+		if !ok || len(want.Value) != 1 || !strings.Contains("[]{}", want.Value) {
+			return newError("__json_decoder_delim 第二个参数必须是 \"[\"、\"]\"、\"{\" 或 \"}\"")
+		}
+		start := d.nextOffset()
+		tok, err := d.dec.Token()
+		if err != nil {
+			return d.fail(err)
+		}
+		if delim, ok := tok.(json.Delim); !ok || string(delim) != want.Value {
+			return d.failAt(start, fmt.Errorf("期望 %s，得到 %s", want.Value, describeJSONToken(tok)))
+		}
+		d.advance()
+		return &Null{}
+	}})
+
+	// __json_decoder_key(decoder) - 在对象中读取下一个键
+	env.Set("__json_decoder_key", &Builtin{Fn: func(args ...Object) Object {
+		d, errObj := jsonDecoderArg("__json_decoder_key", args, 1)
+		if errObj != nil {
+			return errObj
+		}
+		start := d.nextOffset()
+		tok, err := d.dec.Token()
+		if err != nil {
+			return d.fail(err)
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return d.failAt(start, fmt.Errorf("期望对象的键，得到 %s", describeJSONToken(tok)))
+		}
+		d.advance()
+		return &String{Value: key}
+	}})
+
+	// __json_decoder_finish(decoder) - 确认输入中只剩空白
+	env.Set("__json_decoder_finish", &Builtin{Fn: func(args ...Object) Object {
+		d, errObj := jsonDecoderArg("__json_decoder_finish", args, 1)
+		if errObj != nil {
+			return errObj
+		}
+		start := d.nextOffset()
+		tok, err := d.dec.Token()
+		if err == io.EOF {
+			return &Null{}
+		}
+		if err != nil {
+			return d.fail(err)
+		}
+		return d.failAt(start, fmt.Errorf("值之后有多余的内容 %s", describeJSONToken(tok)))
+	}})
+
+	// __json_decoder_error(decoder) - 获取最近一次解码失败的信息
+	// 返回 {"message", "offset", "line", "column"}（offset 从 0 开始，行号和列号从 1 开始，列号按字节计算），没有失败时返回 null
+	env.Set("__json_decoder_error", &Builtin{Fn: func(args ...Object) Object {
+		d, errObj := jsonDecoderArg("__json_decoder_error", args, 1)
+		if errObj != nil {
+			return errObj
+		}
+		if d.err == nil {
+			return &Null{}
+		}
+		return d.err
+	}})
+}
+
+// ========== 编码实现 ==========
+
+// encodeJSON 把值编码到 buf，pretty 为 true 时使用两个空格缩进
+func encodeJSON(buf *bytes.Buffer, obj Object, pretty bool, depth int) error {
+	if depth > jsonMaxDepth {
+		return fmt.Errorf("嵌套超过 %d 层（是否存在循环引用？）", jsonMaxDepth)
+	}
+	switch v := obj.(type) {
+	case *Null:
+		buf.WriteString("null")
+	case *Boolean:
+		buf.WriteString(strconv.FormatBool(v.Value))
+	case *Integer:
+		buf.WriteString(strconv.FormatInt(v.Value, 10))
+	case *Float:
+		text, err := formatJSONFloat(v.Value)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(text)
+	case *String:
+		writeJSONString(buf, v.Value)
+	case *Array:
+		if len(v.Elements) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i, elem := range v.Elements {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONIndent(buf, pretty, depth+1)
+			if err := encodeJSON(buf, elem, pretty, depth+1); err != nil {
+				return err
+			}
+		}
+		writeJSONIndent(buf, pretty, depth)
+		buf.WriteByte(']')
+	case *Map:
+		if len(v.Keys) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteByte('{')
+		for i, key := range v.Keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONIndent(buf, pretty, depth+1)
+			writeJSONString(buf, key)
+			buf.WriteByte(':')
+			if pretty {
+				buf.WriteByte(' ')
+			}
+			if err := encodeJSON(buf, v.Pairs[key], pretty, depth+1); err != nil {
+				return err
+			}
+		}
+		writeJSONIndent(buf, pretty, depth)
+		buf.WriteByte('}')
+	case *Instance:
+		return fmt.Errorf("不能直接编码 %s 的实例，类实例需要使用 Json::serialize", v.Class.Name)
+	default:
+		return fmt.Errorf("不支持编码 %s 类型的值", obj.Type())
+	}
+	return nil
+}
+
+// formatJSONFloat 格式化浮点数，保证结果带小数点或指数，解码后仍是 float
+func formatJSONFloat(f float64) (string, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("JSON 不支持 %v", f)
+	}
+	// 与 encoding/json 相同：数量级在 [1e-6, 1e21) 之间时不使用指数
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 && (abs < 1e-6 || abs >= 1e21) {
+		format = 'e'
+	}
+	text := strconv.FormatFloat(f, format, -1, 64)
+	if !strings.ContainsAny(text, ".e") {
+		text += ".0"
+	}
+	return text, nil
+}
+
+// writeJSONString 写入带引号和转义的字符串（不转义 <、>、&）
+func writeJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	// Encode 会追加换行符
+	buf.Truncate(buf.Len() - 1)
+}
+
+// writeJSONIndent 美化输出时换行并缩进到指定层级
+func writeJSONIndent(buf *bytes.Buffer, pretty bool, depth int) {
+	if !pretty {
+		return
+	}
+	buf.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		buf.WriteString("  ")
+	}
+}
+
+// ========== 解码实现 ==========
+
+// JsonDecoder JSON 解码器句柄（System.Json 和 System.JsonDecoder 持有的原生句柄）
+type JsonDecoder struct {
+	dec *json.Decoder
+	pos *jsonPositionReader
+	err *Map // 最近一次失败的信息
+}
+
+func (d *JsonDecoder) Type() ObjectType { return "JSON_DECODER" }
+func (d *JsonDecoder) Inspect() string  { return "JsonDecoder" }
+
+// newJsonDecoder 在 reader 上创建解码器
+func newJsonDecoder(r io.Reader) *JsonDecoder {
+	pos := &jsonPositionReader{r: r}
+	dec := json.NewDecoder(pos)
+	dec.UseNumber()
+	return &JsonDecoder{dec: dec, pos: pos}
+}
+
+// jsonDecoderArg 检查参数个数并取出第一个参数中的解码器
+func jsonDecoderArg(name string, args []Object, count int) (*JsonDecoder, Object) {
+	if len(args) != count {
+		return nil, newError("%s 需要%d个参数，得到 %d 个", name, count, len(args))
+	}
+	d, ok := args[0].(*JsonDecoder)
+	if !ok {
+		return nil, newError("%s 第一个参数必须是 JSON 解码器，得到 %s", name, args[0].Type())
+	}
+	return d, nil
+}
+
+// advance 成功读取后丢弃已经用不到的换行位置
+func (d *JsonDecoder) advance() {
+	d.pos.discard(d.dec.InputOffset())
+}
+
+// nextOffset 下一个 token 的大致起点：跳过缓冲区中的空白
+func (d *JsonDecoder) nextOffset() int64 {
+	offset := d.dec.InputOffset()
+	rest, _ := io.ReadAll(d.dec.Buffered())
+	for _, c := range rest {
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' && c != ',' && c != ':' {
+			break
+		}
+		offset++
+	}
+	return offset
+}
+
+// fail 记录 encoding/json 返回的错误
+func (d *JsonDecoder) fail(err error) Object {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		// Offset 是读到出错字符之后的字节数
+		return d.failAt(e.Offset-1, fmt.Errorf("%s", e.Error()))
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return d.failAt(d.pos.read, fmt.Errorf("意外的输入结束"))
+	}
+	return d.failAt(d.dec.InputOffset(), err)
+}
+
+// failAt 记录在 offset 处发生的错误，返回可捕获的错误对象
+func (d *JsonDecoder) failAt(offset int64, err error) Object {
+	if offset < 0 {
+		offset = 0
+	}
+	line, column := d.pos.position(offset)
+	message := fmt.Sprintf("%s（第 %d 行，第 %d 列）", err.Error(), line, column)
+	info := &Map{Pairs: make(map[string]Object), Keys: []string{}, KeyType: "string", ValueType: "any"}
+	info.Set("message", &String{Value: message})
+	info.Set("offset", &Integer{Value: offset})
+	info.Set("line", &Integer{Value: line})
+	info.Set("column", &Integer{Value: column})
+	d.err = info
+	return newError("JSON 解析失败: %s", message)
+}
+
+// jsonPositionReader 记录读过的换行符位置，用于把字节偏移换算为行号和列号
+// 已经成功解码的部分会被丢弃，只保留行数，因此内存占用与输入大小无关
+type jsonPositionReader struct {
+	r         io.Reader
+	read      int64   // 已读取的字节数
+	lines     int64   // 已丢弃的换行符个数
+	lineStart int64   // 已丢弃的最后一个换行符之后的偏移
+	newlines  []int64 // 尚未丢弃的换行符偏移
+}
+
+func (p *jsonPositionReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	for i := 0; i < n; i++ {
+		if b[i] == '\n' {
+			p.newlines = append(p.newlines, p.read+int64(i))
+		}
+	}
+	p.read += int64(n)
+	return n, err
+}
+
+// position 把字节偏移换算为从 1 开始的行号和列号
+func (p *jsonPositionReader) position(offset int64) (int64, int64) {
+	line, start := p.lines+1, p.lineStart
+	for _, nl := range p.newlines {
+		if nl >= offset {
+			break
+		}
+		line++
+		start = nl + 1
+	}
+	return line, offset - start + 1
+}
+
+// discard 丢弃 offset 之前的换行位置
+func (p *jsonPositionReader) discard(offset int64) {
+	i := 0
+	for i < len(p.newlines) && p.newlines[i] < offset {
+		p.lineStart = p.newlines[i] + 1
+		i++
+	}
+	p.lines += int64(i)
+	p.newlines = append(p.newlines[:0], p.newlines[i:]...)
+}
+
+// describeJSONToken 用于错误消息的 token 描述
+func describeJSONToken(tok json.Token) string {
+	switch v := tok.(type) {
+	case json.Delim:
+		return string(v)
+	case string:
+		return strconv.Quote(v)
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%v", tok)
+}
+
+// decodeJSONValue 逐个读取 token 解析一个值（不使用 map[string]any，以保持对象的键顺序）
+func decodeJSONValue(dec *json.Decoder) (Object, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch v := tok.(type) {
+	case json.Delim:
+		switch v {
+		case '{':
+			result := &Map{Pairs: make(map[string]Object), KeyType: "string", ValueType: "any"}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				result.Set(keyTok.(string), value)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return result, nil
+		case '[':
+			result := &Array{Elements: []Object{}, ElementType: "any"}
+			for dec.More() {
+				value, err := decodeJSONValue(dec)
+				if err != nil {
+					return nil, err
+				}
+				result.Elements = append(result.Elements, value)
+			}
+			if _, err := dec.Token(); err != nil {
+				return nil, err
+			}
+			return result, nil
+		}
+		return nil, fmt.Errorf("意外的 %s", v)
+	case string:
+		return &String{Value: v}, nil
+	case json.Number:
+		return jsonNumber(v)
+	case bool:
+		return &Boolean{Value: v}, nil
+	case nil:
+		return &Null{}, nil
+	}
+	return nil, fmt.Errorf("无法识别的 token %v", tok)
+}
+
+// jsonNumber 整数保持为 int（超出 int64 范围时为 float），其余为 float
+func jsonNumber(n json.Number) (Object, error) {
+	text := n.String()
+	if !strings.ContainsAny(text, ".eE") {
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return &Integer{Value: i}, nil
+		}
+	}
+	f, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("无效的数字 %s", text)
+	}
+	return &Float{Value: f}, nil
+}
@@ -2,12 +2,17 @@ package interpreter
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io"
 	"net"
+	"net/url"
+	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -40,20 +45,35 @@ func registerNetBuiltins(env *Environment) {
 		return &TcpListener{Listener: listener, Address: addr}
 	}})
 
-	// __tcp_listener_accept(listener) - 接受连接
+	// __tcp_listener_accept(listener, [ctx]) - 接受连接，ctx 结束时抛出 CancelledException
 	env.Set("__tcp_listener_accept", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 1 {
-			return newError("__tcp_listener_accept 需要1个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__tcp_listener_accept", args, 1)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		listener, ok := args[0].(*TcpListener)
 		if !ok {
 			return newError("__tcp_listener_accept 参数必须是 TcpListener，得到 %s", args[0].Type())
 		}
-		if listener.Closed {
+		if listener.Closed.Load() {
 			return newError("IOException: listener is closed")
 		}
 
+		// 监听器支持截止时间时才能打断阻塞的 Accept
+		target, _ := listener.Listener.(deadlineSetter)
+		finish, cancelled := watchContext(ctx, target, func() {
+			target.SetDeadline(time.Time{})
+		})
+		if cancelled != nil {
+			return cancelled
+		}
 		conn, err := listener.Listener.Accept()
+		if cancelled := finish(); cancelled != nil {
+			if conn != nil {
+				conn.Close()
+			}
+			return cancelled
+		}
 		if err != nil {
 			return newError("IOException: %s", err.Error())
 		}
@@ -76,12 +96,12 @@ func registerNetBuiltins(env *Environment) {
 		if !ok {
 			return newError("__tcp_listener_close 参数必须是 TcpListener，得到 %s", args[0].Type())
 		}
-		if listener.Closed {
+		// 可能在另一个协程阻塞于 accept 时关闭
+		if !listener.Closed.CompareAndSwap(false, true) {
 			return &Null{}
 		}
 
 		err := listener.Listener.Close()
-		listener.Closed = true
 		if err != nil {
 			return newError("IOException: %s", err.Error())
 		}
@@ -102,10 +122,11 @@ func registerNetBuiltins(env *Environment) {
 
 	// ===== TCP 客户端函数 =====
 
-	// __tcp_connect(host, port) - 连接到 TCP 服务器
+	// __tcp_connect(host, port, [ctx]) - 连接到 TCP 服务器
 	env.Set("__tcp_connect", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 2 {
-			return newError("__tcp_connect 需要2个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__tcp_connect", args, 2)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		hostStr, ok := args[0].(*String)
 		if !ok {
@@ -117,8 +138,12 @@ func registerNetBuiltins(env *Environment) {
 		}
 
 		addr := fmt.Sprintf("%s:%d", hostStr.Value, portInt.Value)
-		conn, err := net.Dial("tcp", addr)
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
 		if err != nil {
+			if ctx.Err() != nil {
+				return NewCancelledException(contextErrorMessage(ctx))
+			}
 			return newError("IOException: %s", err.Error())
 		}
 
@@ -131,10 +156,11 @@ func registerNetBuiltins(env *Environment) {
 		}
 	}})
 
-	// __tcp_connect_timeout(host, port, timeout_ms) - 带超时连接到 TCP 服务器
+	// __tcp_connect_timeout(host, port, timeout_ms, [ctx]) - 带超时连接到 TCP 服务器
 	env.Set("__tcp_connect_timeout", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 3 {
-			return newError("__tcp_connect_timeout 需要3个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__tcp_connect_timeout", args, 3)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		hostStr, ok := args[0].(*String)
 		if !ok {
@@ -151,8 +177,12 @@ func registerNetBuiltins(env *Environment) {
 
 		addr := fmt.Sprintf("%s:%d", hostStr.Value, portInt.Value)
 		timeout := time.Duration(timeoutInt.Value) * time.Millisecond
-		conn, err := net.DialTimeout("tcp", addr, timeout)
+		dialer := net.Dialer{Timeout: timeout}
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
 		if err != nil {
+			if ctx.Err() != nil {
+				return NewCancelledException(contextErrorMessage(ctx))
+			}
 			return newError("IOException: %s", err.Error())
 		}
 
@@ -166,11 +196,13 @@ func registerNetBuiltins(env *Environment) {
 	}})
 
 	// ===== TCP 连接函数 =====
+	// 读写函数都接受一个可选的 Context 参数，ctx 结束时打断阻塞的读写并抛出 CancelledException
 
-	// __tcp_conn_read(conn, count) - 读取指定字节数
+	// __tcp_conn_read(conn, count, [ctx]) - 读取指定字节数
 	env.Set("__tcp_conn_read", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 2 {
-			return newError("__tcp_conn_read 需要2个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__tcp_conn_read", args, 2)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		conn, ok := args[0].(*TcpConnection)
 		if !ok {
@@ -184,8 +216,15 @@ func registerNetBuiltins(env *Environment) {
 			return newError("__tcp_conn_read 第二个参数必须是整数，得到 %s", args[1].Type())
 		}
 
+		finish, cancelled := conn.watch(ctx)
+		if cancelled != nil {
+			return cancelled
+		}
 		buf := make([]byte, countInt.Value)
 		n, err := conn.Reader.Read(buf)
+		if cancelled := finish(); cancelled != nil {
+			return cancelled
+		}
 		if err != nil && err != io.EOF {
 			return newError("IOException: %s", err.Error())
 		}
@@ -198,10 +237,11 @@ func registerNetBuiltins(env *Environment) {
 		return &Array{Elements: elements}
 	}})
 
-	// __tcp_conn_read_line(conn) - 读取一行
+	// __tcp_conn_read_line(conn, [ctx]) - 读取一行
 	env.Set("__tcp_conn_read_line", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 1 {
-			return newError("__tcp_conn_read_line 需要1个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__tcp_conn_read_line", args, 1)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		conn, ok := args[0].(*TcpConnection)
 		if !ok {
@@ -211,7 +251,14 @@ func registerNetBuiltins(env *Environment) {
 			return newError("IOException: connection is closed")
 		}
 
+		finish, cancelled := conn.watch(ctx)
+		if cancelled != nil {
+			return cancelled
+		}
 		line, err := conn.Reader.ReadString('\n')
+		if cancelled := finish(); cancelled != nil {
+			return cancelled
+		}
 		if err != nil && err != io.EOF {
 			return newError("IOException: %s", err.Error())
 		}
@@ -221,10 +268,11 @@ func registerNetBuiltins(env *Environment) {
 		return &String{Value: line}
 	}})
 
-	// __tcp_conn_read_all(conn) - 读取所有数据直到 EOF
+	// __tcp_conn_read_all(conn, [ctx]) - 读取所有数据直到 EOF
 	env.Set("__tcp_conn_read_all", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 1 {
-			return newError("__tcp_conn_read_all 需要1个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__tcp_conn_read_all", args, 1)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		conn, ok := args[0].(*TcpConnection)
 		if !ok {
@@ -234,17 +282,25 @@ func registerNetBuiltins(env *Environment) {
 			return newError("IOException: connection is closed")
 		}
 
+		finish, cancelled := conn.watch(ctx)
+		if cancelled != nil {
+			return cancelled
+		}
 		data, err := io.ReadAll(conn.Reader)
+		if cancelled := finish(); cancelled != nil {
+			return cancelled
+		}
 		if err != nil {
 			return newError("IOException: %s", err.Error())
 		}
 		return &String{Value: string(data)}
 	}})
 
-	// __tcp_conn_write(conn, data) - 写入字符串
+	// __tcp_conn_write(conn, data, [ctx]) - 写入字符串
 	env.Set("__tcp_conn_write", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 2 {
-			return newError("__tcp_conn_write 需要2个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__tcp_conn_write", args, 2)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		conn, ok := args[0].(*TcpConnection)
 		if !ok {
@@ -258,21 +314,29 @@ func registerNetBuiltins(env *Environment) {
 			return newError("__tcp_conn_write 第二个参数必须是字符串，得到 %s", args[1].Type())
 		}
 
+		finish, cancelled := conn.watch(ctx)
+		if cancelled != nil {
+			return cancelled
+		}
 		n, err := conn.Writer.WriteString(dataStr.Value)
-		if err != nil {
-			return newError("IOException: %s", err.Error())
+		if err == nil {
+			// 自动 flush
+			err = conn.Writer.Flush()
+		}
+		if cancelled := finish(); cancelled != nil {
+			return cancelled
 		}
-		// 自动 flush
-		if err := conn.Writer.Flush(); err != nil {
+		if err != nil {
 			return newError("IOException: %s", err.Error())
 		}
 		return &Integer{Value: int64(n)}
 	}})
 
-	// __tcp_conn_write_line(conn, line) - 写入一行（自动添加换行符）
+	// __tcp_conn_write_line(conn, line, [ctx]) - 写入一行（自动添加换行符）
 	env.Set("__tcp_conn_write_line", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 2 {
-			return newError("__tcp_conn_write_line 需要2个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__tcp_conn_write_line", args, 2)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		conn, ok := args[0].(*TcpConnection)
 		if !ok {
@@ -286,21 +350,29 @@ func registerNetBuiltins(env *Environment) {
 			return newError("__tcp_conn_write_line 第二个参数必须是字符串，得到 %s", args[1].Type())
 		}
 
+		finish, cancelled := conn.watch(ctx)
+		if cancelled != nil {
+			return cancelled
+		}
 		n, err := conn.Writer.WriteString(lineStr.Value + "\n")
-		if err != nil {
-			return newError("IOException: %s", err.Error())
+		if err == nil {
+			// 自动 flush
+			err = conn.Writer.Flush()
+		}
+		if cancelled := finish(); cancelled != nil {
+			return cancelled
 		}
-		// 自动 flush
-		if err := conn.Writer.Flush(); err != nil {
+		if err != nil {
 			return newError("IOException: %s", err.Error())
 		}
 		return &Integer{Value: int64(n)}
 	}})
 
-	// __tcp_conn_write_bytes(conn, bytes) - 写入字节数组
+	// __tcp_conn_write_bytes(conn, bytes, [ctx]) - 写入字节数组
 	env.Set("__tcp_conn_write_bytes", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 2 {
-			return newError("__tcp_conn_write_bytes 需要2个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__tcp_conn_write_bytes", args, 2)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		conn, ok := args[0].(*TcpConnection)
 		if !ok {
@@ -323,21 +395,29 @@ func registerNetBuiltins(env *Environment) {
 			buf[i] = byte(byteInt.Value)
 		}
 
+		finish, cancelled := conn.watch(ctx)
+		if cancelled != nil {
+			return cancelled
+		}
 		n, err := conn.Writer.Write(buf)
-		if err != nil {
-			return newError("IOException: %s", err.Error())
+		if err == nil {
+			// 自动 flush
+			err = conn.Writer.Flush()
 		}
-		// 自动 flush
-		if err := conn.Writer.Flush(); err != nil {
+		if cancelled := finish(); cancelled != nil {
+			return cancelled
+		}
+		if err != nil {
 			return newError("IOException: %s", err.Error())
 		}
 		return &Integer{Value: int64(n)}
 	}})
 
-	// __tcp_conn_flush(conn) - 刷新缓冲区
+	// __tcp_conn_flush(conn, [ctx]) - 刷新缓冲区
 	env.Set("__tcp_conn_flush", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 1 {
-			return newError("__tcp_conn_flush 需要1个参数，得到 %d 个", len(args))
+		ctx, ctxErr := trailingContext("__tcp_conn_flush", args, 1)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		conn, ok := args[0].(*TcpConnection)
 		if !ok {
@@ -347,7 +427,14 @@ func registerNetBuiltins(env *Environment) {
 			return &Null{}
 		}
 
+		finish, cancelled := conn.watch(ctx)
+		if cancelled != nil {
+			return cancelled
+		}
 		err := conn.Writer.Flush()
+		if cancelled := finish(); cancelled != nil {
+			return cancelled
+		}
 		if err != nil {
 			return newError("IOException: %s", err.Error())
 		}
@@ -436,6 +523,8 @@ func registerNetBuiltins(env *Environment) {
 		if err != nil {
 			return newError("IOException: %s", err.Error())
 		}
+		conn.readDeadline = deadline
+		conn.writeDeadline = deadline
 		return &Null{}
 	}})
 
@@ -462,6 +551,7 @@ func registerNetBuiltins(env *Environment) {
 		if err != nil {
 			return newError("IOException: %s", err.Error())
 		}
+		conn.readDeadline = deadline
 		return &Null{}
 	}})
 
@@ -488,6 +578,7 @@ func registerNetBuiltins(env *Environment) {
 		if err != nil {
 			return newError("IOException: %s", err.Error())
 		}
+		conn.writeDeadline = deadline
 		return &Null{}
 	}})
 
@@ -545,12 +636,70 @@ func registerNetBuiltins(env *Environment) {
 		return &Array{Elements: elements}
 	}})
 
+	// __url_encode(str) - 按 URL 查询参数的规则编码字符串（空格编码为 +）
+	env.Set("__url_encode", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 1 {
+			return newError("__url_encode 需要1个参数，得到 %d 个", len(args))
+		}
+		str, ok := args[0].(*String)
+		if !ok {
+			return newError("__url_encode 参数必须是字符串，得到 %s", args[0].Type())
+		}
+		return &String{Value: url.QueryEscape(str.Value)}
+	}})
+
+	// __url_decode(str) - 解码 URL 查询参数（+ 解码为空格）
+	env.Set("__url_decode", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 1 {
+			return newError("__url_decode 需要1个参数，得到 %d 个", len(args))
+		}
+		str, ok := args[0].(*String)
+		if !ok {
+			return newError("__url_decode 参数必须是字符串，得到 %s", args[0].Type())
+		}
+		decoded, err := url.QueryUnescape(str.Value)
+		if err != nil {
+			return newError("无效的 URL 编码: %s", str.Value)
+		}
+		return &String{Value: decoded}
+	}})
+
+	// __url_path_decode(str) - 解码 URL 路径段（+ 保持原样）
+	env.Set("__url_path_decode", &Builtin{Fn: func(args ...Object) Object {
+		if len(args) != 1 {
+			return newError("__url_path_decode 需要1个参数，得到 %d 个", len(args))
+		}
+		str, ok := args[0].(*String)
+		if !ok {
+			return newError("__url_path_decode 参数必须是字符串，得到 %s", args[0].Type())
+		}
+		decoded, err := url.PathUnescape(str.Value)
+		if err != nil {
+			return newError("无效的 URL 编码: %s", str.Value)
+		}
+		return &String{Value: decoded}
+	}})
+
 	// ===== TLS/SSL 函数 =====
 
-	// __tls_upgrade(conn, serverName, skipVerify) - 将 TCP 连接升级为 TLS 加密连接
+	// __tls_upgrade(conn, serverName, skipVerify, [options], [ctx]) - 将 TCP 连接升级为 TLS 加密连接
+	// options 为可选的 Map（或 null）：ca（CA 证书文件，PEM）、cert 和 key（客户端证书和私钥文件，PEM）
+	// 只传 ctx 时 options 位置需要传 null
 	env.Set("__tls_upgrade", &Builtin{Fn: func(args ...Object) Object {
-		if len(args) != 3 {
-			return newError("__tls_upgrade 需要3个参数，得到 %d 个", len(args))
+		var options *Map
+		if len(args) >= 4 {
+			switch opt := args[3].(type) {
+			case *Map:
+				options = opt
+			case *Null:
+			default:
+				return newError("__tls_upgrade 第四个参数必须是 Map，得到 %s", args[3].Type())
+			}
+			args = append(args[:3:3], args[4:]...)
+		}
+		ctx, ctxErr := trailingContext("__tls_upgrade", args, 3)
+		if ctxErr != nil {
+			return ctxErr
 		}
 		conn, ok := args[0].(*TcpConnection)
 		if !ok {
@@ -573,12 +722,23 @@ func registerNetBuiltins(env *Environment) {
 			ServerName:         serverNameStr.Value,
 			InsecureSkipVerify: skipVerifyBool.Value,
 		}
+		if options != nil {
+			if err := applyTLSOptions(tlsConfig, options); err != nil {
+				return err
+			}
+		}
 
 		// 将普通 TCP 连接升级为 TLS 连接
 		tlsConn := tls.Client(conn.Conn, tlsConfig)
 
-		// 执行 TLS 握手
-		if err := tlsConn.Handshake(); err != nil {
+		// 执行 TLS 握手（ctx 结束时中断握手）
+		// 握手失败后连接已不可用（ctx 中断时底层连接已被关闭），标记为关闭，之后的 close() 不再报错
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Conn.Close()
+			conn.Closed = true
+			if ctx.Err() != nil {
+				return NewCancelledException(contextErrorMessage(ctx))
+			}
 			return newError("TLS 握手失败: %s", err.Error())
 		}
 
@@ -594,11 +754,47 @@ func registerNetBuiltins(env *Environment) {
 	}})
 }
 
+// applyTLSOptions 按 __tls_upgrade 的 options 加载 CA 证书和客户端证书
+// 读取 CA 证书文件失败时返回 IOException，配置无效时返回错误
+func applyTLSOptions(tlsConfig *tls.Config, options *Map) Object {
+	option := func(name string) string {
+		if value, ok := options.Pairs[name].(*String); ok {
+			return value.Value
+		}
+		return ""
+	}
+
+	if caFile := option("ca"); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return createIOException("读取 CA 证书失败: " + err.Error())
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return newError("CA 证书文件中没有有效的 PEM 证书: %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certFile, keyFile := option("cert"), option("key")
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return newError("客户端证书和私钥必须同时指定")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return newError("加载客户端证书失败: %s", err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return nil
+}
+
 // TcpListener TCP 监听器对象
 type TcpListener struct {
 	Listener net.Listener
 	Address  string
-	Closed   bool
+	Closed   atomic.Bool
 }
 
 func (tl *TcpListener) Type() ObjectType { return "TCP_LISTENER" }
@@ -612,14 +808,33 @@ type TcpConnection struct {
 	LocalAddr  string
 	RemoteAddr string
 	Closed     bool
+
+	// 用户通过 set_timeout 设置的截止时间，被 Context 打断后据此恢复
+	readDeadline  time.Time
+	writeDeadline time.Time
 }
 
 func (tc *TcpConnection) Type() ObjectType { return "TCP_CONNECTION" }
 func (tc *TcpConnection) Inspect() string  { return "TcpConnection(" + tc.RemoteAddr + ")" }
 
+// watch 让连接上的一次阻塞读写受 ctx 控制（见 watchContext）
+func (tc *TcpConnection) watch(ctx context.Context) (func() *ThrownException, *ThrownException) {
+	return watchContext(ctx, tc.Conn, func() {
+		tc.Conn.SetReadDeadline(tc.readDeadline)
+		tc.Conn.SetWriteDeadline(tc.writeDeadline)
+		// 被打断的写入会让 bufio.Writer 一直处于错误状态，丢弃未写出的数据后重置
+		tc.Writer.Reset(tc.Conn)
+	})
+}
+
 // createIOException 创建一个 IOException 异常实例
 // 这个异常可以被 try-catch 捕获，并提供 getMessage() 等标准方法
 func createIOException(message string) *ThrownException {
+	return createBuiltinException("IOException", message)
+}
+
+// createBuiltinException 创建一个由内置函数抛出的异常实例（继承自 Exception）
+func createBuiltinException(className string, message string) *ThrownException {
 	// 创建 Exception 基类
 	exceptionClass := &Class{
 		Name: "Exception",
@@ -649,9 +864,9 @@ func createIOException(message string) *ThrownException {
 		},
 	}
 
-	// 创建 IOException 类，继承自 Exception
-	ioExceptionClass := &Class{
-		Name:   "IOException",
+	// 创建异常类，继承自 Exception
+	builtinExceptionClass := &Class{
+		Name:   className,
 		Parent: exceptionClass,
 		Methods: map[string]*ClassMethod{
 			"getMessage": {
@@ -681,7 +896,7 @@ func createIOException(message string) *ThrownException {
 
 	return &ThrownException{
 		Exception: &Instance{
-			Class: ioExceptionClass,
+			Class: builtinExceptionClass,
 			Fields: map[string]Object{
 				"message": &String{Value: message},
 				"code":    &Integer{Value: 0},
@@ -76,10 +76,16 @@ func New() *Interpreter {
 	registerIOBuiltins(env)
 	// 注册网络操作内置函数
 	registerNetBuiltins(env)
+	// 注册 HTTP 内置函数
+	registerHttpBuiltins(env)
+	// 注册取消上下文内置函数
+	registerContextBuiltins(env)
 	// 注册字节操作内置函数
 	registerBytesBuiltins(env)
 	// 注册加密内置函数
 	registerCryptoBuiltins(env)
+	// 注册 JSON 内置函数
+	registerJsonBuiltins(env)
 	// 注册正则表达式内置函数
 	registerRegexBuiltins(env)
 	// 注册日期时间内置函数
@@ -88,6 +94,8 @@ func New() *Interpreter {
 	registerConsoleBuiltins(env)
 	// 注册注解相关内置函数
 	registerAnnotationBuiltins(env)
+	// 注册断言相关内置函数
+	registerAssertBuiltins(env)
 	// 设置全局环境引用（用于注解内置函数）
 	globalEnv = env
 	// 注册异常类
@@ -182,7 +190,7 @@ func (i *Interpreter) Eval(node parser.Node) Object {
 			return &ReturnValue{Value: &Null{}}
 		}
 		val := i.Eval(node.ReturnValue)
-		if isError(val) {
+		if isError(val) || isThrownException(val) {
 			return val
 		}
 		return &ReturnValue{Value: val}
@@ -204,8 +212,12 @@ func (i *Interpreter) Eval(node parser.Node) Object {
 		return i.evalThrowStatement(node)
 	case *parser.GoStatement:
 		return i.evalGoStatement(node)
+	case *parser.GoExpression:
+		return i.evalGoExpression(node)
 	case *parser.SwitchStatement:
 		return i.evalSwitchStatement(node)
+	case *parser.SelectStatement:
+		return i.evalSelectStatement(node)
 	case *parser.IncrementStatement:
 		return i.evalIncrementStatement(node)
 	case *parser.IntegerLiteral:
@@ -224,17 +236,23 @@ func (i *Interpreter) Eval(node parser.Node) Object {
 		return i.evalIdentifier(node)
 	case *parser.PrefixExpression:
 		right := i.Eval(node.Right)
-		if isError(right) {
+		if isError(right) || isThrownException(right) {
 			return right
 		}
 		return i.evalPrefixExpression(node.Operator, right)
 	case *parser.InfixExpression:
 		left := i.Eval(node.Left)
-		if isError(left) {
+		if isError(left) || isThrownException(left) {
 			return left
 		}
+		// && 和 || 短路求值：左侧已能决定结果时不再计算右侧
+		if leftBool, ok := left.(*Boolean); ok {
+			if (node.Operator == "&&" && !leftBool.Value) || (node.Operator == "||" && leftBool.Value) {
+				return &Boolean{Value: leftBool.Value}
+			}
+		}
 		right := i.Eval(node.Right)
-		if isError(right) {
+		if isError(right) || isThrownException(right) {
 			return right
 		}
 		return i.evalInfixExpression(node.Operator, left, right)
@@ -260,7 +278,7 @@ func (i *Interpreter) Eval(node parser.Node) Object {
 						return newError("命名空间 %s 中没有成员 %s", parts[0], parts[1])
 					}
 					args := i.evalExpressions(node.Arguments)
-					if len(args) == 1 && isError(args[0]) {
+					if len(args) == 1 && (isError(args[0]) || isThrownException(args[0])) {
 						return args[0]
 					}
 					return i.applyFunction(member, args, node.Arguments)
@@ -272,7 +290,7 @@ func (i *Interpreter) Eval(node parser.Node) Object {
 			return function
 		}
 		args := i.evalExpressions(node.Arguments)
-		if len(args) == 1 && isError(args[0]) {
+		if len(args) == 1 && (isError(args[0]) || isThrownException(args[0])) {
 			return args[0]
 		}
 		return i.applyFunction(function, args, node.Arguments)
@@ -561,6 +579,10 @@ func (i *Interpreter) evalInfixExpression(operator string, left, right Object) O
 		default:
 			return newError("枚举类型不支持运算符: %s", operator)
 		}
+	// 布尔值按值比较（内置函数返回的布尔值不一定是同一个对象）
+	case left.Type() == BOOLEAN_OBJ && right.Type() == BOOLEAN_OBJ && (operator == "==" || operator == "!="):
+		equal := left.(*Boolean).Value == right.(*Boolean).Value
+		return &Boolean{Value: equal == (operator == "==")}
 	case operator == "==":
 		return &Boolean{Value: left == right}
 	case operator == "!=":
@@ -738,12 +760,10 @@ func (i *Interpreter) evalBooleanInfixExpression(operator string, left, right Ob
 
 // evalBangOperatorExpression 执行 ! 运算符
 func (i *Interpreter) evalBangOperatorExpression(right Object) Object {
-	switch right {
-	case &Boolean{Value: true}:
-		return &Boolean{Value: false}
-	case &Boolean{Value: false}:
-		return &Boolean{Value: true}
-	case &Null{}:
+	switch right := right.(type) {
+	case *Boolean:
+		return &Boolean{Value: !right.Value}
+	case *Null:
 		return &Boolean{Value: true}
 	default:
 		return &Boolean{Value: false}
@@ -833,7 +853,7 @@ func (i *Interpreter) evalExpressions(args []parser.CallArgument) []Object {
 
 	for _, arg := range args {
 		evaluated := i.Eval(arg.Value)
-		if isError(evaluated) {
+		if isError(evaluated) || isThrownException(evaluated) {
 			return []Object{evaluated}
 		}
 		result = append(result, evaluated)
@@ -863,9 +883,12 @@ func (i *Interpreter) applyFunction(fn Object, args []Object, callArgs []parser.
 		evaluated := i.evalBlockStatementWithEnv(body, extendedEnv)
 		result := unwrapReturnValue(evaluated)
 
-		// 检查返回类型
-		if len(fn.ReturnType) == 0 {
-			// 函数没有声明返回类型，不应该返回非 null 值
+		// 检查返回类型（运行时错误和抛出的异常照常传播）
+		if len(fn.ReturnType) == 0 && !isError(result) && !isThrownException(result) {
+			// 函数没有声明返回类型，不应该用 return 返回非 null 值；最后一条表达式语句的值被忽略
+			if _, explicit := evaluated.(*ReturnValue); !explicit {
+				return &Null{}
+			}
 			if result != nil && result.Type() != NULL_OBJ {
 				return newError("函数未声明返回类型，但返回了值")
 			}
@@ -909,6 +932,9 @@ func (i *Interpreter) applyFunction(fn Object, args []Object, callArgs []parser.
 	case *BoundAtomicMethod:
 		// 处理 Atomic 方法调用
 		return i.evalAtomicMethodCall(fn.Atomic, fn.MethodName, args)
+	case *BoundTaskMethod:
+		// 处理 Task 方法调用
+		return i.evalTaskMethodCall(fn.Task, fn.MethodName, args)
 	default:
 		return newError("不是函数: %s", fn.Type())
 	}
@@ -1127,13 +1153,16 @@ func (i *Interpreter) evalTryStatement(node *parser.TryStatement) Object {
 	if node.FinallyBlock != nil {
 		finallyResult := i.Eval(node.FinallyBlock)
 
-		// finally 块中的错误或异常会替代原有的结果
+		// finally 块中的错误、异常或 return 会替代原有的结果
 		if isError(finallyResult) {
 			return finallyResult
 		}
 		if isThrownException(finallyResult) {
 			return finallyResult
 		}
+		if _, ok := finallyResult.(*ReturnValue); ok {
+			return finallyResult
+		}
 	}
 
 	// 如果异常未被捕获，继续向上传递
@@ -1713,8 +1742,8 @@ func (i *Interpreter) loadNamespaceFile(namespace string, className string) erro
 	p := newParser(l)
 	program := p.ParseProgram()
 
-	if len(p.Errors()) > 0 {
-		return fmt.Errorf("解析文件 %s 错误: %s", loadedPath, p.Errors()[0])
+	if diags := p.Diagnostics(); len(diags) > 0 {
+		return diags
 	}
 
 	// 保存当前状态，执行完后恢复
@@ -1912,6 +1941,9 @@ func (i *Interpreter) evalClassStatement(node *parser.ClassStatement) Object {
 				}
 			} else {
 				// 实例变量
+				if _, exists := class.Variables[m.Name.Value]; !exists {
+					class.FieldOrder = append(class.FieldOrder, m.Name.Value)
+				}
 				class.Variables[m.Name.Value] = classVar
 			}
 		case *parser.ClassMethod:
@@ -2084,6 +2116,10 @@ func (i *Interpreter) evalNewExpression(node *parser.NewExpression) Object {
 	}
 
 	if !ok {
+		// 没有同名的用户类时才是内置 Task 类型
+		if className == "Task" {
+			return newError("Task 不能用 new 创建，请使用 go 表达式（t := go f()）")
+		}
 		return newError("未定义的类: %s", className)
 	}
 
@@ -2115,7 +2151,7 @@ func (i *Interpreter) evalNewExpression(node *parser.NewExpression) Object {
 	if constructor, ok := class.GetMethod("__construct"); ok {
 		// 绑定参数
 		args := i.evalExpressions(node.Arguments)
-		if len(args) == 1 && isError(args[0]) {
+		if len(args) == 1 && (isError(args[0]) || isThrownException(args[0])) {
 			return args[0]
 		}
 
@@ -2371,6 +2407,12 @@ func (i *Interpreter) evalMemberAccessExpression(node *parser.MemberAccessExpres
 			Atomic:     object,
 			MethodName: memberName,
 		}
+	case *TaskObject:
+		// Task 方法
+		return &BoundTaskMethod{
+			Task:       object,
+			MethodName: memberName,
+		}
 	case *EnumValue:
 		// 访问枚举值方法或字段
 		// 内置方法
@@ -2716,6 +2758,11 @@ func (i *Interpreter) evalStaticCallExpression(node *parser.StaticCallExpression
 			}
 		}
 
+		// 没有同名的用户类时才是内置 Task 类型的组合方法（与虚拟机一致：用户定义的 Task 覆盖内置类型）
+		if !ok && className == "Task" {
+			return i.evalTaskStaticCall(methodName, node.Arguments)
+		}
+
 		if !ok {
 			return newError("未定义的类或枚举: %s", className)
 		}
@@ -2725,7 +2772,7 @@ func (i *Interpreter) evalStaticCallExpression(node *parser.StaticCallExpression
 	if enum, isEnum := classObj.(*Enum); isEnum {
 		// 求值参数
 		args := i.evalExpressions(node.Arguments)
-		if len(args) == 1 && isError(args[0]) {
+		if len(args) == 1 && (isError(args[0]) || isThrownException(args[0])) {
 			return args[0]
 		}
 		return i.evalEnumStaticMethodCall(enum, methodName, args)
@@ -2746,7 +2793,7 @@ func (i *Interpreter) evalStaticCallExpression(node *parser.StaticCallExpression
 
 	// 求值参数
 	args := i.evalExpressions(node.Arguments)
-	if len(args) == 1 && isError(args[0]) {
+	if len(args) == 1 && (isError(args[0]) || isThrownException(args[0])) {
 		return args[0]
 	}
 
@@ -2878,7 +2925,7 @@ func (i *Interpreter) evalSuperMethodCall(methodName string, arguments []parser.
 
 	// 求值参数
 	args := i.evalExpressions(arguments)
-	if len(args) == 1 && isError(args[0]) {
+	if len(args) == 1 && (isError(args[0]) || isThrownException(args[0])) {
 		return args[0]
 	}
 
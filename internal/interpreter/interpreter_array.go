@@ -113,12 +113,12 @@ func (i *Interpreter) evalTypedArrayLiteral(node *parser.TypedArrayLiteral) Obje
 // evalIndexExpression 执行索引访问表达式 array[index]
 func (i *Interpreter) evalIndexExpression(node *parser.IndexExpression) Object {
 	left := i.Eval(node.Left)
-	if isError(left) {
+	if isError(left) || isThrownException(left) {
 		return left
 	}
 
 	index := i.Eval(node.Index)
-	if isError(index) {
+	if isError(index) || isThrownException(index) {
 		return index
 	}
 
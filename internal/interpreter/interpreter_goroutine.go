@@ -1,40 +1,21 @@
 package interpreter
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
 	"sync"
+	"time"
 
 	"github.com/tangzhangming/longlang/internal/parser"
 )
 
 // evalGoStatement 执行 go 语句，启动一个新的协程
 func (i *Interpreter) evalGoStatement(node *parser.GoStatement) Object {
-	// 克隆当前环境，协程使用独立的环境副本
-	envCopy := i.env.Clone()
-
-	// 保存当前命名空间和其他状态
-	currentNamespace := i.currentNamespace
-	stdlibPath := i.stdlibPath
-	projectRoot := i.projectRoot
-	projectConfig := i.projectConfig
-	namespaceMgr := i.namespaceMgr
-	loadedNamespaces := make(map[string]bool)
-	for k, v := range i.loadedNamespaces {
-		loadedNamespaces[k] = v
-	}
+	goroutineInterpreter := i.newGoroutineInterpreter()
 
 	// 启动 Go 协程
 	go func() {
-		// 创建新的解释器实例用于协程执行
-		goroutineInterpreter := &Interpreter{
-			env:              envCopy,
-			currentNamespace: currentNamespace,
-			stdlibPath:       stdlibPath,
-			projectRoot:      projectRoot,
-			projectConfig:    projectConfig,
-			namespaceMgr:     namespaceMgr,
-			loadedNamespaces: loadedNamespaces,
-		}
-
 		// 执行表达式
 		goroutineInterpreter.Eval(node.Call)
 	}()
@@ -42,6 +23,101 @@ func (i *Interpreter) evalGoStatement(node *parser.GoStatement) Object {
 	return nil
 }
 
+// evalGoExpression 执行 go 表达式，在新的协程中执行调用并返回对应的 Task
+// 调用的返回值成为任务的结果，未捕获的异常使任务失败，由 await() 重新抛给等待方
+func (i *Interpreter) evalGoExpression(node *parser.GoExpression) Object {
+	goroutineInterpreter := i.newGoroutineInterpreter()
+	task := NewTask()
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				task.Complete(nil, &taskFailure{value: newError("协程崩溃: %v", r)})
+			}
+		}()
+
+		result := unwrapReturnValue(goroutineInterpreter.Eval(node.Call))
+		if isError(result) || isThrownException(result) {
+			task.Complete(nil, &taskFailure{value: result})
+			return
+		}
+		task.Complete(result, nil)
+	}()
+
+	return task
+}
+
+// newGoroutineInterpreter 创建协程使用的解释器实例
+// 协程使用当前环境的副本；必须在父协程中调用，以免与父协程对环境的修改产生竞争
+func (i *Interpreter) newGoroutineInterpreter() *Interpreter {
+	loadedNamespaces := make(map[string]bool)
+	for k, v := range i.loadedNamespaces {
+		loadedNamespaces[k] = v
+	}
+	return &Interpreter{
+		env:              i.env.Clone(),
+		currentNamespace: i.currentNamespace,
+		stdlibPath:       i.stdlibPath,
+		projectRoot:      i.projectRoot,
+		projectConfig:    i.projectConfig,
+		namespaceMgr:     i.namespaceMgr,
+		loadedNamespaces: loadedNamespaces,
+	}
+}
+
+// taskFailure 解释器中任务的失败原因，保存协程中未捕获的异常或运行时错误对象
+type taskFailure struct {
+	value Object // *ThrownException 或 *Error
+}
+
+func (f *taskFailure) Error() string {
+	if thrown, ok := f.value.(*ThrownException); ok {
+		return thrown.GetMessage()
+	}
+	return f.value.Inspect()
+}
+
+// evalSelectStatement 执行 select 语句
+// 按顺序求值所有分支的通道和操作数，然后等待任一分支就绪
+func (i *Interpreter) evalSelectStatement(node *parser.SelectStatement) Object {
+	cases := make([]SelectCase, len(node.Cases))
+	for idx, c := range node.Cases {
+		var target, value Object = &Null{}, &Null{}
+		if c.Channel != nil {
+			target = i.Eval(c.Channel)
+			if isError(target) {
+				return target
+			}
+		}
+		if c.Value != nil {
+			value = i.Eval(c.Value)
+			if isError(value) {
+				return value
+			}
+		}
+		selectCase, err := NewSelectCase(c.Kind, target, value)
+		if err != nil {
+			return newError("%s", err.Error())
+		}
+		cases[idx] = selectCase
+	}
+
+	chosen, received, err := Select(cases, node.Default != nil)
+	if err != nil {
+		return newError("%s", err.Error())
+	}
+	if chosen < 0 {
+		return i.evalBlockStatement(node.Default)
+	}
+
+	// 与 switch 的 case 体一样在当前环境中执行，分支体可以修改外部变量
+	selected := node.Cases[chosen]
+	if selected.Binding != nil {
+		i.env.Set(selected.Binding.Value, received)
+	}
+	return i.evalBlockStatement(selected.Body)
+}
+
 // ========== Channel 实现 ==========
 
 // ChannelObject Channel 对象
@@ -126,6 +202,304 @@ func (c *ChannelObject) Cap() int {
 	return c.capacity
 }
 
+// ========== Select 实现 ==========
+
+// SelectCase select 语句中一个已求值的分支
+type SelectCase struct {
+	Kind    parser.SelectCaseKind // 分支种类
+	Channel *ChannelObject        // receive 和 send 分支的通道
+	Value   Object                // send 分支发送的值
+	Timeout time.Duration         // after 分支的等待时间
+}
+
+// NewSelectCase 根据分支种类和求值后的操作数创建分支
+// target 是 receive/send 分支的通道，value 是 send 的值或 after 的毫秒数
+func NewSelectCase(kind parser.SelectCaseKind, target Object, value Object) (SelectCase, error) {
+	switch kind {
+	case parser.SelectReceive, parser.SelectSend:
+		ch, ok := target.(*ChannelObject)
+		if !ok {
+			method := "receive"
+			if kind == parser.SelectSend {
+				method = "send"
+			}
+			return SelectCase{}, fmt.Errorf("select 分支的 %s() 只能用于 Channel，得到 %s", method, target.Type())
+		}
+		return SelectCase{Kind: kind, Channel: ch, Value: value}, nil
+
+	case parser.SelectAfter:
+		ms, ok := value.(*Integer)
+		if !ok || ms.Value < 0 {
+			return SelectCase{}, fmt.Errorf("after() 参数必须是非负整数（毫秒）")
+		}
+		return SelectCase{Kind: kind, Timeout: time.Duration(ms.Value) * time.Millisecond}, nil
+	}
+	return SelectCase{}, fmt.Errorf("未知的 select 分支类型 %d", kind)
+}
+
+// Select 等待任一分支就绪并完成它的通道操作，返回分支下标和接收到的值
+// 多个分支同时就绪时随机选择一个；hasDefault 为 true 且没有分支就绪时立即返回 -1
+// 从已关闭的通道接收得到 null，向已关闭的通道发送返回错误
+func Select(cases []SelectCase, hasDefault bool) (chosen int, received Object, err error) {
+	selectCases := make([]reflect.SelectCase, 0, len(cases)+1)
+	for _, c := range cases {
+		switch c.Kind {
+		case parser.SelectReceive:
+			selectCases = append(selectCases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(c.Channel.ch)})
+		case parser.SelectSend:
+			if c.Channel.IsClosed() {
+				return 0, nil, fmt.Errorf("不能向已关闭的通道发送数据")
+			}
+			selectCases = append(selectCases, reflect.SelectCase{Dir: reflect.SelectSend, Chan: reflect.ValueOf(c.Channel.ch), Send: reflect.ValueOf(&c.Value).Elem()})
+		case parser.SelectAfter:
+			selectCases = append(selectCases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(time.After(c.Timeout))})
+		}
+	}
+	if hasDefault {
+		selectCases = append(selectCases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	}
+
+	// 等待期间通道被关闭时，发送会 panic
+	defer func() {
+		if r := recover(); r != nil {
+			chosen, received, err = 0, nil, fmt.Errorf("不能向已关闭的通道发送数据")
+		}
+	}()
+
+	index, value, ok := reflect.Select(selectCases)
+	if index == len(cases) {
+		return -1, &Null{}, nil
+	}
+	received = &Null{}
+	if cases[index].Kind == parser.SelectReceive && ok {
+		if obj, isObject := value.Interface().(Object); isObject && obj != nil {
+			received = obj
+		}
+	}
+	return index, received, nil
+}
+
+// ========== Task 实现 ==========
+
+var (
+	// ErrTaskCancelled 等待已取消的任务时返回的错误
+	ErrTaskCancelled = errors.New("任务已取消")
+	// ErrTaskTimeout await(timeoutMs) 超时返回的错误（任务本身继续运行）
+	ErrTaskTimeout = errors.New("等待任务超时")
+)
+
+// TaskObject 异步任务（t := go f() 的结果）
+// 任务只完成一次：函数返回、抛出异常或被取消，先到者生效，之后的结果被丢弃
+type TaskObject struct {
+	done      chan struct{} // 完成时关闭
+	mu        sync.Mutex
+	result    Object // 成功时的返回值
+	err       error  // 失败原因（异常由各执行引擎包装为 error）
+	cancelled bool
+}
+
+func (t *TaskObject) Type() ObjectType { return "TASK" }
+func (t *TaskObject) Inspect() string {
+	switch {
+	case !t.IsDone():
+		return "Task(running)"
+	case t.IsCancelled():
+		return "Task(cancelled)"
+	case t.err != nil:
+		return "Task(failed)"
+	}
+	return "Task(done)"
+}
+
+// NewTask 创建未完成的任务
+func NewTask() *TaskObject {
+	return &TaskObject{done: make(chan struct{})}
+}
+
+// Complete 以结果或错误完成任务，任务已完成时返回 false
+func (t *TaskObject) Complete(result Object, err error) bool {
+	return t.finish(result, err, false)
+}
+
+// Cancel 取消任务，任务已完成时返回 false
+// 取消是协作式的：正在执行的函数不会被中断，只是它的结果会被丢弃
+func (t *TaskObject) Cancel() bool {
+	return t.finish(nil, ErrTaskCancelled, true)
+}
+
+// finish 完成任务（只有第一次生效）
+func (t *TaskObject) finish(result Object, err error, cancelled bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	select {
+	case <-t.done:
+		return false
+	default:
+	}
+	if result == nil {
+		result = &Null{}
+	}
+	t.result, t.err, t.cancelled = result, err, cancelled
+	close(t.done)
+	return true
+}
+
+// Wait 等待任务完成并返回结果或失败原因
+// timeout 小于 0 表示一直等待，超时返回 ErrTaskTimeout
+func (t *TaskObject) Wait(timeout time.Duration) (Object, error) {
+	if timeout < 0 {
+		<-t.done
+	} else {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-t.done:
+		case <-timer.C:
+			return nil, ErrTaskTimeout
+		}
+	}
+	// done 关闭后结果不再变化
+	return t.result, t.err
+}
+
+// Done 返回任务完成时关闭的通道
+func (t *TaskObject) Done() <-chan struct{} {
+	return t.done
+}
+
+// IsDone 检查任务是否已完成（包括失败和取消）
+func (t *TaskObject) IsDone() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsCancelled 检查任务是否已被取消
+func (t *TaskObject) IsCancelled() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cancelled
+}
+
+// TaskAll 返回等待所有任务的组合任务
+// 全部成功时结果是按顺序排列的结果数组；任一任务失败时立即以该失败完成
+func TaskAll(tasks []*TaskObject) *TaskObject {
+	all := NewTask()
+	if len(tasks) == 0 {
+		all.Complete(&Array{Elements: []Object{}}, nil)
+		return all
+	}
+
+	results := make([]Object, len(tasks))
+	var mu sync.Mutex
+	remaining := len(tasks)
+	for idx, task := range tasks {
+		go func(idx int, task *TaskObject) {
+			select {
+			case <-task.Done():
+			case <-all.Done():
+				return
+			}
+			result, err := task.Wait(-1)
+			if err != nil {
+				all.Complete(nil, err)
+				return
+			}
+			mu.Lock()
+			results[idx] = result
+			remaining--
+			finished := remaining == 0
+			mu.Unlock()
+			if finished {
+				all.Complete(&Array{Elements: results}, nil)
+			}
+		}(idx, task)
+	}
+	return all
+}
+
+// TaskAny 返回等待第一个成功任务的组合任务
+// 结果是最先成功的任务的结果；全部失败时以最先发生的失败完成
+func TaskAny(tasks []*TaskObject) (*TaskObject, error) {
+	if len(tasks) == 0 {
+		return nil, fmt.Errorf("Task::any() 需要至少一个任务")
+	}
+
+	first := NewTask()
+	var mu sync.Mutex
+	var firstErr error
+	failed := 0
+	for _, task := range tasks {
+		go func(task *TaskObject) {
+			select {
+			case <-task.Done():
+			case <-first.Done():
+				return
+			}
+			result, err := task.Wait(-1)
+			if err == nil {
+				first.Complete(result, nil)
+				return
+			}
+			mu.Lock()
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+			allFailed := failed == len(tasks)
+			mu.Unlock()
+			if allFailed {
+				first.Complete(nil, firstErr)
+			}
+		}(task)
+	}
+	return first, nil
+}
+
+// TaskStaticCall 执行 Task 的静态方法（Task::all、Task::any），参数是一个任务数组
+func TaskStaticCall(method string, args []Object) (*TaskObject, error) {
+	if method != "all" && method != "any" {
+		return nil, fmt.Errorf("Task 没有静态方法: %s", method)
+	}
+	if len(args) != 1 {
+		return nil, fmt.Errorf("Task::%s() 需要1个任务数组参数", method)
+	}
+	arr, ok := args[0].(*Array)
+	if !ok {
+		return nil, fmt.Errorf("Task::%s() 参数必须是数组，得到 %s", method, args[0].Type())
+	}
+	tasks := make([]*TaskObject, len(arr.Elements))
+	for idx, elem := range arr.Elements {
+		task, ok := elem.(*TaskObject)
+		if !ok {
+			return nil, fmt.Errorf("Task::%s() 的数组元素必须是 Task，第 %d 个元素是 %s", method, idx, elem.Type())
+		}
+		tasks[idx] = task
+	}
+	if method == "all" {
+		return TaskAll(tasks), nil
+	}
+	return TaskAny(tasks)
+}
+
+// TaskAwaitTimeout 解析 await() 的可选超时参数（毫秒），未指定时返回 -1（一直等待）
+func TaskAwaitTimeout(args []Object) (time.Duration, error) {
+	switch len(args) {
+	case 0:
+		return -1, nil
+	case 1:
+		ms, ok := args[0].(*Integer)
+		if !ok || ms.Value < 0 {
+			return 0, fmt.Errorf("await() 参数必须是非负整数（毫秒）")
+		}
+		return time.Duration(ms.Value) * time.Millisecond, nil
+	}
+	return 0, fmt.Errorf("await() 最多接受1个参数")
+}
+
 // ========== WaitGroup 实现 ==========
 
 // WaitGroupObject WaitGroup 对象
@@ -216,6 +590,30 @@ func (a *AtomicObject) CompareAndSwap(expected, newValue Object) bool {
 	return false
 }
 
+// AddInt 将整数值加上 delta 并返回新值；当前值不是整数时返回 false
+func (a *AtomicObject) AddInt(delta int64) (Object, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	current, ok := a.value.(*Integer)
+	if !ok {
+		return nil, false
+	}
+	a.value = &Integer{Value: current.Value + delta}
+	return a.value, true
+}
+
+// Update 在锁保护下用 fn 的返回值替换当前值，fn 出错时保持原值
+func (a *AtomicObject) Update(fn func(current Object) (Object, error)) (Object, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	result, err := fn(a.value)
+	if err != nil {
+		return nil, err
+	}
+	a.value = result
+	return result, nil
+}
+
 // atomicObjectsEqual 比较两个对象是否相等
 func atomicObjectsEqual(a, b Object) bool {
 	if a == nil && b == nil {
@@ -310,6 +708,15 @@ type BoundAtomicMethod struct {
 func (b *BoundAtomicMethod) Type() ObjectType { return FUNCTION_OBJ }
 func (b *BoundAtomicMethod) Inspect() string  { return "atomic method " + b.MethodName }
 
+// BoundTaskMethod Task 方法绑定
+type BoundTaskMethod struct {
+	Task       *TaskObject
+	MethodName string
+}
+
+func (b *BoundTaskMethod) Type() ObjectType { return FUNCTION_OBJ }
+func (b *BoundTaskMethod) Inspect() string  { return "task method " + b.MethodName }
+
 // ========== 方法调用实现 ==========
 
 // evalChannelMethodCall 执行 Channel 方法调用
@@ -455,29 +862,20 @@ func (i *Interpreter) evalAtomicMethodCall(a *AtomicObject, methodName string, a
 		if !ok {
 			return newError("add() 参数必须是整数")
 		}
-		a.mu.Lock()
-		defer a.mu.Unlock()
-		if current, ok := a.value.(*Integer); ok {
-			a.value = &Integer{Value: current.Value + delta.Value}
-			return a.value
+		if value, ok := a.AddInt(delta.Value); ok {
+			return value
 		}
 		return newError("Atomic 值不是整数，无法使用 add()")
 
 	case "increment":
-		a.mu.Lock()
-		defer a.mu.Unlock()
-		if current, ok := a.value.(*Integer); ok {
-			a.value = &Integer{Value: current.Value + 1}
-			return a.value
+		if value, ok := a.AddInt(1); ok {
+			return value
 		}
 		return newError("Atomic 值不是整数，无法使用 increment()")
 
 	case "decrement":
-		a.mu.Lock()
-		defer a.mu.Unlock()
-		if current, ok := a.value.(*Integer); ok {
-			a.value = &Integer{Value: current.Value - 1}
-			return a.value
+		if value, ok := a.AddInt(-1); ok {
+			return value
 		}
 		return newError("Atomic 值不是整数，无法使用 decrement()")
 
@@ -508,3 +906,55 @@ func (i *Interpreter) evalAtomicMethodCall(a *AtomicObject, methodName string, a
 	}
 }
 
+// evalTaskMethodCall 执行 Task 方法调用
+func (i *Interpreter) evalTaskMethodCall(t *TaskObject, methodName string, args []Object) Object {
+	switch methodName {
+	case "await":
+		timeout, err := TaskAwaitTimeout(args)
+		if err != nil {
+			return newError("%s", err.Error())
+		}
+		result, err := t.Wait(timeout)
+		if err != nil {
+			// 任务中未捕获的异常原样重新抛出
+			if failure, ok := err.(*taskFailure); ok {
+				return failure.value
+			}
+			// 等待已取消的任务抛出 CancelledException
+			if errors.Is(err, ErrTaskCancelled) {
+				return NewCancelledException(err.Error())
+			}
+			return newError("%s", err.Error())
+		}
+		return result
+
+	case "cancel":
+		return &Boolean{Value: t.Cancel()}
+
+	case "isDone":
+		return &Boolean{Value: t.IsDone()}
+
+	case "isCancelled":
+		return &Boolean{Value: t.IsCancelled()}
+
+	default:
+		return newError("Task 没有方法: %s", methodName)
+	}
+}
+
+// evalTaskStaticCall 执行 Task 的静态方法（Task::all、Task::any）
+func (i *Interpreter) evalTaskStaticCall(methodName string, arguments []parser.CallArgument) Object {
+	args := make([]Object, 0, len(arguments))
+	for _, arg := range arguments {
+		value := i.Eval(arg.Value)
+		if isError(value) || isThrownException(value) {
+			return value
+		}
+		args = append(args, value)
+	}
+	task, err := TaskStaticCall(methodName, args)
+	if err != nil {
+		return newError("%s", err.Error())
+	}
+	return task
+}
@@ -224,6 +224,14 @@ func (i *Interpreter) assertMapType(value Object, targetTypeName string, targetT
 
 // assertClassType 断言类/接口类型
 func (i *Interpreter) assertClassType(value Object, targetTypeName string) (Object, bool) {
+	// 枚举值：匹配枚举本身或其实现的接口
+	if enumValue, ok := value.(*EnumValue); ok {
+		if enumValue.Enum.Implements(targetTypeName) {
+			return enumValue, true
+		}
+		return nil, false
+	}
+
 	instance, ok := value.(*Instance)
 	if !ok {
 		return nil, false
@@ -3,10 +3,11 @@ package interpreter
 import (
 	"fmt"
 	"strings"
+	"sync"
 )
 
 // Namespace 命名空间对象
-// 存储命名空间中的类、函数、枚举等符号
+// 存储命名空间中的类、函数、枚举等符号，Get/Set 方法可以在多个协程中并发调用
 type Namespace struct {
 	FullName   string               // 完全限定名，如 "Mycompany.Myapp.Models"
 	Classes    map[string]*Class    // 类定义
@@ -14,6 +15,8 @@ type Namespace struct {
 	Interfaces map[string]*Interface // 接口定义
 	Functions  map[string]*Function // 函数定义
 	Variables  map[string]Object    // 变量（常量等）
+
+	mu sync.RWMutex // 保护上述映射的并发读写
 }
 
 // NewNamespace 创建新的命名空间
@@ -30,51 +33,68 @@ func NewNamespace(fullName string) *Namespace {
 
 // GetClass 获取类
 func (ns *Namespace) GetClass(name string) (*Class, bool) {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
 	class, ok := ns.Classes[name]
 	return class, ok
 }
 
 // SetClass 设置类
 func (ns *Namespace) SetClass(name string, class *Class) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
 	ns.Classes[name] = class
 }
 
 // GetEnum 获取枚举
 func (ns *Namespace) GetEnum(name string) (*Enum, bool) {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
 	enum, ok := ns.Enums[name]
 	return enum, ok
 }
 
 // SetEnum 设置枚举
 func (ns *Namespace) SetEnum(name string, enum *Enum) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
 	ns.Enums[name] = enum
 }
 
 // GetInterface 获取接口
 func (ns *Namespace) GetInterface(name string) (*Interface, bool) {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
 	iface, ok := ns.Interfaces[name]
 	return iface, ok
 }
 
 // SetInterface 设置接口
 func (ns *Namespace) SetInterface(name string, iface *Interface) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
 	ns.Interfaces[name] = iface
 }
 
 // GetFunction 获取函数
 func (ns *Namespace) GetFunction(name string) (*Function, bool) {
+	ns.mu.RLock()
+	defer ns.mu.RUnlock()
 	fn, ok := ns.Functions[name]
 	return fn, ok
 }
 
 // SetFunction 设置函数
 func (ns *Namespace) SetFunction(name string, fn *Function) {
+	ns.mu.Lock()
+	defer ns.mu.Unlock()
 	ns.Functions[name] = fn
 }
 
 // NamespaceManager 命名空间管理器
 type NamespaceManager struct {
 	namespaces map[string]*Namespace // 命名空间表，key为完全限定名
+	mu         sync.RWMutex          // 保护命名空间表的并发读写
 }
 
 // NewNamespaceManager 创建命名空间管理器
@@ -86,6 +106,8 @@ func NewNamespaceManager() *NamespaceManager {
 
 // GetNamespace 获取命名空间（如果不存在则创建）
 func (nm *NamespaceManager) GetNamespace(fullName string) *Namespace {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
 	if ns, ok := nm.namespaces[fullName]; ok {
 		return ns
 	}
@@ -96,10 +118,25 @@ func (nm *NamespaceManager) GetNamespace(fullName string) *Namespace {
 
 // FindNamespace 查找命名空间（不存在返回nil）
 func (nm *NamespaceManager) FindNamespace(fullName string) (*Namespace, bool) {
+	nm.mu.RLock()
+	defer nm.mu.RUnlock()
 	ns, ok := nm.namespaces[fullName]
 	return ns, ok
 }
 
+// FindClassNamespaces 查找定义了指定简单类名的全部命名空间
+func (nm *NamespaceManager) FindClassNamespaces(className string) []string {
+	nm.mu.RLock()
+	defer nm.mu.RUnlock()
+	var result []string
+	for fullName, ns := range nm.namespaces {
+		if _, ok := ns.GetClass(className); ok {
+			result = append(result, fullName)
+		}
+	}
+	return result
+}
+
 // ResolveClassName 解析类名（完全限定名）
 // 输入：Illuminate.Database.Eloquent.Model
 // 返回：命名空间 "Illuminate.Database.Eloquent"，类名 "Model"
@@ -434,6 +434,7 @@ type Class struct {
 	Parent          *Class                    // 父类（用于继承）
 	Interfaces      []*Interface              // 实现的接口列表
 	Variables       map[string]*ClassVariable // 实例成员变量定义
+	FieldOrder      []string                  // 实例成员变量的声明顺序（Variables 不保留顺序）
 	StaticVariables map[string]*ClassVariable // 静态成员变量定义
 	StaticFields    map[string]Object         // 静态字段值存储
 	Constants       map[string]*ClassConstant // 常量定义
@@ -526,6 +527,24 @@ func (c *Class) GetVariable(name string) (*ClassVariable, bool) {
 	return nil, false
 }
 
+// FieldNames 获取全部实例成员变量名（包括继承的变量，父类在前，各自按声明顺序）
+func (c *Class) FieldNames() []string {
+	var names []string
+	if c.Parent != nil {
+		names = c.Parent.FieldNames()
+	}
+	for _, name := range c.FieldOrder {
+		// 子类重新声明的父类变量只保留父类中的位置
+		if c.Parent != nil {
+			if _, ok := c.Parent.GetVariable(name); ok {
+				continue
+			}
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
 // GetConstant 获取常量（包括继承的常量，但子类同名常量会覆盖父类）
 func (c *Class) GetConstant(name string) (*ClassConstant, bool) {
 	if constant, ok := c.Constants[name]; ok {
@@ -696,6 +715,19 @@ func (e *Enum) GetMethod(name string) (*ClassMethod, bool) {
 	return method, ok
 }
 
+// Implements 检查枚举是否是指定类型（枚举本身或实现的接口）
+func (e *Enum) Implements(typeName string) bool {
+	if e.Name == typeName {
+		return true
+	}
+	for _, iface := range e.Interfaces {
+		if iface.Name == typeName {
+			return true
+		}
+	}
+	return false
+}
+
 // EnumValue 枚举值对象
 // 表示一个枚举成员的实例
 type EnumValue struct {
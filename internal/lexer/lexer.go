@@ -4,6 +4,8 @@ import (
 	"strings"
 	"unicode"
 	"unicode/utf8"
+
+	"github.com/tangzhangming/longlang/internal/diagnostic"
 )
 
 // Lexer 词法分析器
@@ -17,6 +19,9 @@ type Lexer struct {
 	line         int    // 当前行号（用于错误报告）
 	column       int    // 当前列号（用于错误报告）
 	isStdlib     bool   // 是否是标准库文件（允许使用 __ 前缀的内部函数）
+	fileName     string // 源文件路径（用于诊断信息）
+
+	diagnostics diagnostic.List // 词法错误（非法字符等）
 }
 
 // New 创建新的词法分析器
@@ -28,7 +33,7 @@ func New(input string) *Lexer {
 	l := &Lexer{
 		input:    input,
 		line:     1, // 行号从1开始
-		column:   1, // 列号从1开始
+		column:   0, // 读取第一个字符后列号为1
 		isStdlib: false,
 	}
 	l.readChar() // 读取第一个字符
@@ -45,7 +50,7 @@ func NewWithOptions(input string, isStdlib bool) *Lexer {
 	l := &Lexer{
 		input:    input,
 		line:     1,
-		column:   1,
+		column:   0,
 		isStdlib: isStdlib,
 	}
 	l.readChar()
@@ -61,7 +66,9 @@ func NewWithOptions(input string, isStdlib bool) *Lexer {
 func NewFromFile(input string, filePath string) *Lexer {
 	// 判断是否为标准库文件：路径包含 stdlib/ 或 stdlib\
 	isStdlib := strings.Contains(filePath, "stdlib/") || strings.Contains(filePath, "stdlib\\")
-	return NewWithOptions(input, isStdlib)
+	l := NewWithOptions(input, isStdlib)
+	l.fileName = filePath
+	return l
 }
 
 // IsStdlib 返回当前 Lexer 是否在标准库模式
@@ -69,6 +76,26 @@ func (l *Lexer) IsStdlib() bool {
 	return l.isStdlib
 }
 
+// SetFileName 设置源文件路径（用于诊断信息）
+func (l *Lexer) SetFileName(fileName string) {
+	l.fileName = fileName
+}
+
+// FileName 返回源文件路径
+func (l *Lexer) FileName() string {
+	return l.fileName
+}
+
+// Input 返回完整的源代码（用于生成诊断信息中的源码片段）
+func (l *Lexer) Input() string {
+	return l.input
+}
+
+// Diagnostics 返回词法分析过程中产生的诊断信息
+func (l *Lexer) Diagnostics() diagnostic.List {
+	return l.diagnostics
+}
+
 // readChar 读取下一个字符并更新位置信息
 // 将 readPosition 的字符读取到 ch，并更新 position
 // 同时更新行号和列号信息
@@ -85,9 +112,9 @@ func (l *Lexer) readChar() {
 
 	// 更新行号和列号
 	if l.ch == '\n' {
-		// 遇到换行符，行号加1，列号重置为1
+		// 遇到换行符，行号加1，列号重置（下一行第一个字符为第1列）
 		l.line++
-		l.column = 1
+		l.column = 0
 	} else {
 		// 列号加1
 		l.column++
@@ -107,17 +134,33 @@ func (l *Lexer) peekChar() byte {
 
 // NextToken 读取并返回下一个 token
 // 这是词法分析器的核心方法，根据当前字符生成对应的 token
+// 遇到非法 token 时记录一条诊断信息
 // 返回:
 //   下一个 token
 func (l *Lexer) NextToken() Token {
+	tok := l.scanToken()
+	if tok.Type == ILLEGAL {
+		message := "非法字符: " + tok.Literal
+		if strings.HasPrefix(tok.Literal, "禁止使用内部函数") {
+			message = tok.Literal
+		}
+		d := diagnostic.New(l.fileName, tok.Line, tok.Column, "%s", message)
+		l.diagnostics = append(l.diagnostics, d.WithSource(l.input))
+	}
+	return tok
+}
+
+// scanToken 扫描下一个 token
+func (l *Lexer) scanToken() Token {
 	var tok Token
 
 	// 跳过空白字符（空格、制表符、换行符等）
 	l.skipWhitespace()
 
-	// 设置 token 的位置信息
+	// 设置 token 的位置信息（多字符 token 的位置为其起始字符）
 	tok.Line = l.line
 	tok.Column = l.column
+	startLine, startColumn := l.line, l.column
 
 	// 根据当前字符生成对应的 token
 	switch l.ch {
@@ -368,22 +411,22 @@ func (l *Lexer) NextToken() Token {
 		// 双引号字符串字面量
 		tok.Type = STRING
 		tok.Literal = l.readString('"')
-		tok.Line = l.line
-		tok.Column = l.column
+		tok.Line = startLine
+		tok.Column = startColumn
 		return tok
 	case '\'':
 		// 单引号字符串字面量
 		tok.Type = STRING
 		tok.Literal = l.readString('\'')
-		tok.Line = l.line
-		tok.Column = l.column
+		tok.Line = startLine
+		tok.Column = startColumn
 		return tok
 	case '`':
 		// 反引号原始字符串字面量
 		tok.Type = STRING
 		tok.Literal = l.readRawString()
-		tok.Line = l.line
-		tok.Column = l.column
+		tok.Line = startLine
+		tok.Column = startColumn
 		return tok
 	case 0:
 		// 文件结束
@@ -397,8 +440,8 @@ func (l *Lexer) NextToken() Token {
 			// 是字母或下划线，可能是标识符或关键字
 			// 支持以下划线开头的标识符（如 __construct）
 			tok.Literal = l.readIdentifier()
-			tok.Line = l.line
-			tok.Column = l.column
+			tok.Line = startLine
+			tok.Column = startColumn
 
 			// 检查是否是非法的内部函数调用
 			if strings.HasPrefix(tok.Literal, "__ILLEGAL_INTERNAL_FUNC__:") {
@@ -428,8 +471,8 @@ func (l *Lexer) NextToken() Token {
 				tok.Type = INT
 			}
 			tok.Literal = literal
-			tok.Line = l.line
-			tok.Column = l.column
+			tok.Line = startLine
+			tok.Column = startColumn
 			return tok
 		} else {
 			// 无法识别的字符
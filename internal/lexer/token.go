@@ -162,6 +162,7 @@ const (
 	CASE     TokenType = "CASE"     // case - 分支条件
 	DEFAULT  TokenType = "DEFAULT"  // default - 默认分支
 	RANGE    TokenType = "RANGE"    // range - 范围迭代
+	SELECT   TokenType = "SELECT"   // select - 多路通道选择（不是保留字，由语法分析器在语句开头识别）
 	
 	// ========== 命名空间和模块关键字 ==========
 	NAMESPACE  TokenType = "NAMESPACE"  // namespace - 命名空间
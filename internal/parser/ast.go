@@ -1,6 +1,10 @@
 package parser
 
-import "github.com/tangzhangming/longlang/internal/lexer"
+import (
+	"reflect"
+
+	"github.com/tangzhangming/longlang/internal/lexer"
+)
 
 // ========== AST 节点接口 ==========
 
@@ -12,6 +16,27 @@ type Node interface {
 	String() string      // 返回节点的字符串表示（用于调试）
 }
 
+// NodePosition 返回节点起始 token 的行号和列号
+// 所有节点都在 Token 字段中保存起始 token；无法确定位置时返回 0, 0
+func NodePosition(node Node) (int, int) {
+	v := reflect.ValueOf(node)
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return 0, 0
+	}
+	v = reflect.Indirect(v)
+	if v.Kind() != reflect.Struct {
+		return 0, 0
+	}
+	field := v.FieldByName("Token")
+	if !field.IsValid() {
+		return 0, 0
+	}
+	if tok, ok := field.Interface().(lexer.Token); ok {
+		return tok.Line, tok.Column
+	}
+	return 0, 0
+}
+
 // Statement 语句接口
 // 语句是程序执行的基本单元，不产生值（或产生值但被忽略）
 // 例如：变量声明、赋值、if 语句、函数定义等
@@ -1283,6 +1308,81 @@ func (gs *GoStatement) String() string {
 	return "go " + gs.Call.String()
 }
 
+// GoExpression go 表达式（异步启动函数并返回 Task）
+// 对应语法：t := go compute(1)
+// 出现在表达式位置时，go 的结果是可以 await 的 Task；语句位置的 go 仍是 GoStatement
+type GoExpression struct {
+	Token lexer.Token // go 关键字对应的 token
+	Call  Expression  // 要异步执行的调用
+}
+
+func (ge *GoExpression) expressionNode()      {}
+func (ge *GoExpression) TokenLiteral() string { return ge.Token.Literal }
+func (ge *GoExpression) String() string {
+	return "go " + ge.Call.String()
+}
+
+// SelectStatement select 语句（等待多个通道操作中的任意一个）
+// 对应语法：select { case v := ch.receive(): ... case ch.send(x): ... case after(ms): ... default: ... }
+type SelectStatement struct {
+	Token   lexer.Token     // select 关键字对应的 token
+	Cases   []*SelectCase   // case 分支列表
+	Default *BlockStatement // default 分支（可选，有则不阻塞）
+}
+
+func (ss *SelectStatement) statementNode()       {}
+func (ss *SelectStatement) TokenLiteral() string { return ss.Token.Literal }
+func (ss *SelectStatement) String() string {
+	var out string
+	out += "select {\n"
+	for _, c := range ss.Cases {
+		out += c.String() + "\n"
+	}
+	if ss.Default != nil {
+		out += "default:\n" + ss.Default.String() + "\n"
+	}
+	out += "}"
+	return out
+}
+
+// SelectCaseKind select 分支的种类
+type SelectCaseKind int
+
+const (
+	SelectReceive SelectCaseKind = iota // case [v :=] ch.receive()
+	SelectSend                          // case ch.send(value)
+	SelectAfter                         // case after(ms)
+)
+
+// SelectCase select 的 case 分支
+// 对应语法：case v := ch.receive(): statements
+// 或：case ch.send(value): statements
+// 或：case after(500): statements
+type SelectCase struct {
+	Token   lexer.Token     // case 关键字对应的 token
+	Kind    SelectCaseKind  // 分支种类
+	Channel Expression      // 接收或发送的通道（after 分支为 nil）
+	Value   Expression      // 发送的值，或 after 的毫秒数
+	Binding *Identifier     // 接收值绑定的变量（可选，只用于 receive）
+	Call    Expression      // case 后的原始调用表达式
+	Body    *BlockStatement // case 体
+}
+
+func (sc *SelectCase) statementNode()       {}
+func (sc *SelectCase) TokenLiteral() string { return sc.Token.Literal }
+func (sc *SelectCase) String() string {
+	var out string
+	out += "case "
+	if sc.Binding != nil {
+		out += sc.Binding.String() + " := "
+	}
+	out += sc.Call.String() + ":\n"
+	if sc.Body != nil {
+		out += sc.Body.String()
+	}
+	return out
+}
+
 // ========== Switch/Match 相关 ==========
 
 // SwitchStatement switch 语句
@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"fmt"
+
+	"github.com/tangzhangming/longlang/internal/diagnostic"
 	"github.com/tangzhangming/longlang/internal/lexer"
 )
 
@@ -80,8 +83,8 @@ var precedences = map[lexer.TokenType]int{
 
 // Parser 语法分析器
 type Parser struct {
-	l      *lexer.Lexer
-	errors []string
+	l           *lexer.Lexer
+	diagnostics diagnostic.List
 
 	prevToken lexer.Token
 	curToken  lexer.Token
@@ -102,8 +105,7 @@ type infixParseFn func(Expression) Expression
 // New 创建新的语法分析器
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
-		l:      l,
-		errors: []string{},
+		l: l,
 	}
 
 	p.allowTernary = true
@@ -130,6 +132,7 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerPrefix(lexer.LBRACKET, p.parseArrayTypeOrLiteral)
 	p.registerPrefix(lexer.MAP, p.parseMapLiteral)
 	p.registerPrefix(lexer.MATCH, p.parseMatchExpression)
+	p.registerPrefix(lexer.GO, p.parseGoExpression)      // t := go f() 返回 Task
 	p.registerPrefix(lexer.STATIC, p.parseStaticKeyword) // 支持 static::xxx 语法
 
 	// 注册中缀解析函数
@@ -196,9 +199,27 @@ func (p *Parser) ParseProgram() *Program {
 	return program
 }
 
-// Errors 返回解析错误列表
+// Errors 返回解析错误列表（文本形式，包含词法错误）
 func (p *Parser) Errors() []string {
-	return p.errors
+	diags := p.Diagnostics()
+	errors := make([]string, len(diags))
+	for i, d := range diags {
+		if d.Line > 0 {
+			errors[i] = fmt.Sprintf("%s (行 %d, 列 %d)", d.Message, d.Line, d.Column)
+		} else {
+			errors[i] = d.Message
+		}
+	}
+	return errors
+}
+
+// Diagnostics 返回结构化的诊断信息（词法错误和语法错误，按位置排序）
+func (p *Parser) Diagnostics() diagnostic.List {
+	diags := make(diagnostic.List, 0, len(p.l.Diagnostics())+len(p.diagnostics))
+	diags = append(diags, p.l.Diagnostics()...)
+	diags = append(diags, p.diagnostics...)
+	diags.Sort()
+	return diags
 }
 
 // ParseExpression 解析单个表达式（用于插值字符串等场景）
@@ -1,8 +1,6 @@
 package parser
 
 import (
-	"fmt"
-
 	"github.com/tangzhangming/longlang/internal/lexer"
 )
 
@@ -76,7 +74,7 @@ func (p *Parser) parseAnnotationArguments(ann *Annotation) {
 	for {
 		// 解析参数名
 		if !p.curTokenIs(lexer.IDENT) {
-			p.errors = append(p.errors, fmt.Sprintf("期望注解参数名，得到 %s (行 %d, 列 %d)", p.curToken.Type, p.curToken.Line, p.curToken.Column))
+			p.errorAt(p.curToken.Line, p.curToken.Column, "期望注解参数名，得到 %s", p.curToken.Type)
 			return
 		}
 		paramName := p.curToken.Literal
@@ -199,7 +197,7 @@ func (p *Parser) parseAnnotationTypeExpression() Expression {
 		// 数组类型 []type
 		return p.parseArrayType()
 	default:
-		p.errors = append(p.errors, fmt.Sprintf("无效的类型: %s (行 %d, 列 %d)", p.curToken.Literal, p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "无效的类型: %s", p.curToken.Literal)
 		return nil
 	}
 }
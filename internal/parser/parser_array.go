@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"fmt"
 	"strconv"
 
 	"github.com/tangzhangming/longlang/internal/lexer"
@@ -68,8 +67,7 @@ func (p *Parser) parseArrayType() *ArrayType {
 		// [size]type - 固定长度数组
 		size, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 		if err != nil {
-			p.errors = append(p.errors, fmt.Sprintf("无法解析数组长度 %q (行 %d, 列 %d)", 
-				p.curToken.Literal, p.curToken.Line, p.curToken.Column))
+			p.errorAt(p.curToken.Line, p.curToken.Column, "无法解析数组长度 %q", p.curToken.Literal)
 			return nil
 		}
 		arrayType.Size = &IntegerLiteral{Token: p.curToken, Value: size}
@@ -77,8 +75,7 @@ func (p *Parser) parseArrayType() *ArrayType {
 			return nil
 		}
 	} else {
-		p.errors = append(p.errors, fmt.Sprintf("数组类型格式错误，期望数字、']' 或 '...'，得到 %s (行 %d, 列 %d)", 
-			p.curToken.Type, p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "数组类型格式错误，期望数字、']' 或 '...'，得到 %s", p.curToken.Type)
 		return nil
 	}
 
@@ -107,8 +104,7 @@ func (p *Parser) parseElementType() Expression {
 		return &Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	}
 
-	p.errors = append(p.errors, fmt.Sprintf("期望元素类型，得到 %s (行 %d, 列 %d)", 
-		p.curToken.Type, p.curToken.Line, p.curToken.Column))
+	p.errorAt(p.curToken.Line, p.curToken.Column, "期望元素类型，得到 %s", p.curToken.Type)
 	return nil
 }
 
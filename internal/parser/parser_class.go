@@ -1,8 +1,6 @@
 package parser
 
 import (
-	"fmt"
-
 	"github.com/tangzhangming/longlang/internal/lexer"
 )
 
@@ -37,7 +35,7 @@ func (p *Parser) parseClassStatementWithAnnotations(isPublic bool, isInternal bo
 		stmt.IsAbstract = true
 		p.nextToken() // 跳过 abstract，现在应该是 class
 		if !p.curTokenIs(lexer.CLASS) {
-			p.errors = append(p.errors, fmt.Sprintf("abstract 后面必须是 class 关键字 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+			p.errorAt(p.curToken.Line, p.curToken.Column, "abstract 后面必须是 class 关键字")
 			return nil
 		}
 	}
@@ -230,7 +228,7 @@ func (p *Parser) parseClassMembers() []ClassMember {
 		// 解析访问修饰符
 		if !p.curTokenIs(lexer.PUBLIC) && !p.curTokenIs(lexer.PRIVATE) && !p.curTokenIs(lexer.PROTECTED) {
 			if isAbstract {
-				p.errors = append(p.errors, fmt.Sprintf("abstract 后面必须是访问修饰符 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+				p.errorAt(p.curToken.Line, p.curToken.Column, "abstract 后面必须是访问修饰符")
 			}
 			p.nextToken()
 			continue
@@ -240,7 +238,7 @@ func (p *Parser) parseClassMembers() []ClassMember {
 
 		// 抽象方法不能是 private
 		if isAbstract && accessModifier == "private" {
-			p.errors = append(p.errors, fmt.Sprintf("抽象方法不能是 private (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+			p.errorAt(p.curToken.Line, p.curToken.Column, "抽象方法不能是 private")
 			p.nextToken()
 			continue
 		}
@@ -250,7 +248,7 @@ func (p *Parser) parseClassMembers() []ClassMember {
 		// 检查是否是常量
 		if p.curTokenIs(lexer.CONST) {
 			if isAbstract {
-				p.errors = append(p.errors, fmt.Sprintf("常量不能是抽象的 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+				p.errorAt(p.curToken.Line, p.curToken.Column, "常量不能是抽象的")
 			}
 			constant := p.parseClassConstant(accessModifier)
 			if constant != nil {
@@ -307,9 +305,7 @@ func (p *Parser) parseClassMembers() []ClassMember {
 			// 检查是否是缺少 function 关键字的方法定义
 			// 模式: public/private/protected identifier(
 			if p.peekTokenIs(lexer.LPAREN) {
-				p.errors = append(p.errors, fmt.Sprintf(
-					"语法错误: 方法定义缺少 'function' 关键字，应为 '%s function %s(...)' (行 %d, 列 %d)",
-					accessModifier, p.curToken.Literal, p.curToken.Line, p.curToken.Column))
+				p.errorAt(p.curToken.Line, p.curToken.Column, "语法错误: 方法定义缺少 'function' 关键字，应为 '%s function %s(...)'", accessModifier, p.curToken.Literal)
 				// 错误恢复：跳过这个错误的方法定义
 				for !p.curTokenIs(lexer.EOF) &&
 					!p.curTokenIs(lexer.RBRACE) &&
@@ -324,7 +320,7 @@ func (p *Parser) parseClassMembers() []ClassMember {
 			}
 			
 			if isAbstract {
-				p.errors = append(p.errors, fmt.Sprintf("成员变量不能是抽象的 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+				p.errorAt(p.curToken.Line, p.curToken.Column, "成员变量不能是抽象的")
 			}
 			// 成员变量（可能是静态的）
 			variable := p.parseClassVariable(accessModifier, isStatic)
@@ -340,7 +336,7 @@ func (p *Parser) parseClassMembers() []ClassMember {
 				}
 			}
 		} else {
-			p.errors = append(p.errors, fmt.Sprintf("类成员必须是方法或变量，得到 %s (行 %d, 列 %d)", p.curToken.Type, p.curToken.Line, p.curToken.Column))
+			p.errorAt(p.curToken.Line, p.curToken.Column, "类成员必须是方法或变量，得到 %s", p.curToken.Type)
 			p.nextToken()
 		}
 	}
@@ -358,7 +354,7 @@ func (p *Parser) parseClassVariable(accessModifier string, isStatic bool) *Class
 	}
 
 	if !p.curTokenIs(lexer.IDENT) {
-		p.errors = append(p.errors, fmt.Sprintf("期望变量名，得到 %s (行 %d, 列 %d)", p.curToken.Type, p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "期望变量名，得到 %s", p.curToken.Type)
 		return nil
 	}
 
@@ -368,7 +364,7 @@ func (p *Parser) parseClassVariable(accessModifier string, isStatic bool) *Class
 
 	// 解析类型
 	if !p.curTokenIs(lexer.STRING_TYPE) && !p.curTokenIs(lexer.INT_TYPE) && !p.curTokenIs(lexer.BOOL_TYPE) && !p.curTokenIs(lexer.FLOAT_TYPE) && !p.curTokenIs(lexer.ANY) && !p.curTokenIs(lexer.BYTE_TYPE) && !p.curTokenIs(lexer.U8_TYPE) {
-		p.errors = append(p.errors, fmt.Sprintf("类成员变量必须声明类型，得到 %s (行 %d, 列 %d)", p.curToken.Type, p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "类成员变量必须声明类型，得到 %s", p.curToken.Type)
 		return nil
 	}
 
@@ -394,14 +390,14 @@ func (p *Parser) parseClassConstant(accessModifier string) *ClassConstant {
 	}
 
 	if !p.curTokenIs(lexer.CONST) {
-		p.errors = append(p.errors, fmt.Sprintf("期望 const 关键字 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "期望 const 关键字")
 		return nil
 	}
 
 	p.nextToken()
 
 	if !p.curTokenIs(lexer.IDENT) {
-		p.errors = append(p.errors, fmt.Sprintf("期望常量名 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "期望常量名")
 		return nil
 	}
 
@@ -420,7 +416,7 @@ func (p *Parser) parseClassConstant(accessModifier string) *ClassConstant {
 
 	// 必须有 = 赋值
 	if !p.curTokenIs(lexer.ASSIGN) {
-		p.errors = append(p.errors, fmt.Sprintf("常量必须有初始值，期望 = (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "常量必须有初始值，期望 =")
 		return nil
 	}
 
@@ -428,7 +424,7 @@ func (p *Parser) parseClassConstant(accessModifier string) *ClassConstant {
 
 	// 常量值必须是字面量（整数、浮点数、字符串、布尔、null）
 	if !p.curTokenIs(lexer.INT) && !p.curTokenIs(lexer.FLOAT) && !p.curTokenIs(lexer.STRING) && !p.curTokenIs(lexer.TRUE) && !p.curTokenIs(lexer.FALSE) && !p.curTokenIs(lexer.NULL) {
-		p.errors = append(p.errors, fmt.Sprintf("常量值必须是字面量（整数、浮点数、字符串、布尔、null），得到 %s (行 %d, 列 %d)", p.curToken.Type, p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "常量值必须是字面量（整数、浮点数、字符串、布尔、null），得到 %s", p.curToken.Type)
 		return nil
 	}
 
@@ -447,14 +443,14 @@ func (p *Parser) parseClassMethod(accessModifier string, isStatic bool, isAbstra
 	}
 
 	if !p.curTokenIs(lexer.FUNCTION) {
-		p.errors = append(p.errors, fmt.Sprintf("期望 function 关键字 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "期望 function 关键字")
 		return nil
 	}
 
 	p.nextToken()
 
-	if !p.curTokenIs(lexer.IDENT) {
-		p.errors = append(p.errors, fmt.Sprintf("期望方法名 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+	if !p.isMemberNameToken(p.curToken.Type) {
+		p.errorAt(p.curToken.Line, p.curToken.Column, "期望方法名")
 		return nil
 	}
 
@@ -560,7 +556,7 @@ func (p *Parser) parseStaticCallExpression(left Expression) Expression {
 		// super 被当作特殊的类名
 		className = &Identifier{Token: l.Token, Value: "super"}
 	default:
-		p.errors = append(p.errors, fmt.Sprintf("静态访问左侧必须是类名、self 或 super (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "静态访问左侧必须是类名、self 或 super")
 		return nil
 	}
 
@@ -575,8 +571,8 @@ func (p *Parser) parseStaticCallExpression(left Expression) Expression {
 		return exp
 	}
 
-	if !p.curTokenIs(lexer.IDENT) {
-		p.errors = append(p.errors, fmt.Sprintf("期望方法名或常量名 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+	if !p.isMemberNameToken(p.curToken.Type) {
+		p.errorAt(p.curToken.Line, p.curToken.Column, "期望方法名或常量名")
 		return nil
 	}
 
@@ -614,8 +610,8 @@ func (p *Parser) parseMemberAccessExpression(left Expression) Expression {
 	precedence := p.curPrecedence()
 	p.nextToken()
 
-	if !p.curTokenIs(lexer.IDENT) {
-		p.errors = append(p.errors, fmt.Sprintf("期望成员名 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+	if !p.isMemberNameToken(p.curToken.Type) {
+		p.errorAt(p.curToken.Line, p.curToken.Column, "期望成员名")
 		return nil
 	}
 
@@ -46,7 +46,7 @@ func (p *Parser) parseEnumStatementWithAnnotations(isPublic bool, isInternal boo
 		if p.curTokenIs(lexer.INT_TYPE) || p.curTokenIs(lexer.STRING_TYPE) {
 			stmt.BackingType = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
 		} else {
-			p.errors = append(p.errors, fmt.Sprintf("枚举底层类型必须是 int 或 string，得到 %s (行 %d, 列 %d)", p.curToken.Literal, p.curToken.Line, p.curToken.Column))
+			p.errorAt(p.curToken.Line, p.curToken.Column, "枚举底层类型必须是 int 或 string，得到 %s", p.curToken.Literal)
 			return nil
 		}
 	}
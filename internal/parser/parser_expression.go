@@ -1,7 +1,6 @@
 package parser
 
 import (
-	"fmt"
 	"strconv"
 
 	"github.com/tangzhangming/longlang/internal/lexer"
@@ -12,8 +11,7 @@ import (
 // parseExpression 解析表达式（Pratt 解析器核心）
 func (p *Parser) parseExpression(precedence int) Expression {
 	if p.curToken.Type == lexer.ILLEGAL {
-		msg := fmt.Sprintf("非法字符: %s (行 %d, 列 %d)", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
-		p.errors = append(p.errors, msg)
+		// 非法字符已由词法分析器报告
 		return nil
 	}
 
@@ -50,8 +48,7 @@ func (p *Parser) parseIntegerLiteral() Expression {
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("无法将 %q 解析为整数 (行 %d, 列 %d)", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken.Line, p.curToken.Column, "无法将 %q 解析为整数", p.curToken.Literal)
 		return nil
 	}
 
@@ -65,8 +62,7 @@ func (p *Parser) parseFloatLiteral() Expression {
 
 	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		msg := fmt.Sprintf("无法将 %q 解析为浮点数 (行 %d, 列 %d)", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken.Line, p.curToken.Column, "无法将 %q 解析为浮点数", p.curToken.Literal)
 		return nil
 	}
 
@@ -143,9 +139,10 @@ func (p *Parser) parseInterpolatedStringLiteral() Expression {
 			exprParser := New(exprLexer)
 			expr := exprParser.ParseExpression()
 			
-			if len(exprParser.Errors()) > 0 {
-				for _, err := range exprParser.Errors() {
-					p.errors = append(p.errors, "插值表达式错误: "+err)
+			if diags := exprParser.Diagnostics(); len(diags) > 0 {
+				// 插值表达式内的位置是相对的，统一报告在字符串字面量处
+				for _, d := range diags {
+					p.errorAt(p.curToken.Line, p.curToken.Column, "插值表达式错误: %s", d.Message)
 				}
 				continue
 			}
@@ -236,7 +233,7 @@ func (p *Parser) parseTernaryExpression(condition Expression) Expression {
 
 	// 限制：三目运算符不能作为函数/方法参数
 	if !p.allowTernary {
-		p.errors = append(p.errors, fmt.Sprintf("三目运算符不能作为函数/方法参数使用 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "三目运算符不能作为函数/方法参数使用")
 	}
 
 	// 格式检查
@@ -256,12 +253,12 @@ func (p *Parser) parseTernaryExpression(condition Expression) Expression {
 	// 单行检查
 	if questionLine == condEndLine {
 		if colonLine != questionLine {
-			p.errors = append(p.errors, fmt.Sprintf("三目运算符单行写法要求 '?' 和 ':' 在同一行 (行 %d, 列 %d)", questionLine, p.curToken.Column))
+			p.errorAt(questionLine, p.curToken.Column, "三目运算符单行写法要求 '?' 和 ':' 在同一行")
 		}
 	} else {
 		// 多行检查
 		if colonLine == trueEndLine {
-			p.errors = append(p.errors, fmt.Sprintf("三目运算符多行写法要求 ':' 单独换行，禁止 '? true : false' 这种混写 (行 %d, 列 %d)", colonLine, p.curToken.Column))
+			p.errorAt(colonLine, p.curToken.Column, "三目运算符多行写法要求 ':' 单独换行，禁止 '? true : false' 这种混写")
 		}
 	}
 
@@ -320,8 +317,7 @@ func (p *Parser) parseTypeAssertionExpression(left Expression) Expression {
 	// 解析目标类型
 	exp.TargetType = p.parseTypeExpressionForAssertion()
 	if exp.TargetType == nil {
-		msg := fmt.Sprintf("类型断言缺少目标类型 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken.Line, p.curToken.Column, "类型断言缺少目标类型")
 		return nil
 	}
 
@@ -402,8 +398,7 @@ func (p *Parser) parseArrayTypeForAssertion() Expression {
 
 	// 期望 ]
 	if !p.curTokenIs(lexer.RBRACKET) {
-		msg := fmt.Sprintf("数组类型期望 ']'，得到 %s (行 %d, 列 %d)", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken.Line, p.curToken.Column, "数组类型期望 ']'，得到 %s", p.curToken.Literal)
 		return nil
 	}
 
@@ -426,8 +421,7 @@ func (p *Parser) parseMapTypeForAssertion() Expression {
 
 	// 期望 [
 	if !p.curTokenIs(lexer.LBRACKET) {
-		msg := fmt.Sprintf("Map 类型期望 '['，得到 %s (行 %d, 列 %d)", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken.Line, p.curToken.Column, "Map 类型期望 '['，得到 %s", p.curToken.Literal)
 		return nil
 	}
 	p.nextToken()
@@ -436,16 +430,14 @@ func (p *Parser) parseMapTypeForAssertion() Expression {
 	if p.curToken.Type == lexer.IDENT || p.curToken.Type == lexer.STRING_TYPE {
 		mapType.KeyType = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
 	} else {
-		msg := fmt.Sprintf("Map 键类型无效 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken.Line, p.curToken.Column, "Map 键类型无效")
 		return nil
 	}
 	p.nextToken()
 
 	// 期望 ]
 	if !p.curTokenIs(lexer.RBRACKET) {
-		msg := fmt.Sprintf("Map 类型期望 ']'，得到 %s (行 %d, 列 %d)", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
-		p.errors = append(p.errors, msg)
+		p.errorAt(p.curToken.Line, p.curToken.Column, "Map 类型期望 ']'，得到 %s", p.curToken.Literal)
 		return nil
 	}
 	p.nextToken()
@@ -1,8 +1,6 @@
 package parser
 
 import (
-	"fmt"
-
 	"github.com/tangzhangming/longlang/internal/lexer"
 )
 
@@ -23,7 +21,7 @@ func (p *Parser) parseFunctionLiteral() Expression {
 			return nil
 		}
 	} else {
-		p.errors = append(p.errors, fmt.Sprintf("期望函数名或 '(' (行 %d, 列 %d)", p.peekToken.Line, p.peekToken.Column))
+		p.errorAt(p.peekToken.Line, p.peekToken.Column, "期望函数名或 '('")
 		return nil
 	}
 
@@ -97,7 +95,7 @@ func (p *Parser) parseFunctionParameters() []*FunctionParameter {
 	for p.peekTokenIs(lexer.COMMA) {
 		// 如果已经有可变参数，不允许再有其他参数
 		if hasVariadic {
-			p.errors = append(p.errors, fmt.Sprintf("可变参数必须是最后一个参数 (行 %d, 列 %d)", p.peekToken.Line, p.peekToken.Column))
+			p.errorAt(p.peekToken.Line, p.peekToken.Column, "可变参数必须是最后一个参数")
 			return nil
 		}
 
@@ -126,7 +124,7 @@ func (p *Parser) parseSingleParameter(hasVariadic *bool) *FunctionParameter {
 	// 检查是否是可变参数 (...)
 	if p.curTokenIs(lexer.ELLIPSIS) {
 		if *hasVariadic {
-			p.errors = append(p.errors, fmt.Sprintf("函数只能有一个可变参数 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+			p.errorAt(p.curToken.Line, p.curToken.Column, "函数只能有一个可变参数")
 			return nil
 		}
 		param.IsVariadic = true
@@ -136,7 +134,7 @@ func (p *Parser) parseSingleParameter(hasVariadic *bool) *FunctionParameter {
 
 	// 参数名
 	if !p.curTokenIs(lexer.IDENT) {
-		p.errors = append(p.errors, fmt.Sprintf("期望参数名，得到 %s (行 %d, 列 %d)", p.curToken.Type, p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "期望参数名，得到 %s", p.curToken.Type)
 		return nil
 	}
 	param.Name = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
@@ -151,7 +149,7 @@ func (p *Parser) parseSingleParameter(hasVariadic *bool) *FunctionParameter {
 	// 默认值（可变参数不允许有默认值）
 	if p.peekTokenIs(lexer.ASSIGN) {
 		if param.IsVariadic {
-			p.errors = append(p.errors, fmt.Sprintf("可变参数不能有默认值 (行 %d, 列 %d)", p.peekToken.Line, p.peekToken.Column))
+			p.errorAt(p.peekToken.Line, p.peekToken.Column, "可变参数不能有默认值")
 			return nil
 		}
 		p.nextToken()
@@ -1,8 +1,6 @@
 package parser
 
 import (
-	"fmt"
-
 	"github.com/tangzhangming/longlang/internal/lexer"
 )
 
@@ -88,8 +86,7 @@ func (p *Parser) parseMapType() *MapType {
 	// 解析键类型（支持 IDENT 和类型关键字）
 	p.nextToken()
 	if !p.curTokenIsType() {
-		p.errors = append(p.errors, fmt.Sprintf("期望键类型，得到 %s (行 %d, 列 %d)",
-			p.curToken.Type, p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "期望键类型，得到 %s", p.curToken.Type)
 		return nil
 	}
 	mt.KeyType = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
@@ -103,8 +100,7 @@ func (p *Parser) parseMapType() *MapType {
 	p.nextToken()
 	mt.ValueType = p.parseTypeExpression()
 	if mt.ValueType == nil {
-		p.errors = append(p.errors, fmt.Sprintf("期望值类型，得到 %s (行 %d, 列 %d)",
-			p.curToken.Type, p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "期望值类型，得到 %s", p.curToken.Type)
 		return nil
 	}
 
@@ -117,8 +113,7 @@ func (p *Parser) parseMapType() *MapType {
 func (p *Parser) parseMapPair() (Expression, Expression) {
 	// 解析键（只允许字符串字面量）
 	if !p.curTokenIs(lexer.STRING) {
-		p.errors = append(p.errors, fmt.Sprintf("Map 的键必须是字符串，得到 %s (行 %d, 列 %d)",
-			p.curToken.Type, p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "Map 的键必须是字符串，得到 %s", p.curToken.Type)
 		return nil, nil
 	}
 	key := p.parseStringLiteral()
@@ -1,8 +1,6 @@
 package parser
 
 import (
-	"fmt"
-
 	"github.com/tangzhangming/longlang/internal/lexer"
 )
 
@@ -61,6 +59,14 @@ func (p *Parser) parseStatement() Statement {
 		return p.parseGoStatement()
 	case lexer.SWITCH:
 		return p.parseSwitchStatement()
+	case lexer.IDENT:
+		// select 不是保留字：只有位于语句开头且后跟 { 时才是 select 语句，
+		// 其他位置（变量名、query.select(...) 等）仍是标识符
+		if p.curToken.Literal == "select" && p.peekTokenIs(lexer.LBRACE) {
+			p.curToken.Type = lexer.SELECT
+			return p.parseSelectStatement()
+		}
+		return p.parseExpressionStatement()
 	case lexer.FUNCTION:
 		return p.parseFunctionStatement()
 	case lexer.RBRACE:
@@ -68,8 +74,7 @@ func (p *Parser) parseStatement() Statement {
 	case lexer.EOF:
 		return nil
 	case lexer.ILLEGAL:
-		msg := fmt.Sprintf("非法字符: %s (行 %d, 列 %d)", p.curToken.Literal, p.curToken.Line, p.curToken.Column)
-		p.errors = append(p.errors, msg)
+		// 非法字符已由词法分析器报告
 		return nil
 	default:
 		return p.parseExpressionStatement()
@@ -97,7 +102,7 @@ func (p *Parser) parseAnnotatedDeclaration() Statement {
 	case lexer.ANNOTATION:
 		return p.parseAnnotationDefinition(annotations)
 	default:
-		p.errors = append(p.errors, fmt.Sprintf("注解后面必须是 class、interface、enum 或 annotation (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "注解后面必须是 class、interface、enum 或 annotation")
 		return nil
 	}
 }
@@ -122,7 +127,7 @@ func (p *Parser) parsePublicDeclarationWithAnnotations(annotations []*Annotation
 	case lexer.ENUM:
 		return p.parseEnumStatementWithAnnotations(true, false, annotations)
 	default:
-		p.errors = append(p.errors, fmt.Sprintf("public 后面必须是 class、abstract、interface 或 enum (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "public 后面必须是 class、abstract、interface 或 enum")
 		return nil
 	}
 }
@@ -147,7 +152,7 @@ func (p *Parser) parseInternalDeclarationWithAnnotations(annotations []*Annotati
 	case lexer.ENUM:
 		return p.parseEnumStatementWithAnnotations(false, true, annotations)
 	default:
-		p.errors = append(p.errors, fmt.Sprintf("internal 后面必须是 class、abstract、interface 或 enum (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "internal 后面必须是 class、abstract、interface 或 enum")
 		return nil
 	}
 }
@@ -338,7 +343,7 @@ func (p *Parser) parseForStatement() Statement {
 	p.nextToken()
 
 	if !p.curTokenIs(lexer.LBRACE) {
-		p.errors = append(p.errors, fmt.Sprintf("期望 '{' 但得到 %s (行 %d, 列 %d)", p.curToken.Literal, p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "期望 '{' 但得到 %s", p.curToken.Literal)
 		return nil
 	}
 	stmt.Body = p.parseBlockStatement()
@@ -357,7 +362,7 @@ func (p *Parser) parseForRangeStatement(forToken lexer.Token, firstIdent *lexer.
 	p.nextToken() // 跳过 ,，移动到第二个标识符
 	
 	if !p.curTokenIs(lexer.IDENT) {
-		p.errors = append(p.errors, fmt.Sprintf("for-range 期望变量名 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "for-range 期望变量名")
 		return nil
 	}
 	
@@ -366,13 +371,13 @@ func (p *Parser) parseForRangeStatement(forToken lexer.Token, firstIdent *lexer.
 	
 	p.nextToken() // 移动到 :=
 	if !p.curTokenIs(lexer.ASSIGN) || p.curToken.Literal != ":=" {
-		p.errors = append(p.errors, fmt.Sprintf("for-range 期望 ':=' (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "for-range 期望 ':='")
 		return nil
 	}
 	
 	p.nextToken() // 移动到 range
 	if !p.curTokenIs(lexer.RANGE) {
-		p.errors = append(p.errors, fmt.Sprintf("for-range 期望 'range' (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "for-range 期望 'range'")
 		return nil
 	}
 	
@@ -381,7 +386,7 @@ func (p *Parser) parseForRangeStatement(forToken lexer.Token, firstIdent *lexer.
 	
 	p.nextToken() // 移动到 {
 	if !p.curTokenIs(lexer.LBRACE) {
-		p.errors = append(p.errors, fmt.Sprintf("for-range 期望 '{' (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "for-range 期望 '{'")
 		return nil
 	}
 	
@@ -403,7 +408,7 @@ func (p *Parser) parseForRangeStatementSingleVar(forToken lexer.Token, firstIden
 	
 	p.nextToken() // 移动到 {
 	if !p.curTokenIs(lexer.LBRACE) {
-		p.errors = append(p.errors, fmt.Sprintf("for-range 期望 '{' (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "for-range 期望 '{'")
 		return nil
 	}
 	
@@ -426,7 +431,7 @@ func (p *Parser) parseTraditionalFor(forToken lexer.Token, initIdent *lexer.Toke
 	
 	p.nextToken() // 移动到 ;
 	if !p.curTokenIs(lexer.SEMICOLON) {
-		p.errors = append(p.errors, fmt.Sprintf("for 循环缺少第一个分号 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "for 循环缺少第一个分号")
 		return nil
 	}
 	p.nextToken()
@@ -438,7 +443,7 @@ func (p *Parser) parseTraditionalFor(forToken lexer.Token, initIdent *lexer.Toke
 	}
 
 	if !p.curTokenIs(lexer.SEMICOLON) {
-		p.errors = append(p.errors, fmt.Sprintf("for 循环缺少第二个分号 (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "for 循环缺少第二个分号")
 		return nil
 	}
 	p.nextToken()
@@ -449,7 +454,7 @@ func (p *Parser) parseTraditionalFor(forToken lexer.Token, initIdent *lexer.Toke
 	}
 
 	if !p.curTokenIs(lexer.LBRACE) {
-		p.errors = append(p.errors, fmt.Sprintf("期望 '{' 但得到 %s (行 %d, 列 %d)", p.curToken.Literal, p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "期望 '{' 但得到 %s", p.curToken.Literal)
 		return nil
 	}
 	stmt.Body = p.parseBlockStatement()
@@ -522,9 +527,7 @@ func (p *Parser) parseTryStatement() *TryStatement {
 
 	// 验证：至少要有一个 catch 或 finally
 	if len(stmt.CatchClauses) == 0 && stmt.FinallyBlock == nil {
-		msg := fmt.Sprintf("try 语句必须至少有一个 catch 或 finally 块 (行 %d, 列 %d)", 
-			stmt.Token.Line, stmt.Token.Column)
-		p.errors = append(p.errors, msg)
+		p.errorAt(stmt.Token.Line, stmt.Token.Column, "try 语句必须至少有一个 catch 或 finally 块")
 		return nil
 	}
 
@@ -606,6 +609,21 @@ func (p *Parser) parseGoStatement() *GoStatement {
 	return stmt
 }
 
+// parseGoExpression 解析 go 表达式（异步启动函数并返回 Task）
+// 语法：t := go compute(1)
+func (p *Parser) parseGoExpression() Expression {
+	exp := &GoExpression{Token: p.curToken}
+
+	p.nextToken() // 跳过 go
+
+	exp.Call = p.parseExpression(LOWEST)
+	if exp.Call == nil {
+		return nil
+	}
+
+	return exp
+}
+
 // parseBlockStatement 解析块语句
 func (p *Parser) parseBlockStatement() *BlockStatement {
 	block := &BlockStatement{Token: p.curToken}
@@ -1,8 +1,6 @@
 package parser
 
 import (
-	"fmt"
-
 	"github.com/tangzhangming/longlang/internal/lexer"
 )
 
@@ -87,7 +85,7 @@ func (p *Parser) parseSwitchStatement() *SwitchStatement {
 		} else if p.curTokenIs(lexer.DEFAULT) {
 			p.nextToken() // 跳过 'default'，现在应该在 ':'
 			if !p.curTokenIs(lexer.COLON) {
-				p.errors = append(p.errors, fmt.Sprintf("switch default 期望 ':' (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+				p.errorAt(p.curToken.Line, p.curToken.Column, "switch default 期望 ':'")
 				return nil
 			}
 			p.nextToken() // 跳过 ':'
@@ -157,6 +155,115 @@ func (p *Parser) parseCaseBody() *BlockStatement {
 	return block
 }
 
+// ========== Select 语句解析 ==========
+
+// invalidSelectCase select 分支不是通道操作时的错误信息
+const invalidSelectCase = "select 的 case 必须是 ch.receive()、ch.send(value) 或 after(ms)"
+
+// parseSelectStatement 解析 select 语句
+// 支持语法：
+//   - case v := ch.receive():  接收并绑定到新变量
+//   - case ch.receive():       接收并丢弃值
+//   - case ch.send(value):     发送
+//   - case after(ms):          超时
+//   - default:                 没有分支就绪时执行（不阻塞）
+func (p *Parser) parseSelectStatement() *SelectStatement {
+	stmt := &SelectStatement{Token: p.curToken}
+
+	if !p.expectPeek(lexer.LBRACE) {
+		return nil
+	}
+	p.nextToken() // 跳过 '{'
+
+	for !p.curTokenIs(lexer.RBRACE) && !p.curTokenIs(lexer.EOF) {
+		if p.curTokenIs(lexer.CASE) {
+			selectCase := p.parseSelectCase()
+			if selectCase == nil {
+				return nil
+			}
+			stmt.Cases = append(stmt.Cases, selectCase)
+		} else if p.curTokenIs(lexer.DEFAULT) {
+			if stmt.Default != nil {
+				p.errorAt(p.curToken.Line, p.curToken.Column, "select 只能有一个 default 分支")
+				return nil
+			}
+			p.nextToken() // 跳过 'default'，现在应该在 ':'
+			if !p.curTokenIs(lexer.COLON) {
+				p.errorAt(p.curToken.Line, p.curToken.Column, "select default 期望 ':'")
+				return nil
+			}
+			p.nextToken() // 跳过 ':'
+			stmt.Default = p.parseCaseBody()
+		} else {
+			p.errorAt(p.curToken.Line, p.curToken.Column, "select 中只能有 case 或 default 分支")
+			return nil
+		}
+	}
+
+	return stmt
+}
+
+// parseSelectCase 解析 select 的 case 分支
+func (p *Parser) parseSelectCase() *SelectCase {
+	selectCase := &SelectCase{Token: p.curToken}
+	p.nextToken() // 跳过 'case'
+
+	// 接收绑定：case v := ch.receive()
+	if p.curTokenIs(lexer.IDENT) && p.peekTokenIs(lexer.ASSIGN) && p.peekToken.Literal == ":=" {
+		selectCase.Binding = &Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		p.nextToken()
+		p.nextToken()
+	}
+
+	line, column := p.curToken.Line, p.curToken.Column
+	selectCase.Call = p.parseExpression(LOWEST)
+
+	call, ok := selectCase.Call.(*CallExpression)
+	if !ok {
+		p.errorAt(line, column, invalidSelectCase)
+		return nil
+	}
+	switch fn := call.Function.(type) {
+	case *MemberAccessExpression:
+		switch {
+		case fn.Member.Value == "receive" && len(call.Arguments) == 0:
+			selectCase.Kind = SelectReceive
+		case fn.Member.Value == "send" && len(call.Arguments) == 1:
+			selectCase.Kind = SelectSend
+			selectCase.Value = call.Arguments[0].Value
+		default:
+			p.errorAt(line, column, invalidSelectCase)
+			return nil
+		}
+		selectCase.Channel = fn.Object
+	case *Identifier:
+		if fn.Value != "after" || len(call.Arguments) != 1 {
+			p.errorAt(line, column, invalidSelectCase)
+			return nil
+		}
+		selectCase.Kind = SelectAfter
+		selectCase.Value = call.Arguments[0].Value
+	default:
+		p.errorAt(line, column, invalidSelectCase)
+		return nil
+	}
+
+	if selectCase.Binding != nil && selectCase.Kind != SelectReceive {
+		p.errorAt(selectCase.Binding.Token.Line, selectCase.Binding.Token.Column, "只有 receive 分支可以绑定变量")
+		return nil
+	}
+
+	// 期望 :
+	if !p.expectPeek(lexer.COLON) {
+		return nil
+	}
+	p.nextToken() // 跳过 ':'
+
+	selectCase.Body = p.parseCaseBody()
+
+	return selectCase
+}
+
 // ========== Match 表达式解析 ==========
 
 // parseMatchExpression 解析 match 表达式
@@ -258,7 +365,7 @@ func (p *Parser) parseMatchArm() *MatchArm {
 
 	// 期望 =>
 	if !p.expectPeek(lexer.ARROW) {
-		p.errors = append(p.errors, fmt.Sprintf("match 分支期望 '=>' (行 %d, 列 %d)", p.curToken.Line, p.curToken.Column))
+		p.errorAt(p.curToken.Line, p.curToken.Column, "match 分支期望 '=>'")
 		return nil
 	}
 	p.nextToken() // 跳过 '=>'，移动到结果表达式
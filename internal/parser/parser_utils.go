@@ -1,8 +1,7 @@
 package parser
 
 import (
-	"fmt"
-
+	"github.com/tangzhangming/longlang/internal/diagnostic"
 	"github.com/tangzhangming/longlang/internal/lexer"
 )
 
@@ -68,22 +67,31 @@ func (p *Parser) registerInfix(tokenType lexer.TokenType, fn infixParseFn) {
 
 // ========== 错误处理 ==========
 
+// errorAt 在指定位置记录一条语法错误
+func (p *Parser) errorAt(line, column int, format string, args ...interface{}) {
+	d := diagnostic.New(p.l.FileName(), line, column, format, args...)
+	p.diagnostics = append(p.diagnostics, d.WithSource(p.l.Input()))
+}
+
 // peekError 添加期望 token 错误
 func (p *Parser) peekError(t lexer.TokenType) {
-	msg := fmt.Sprintf("期望下一个 token 是 %s，但得到 %s (行 %d, 列 %d)",
-		t, p.peekToken.Type, p.peekToken.Line, p.peekToken.Column)
-	p.errors = append(p.errors, msg)
+	p.errorAt(p.peekToken.Line, p.peekToken.Column, "期望下一个 token 是 %s，但得到 %s", t, p.peekToken.Type)
 }
 
 // noPrefixParseFnError 添加没有前缀解析函数错误
 func (p *Parser) noPrefixParseFnError(t lexer.TokenType) {
-	msg := fmt.Sprintf("没有找到 %s 的前缀解析函数 (行 %d, 列 %d)",
-		t, p.curToken.Line, p.curToken.Column)
-	p.errors = append(p.errors, msg)
+	p.errorAt(p.curToken.Line, p.curToken.Column, "没有找到 %s 的前缀解析函数", t)
 }
 
 // ========== 类型检查辅助函数 ==========
 
+// isMemberNameToken 检查 token 是否可以作为方法名或成员名
+// 除标识符外，还允许 true/false/null 这类字面量关键字（如 Assert::true()）、any（如 Task::any()）
+// 和 use（如 router.use()）
+func (p *Parser) isMemberNameToken(t lexer.TokenType) bool {
+	return t == lexer.IDENT || t == lexer.TRUE || t == lexer.FALSE || t == lexer.NULL || t == lexer.ANY || t == lexer.USE
+}
+
 // isTypeToken 检查是否是类型 token
 func (p *Parser) isTypeToken(t lexer.TokenType) bool {
 	return t == lexer.STRING_TYPE ||
@@ -0,0 +1,252 @@
+package repl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/tangzhangming/longlang/internal/config"
+	"github.com/tangzhangming/longlang/internal/diagnostic"
+	"github.com/tangzhangming/longlang/internal/interpreter"
+	"github.com/tangzhangming/longlang/internal/lexer"
+	"github.com/tangzhangming/longlang/internal/parser"
+	"github.com/tangzhangming/longlang/internal/vm"
+)
+
+// 提示符
+const (
+	prompt         = ">>> " // 新输入
+	continuePrompt = "... " // 多行输入的后续行
+	inputFileName  = "<repl>"
+)
+
+// REPL 交互式解释器
+// 所有输入在同一个虚拟机中执行，全局变量、use 导入和类定义在多次输入之间保留
+type REPL struct {
+	stdlibPath    string                // 标准库路径
+	projectRoot   string                // 项目根目录
+	projectConfig *config.ProjectConfig // 项目配置
+	machine       *vm.VM                // 当前会话的虚拟机
+	out           io.Writer             // 结果输出
+	errOut        io.Writer             // 错误输出
+}
+
+// New 创建 REPL
+func New(stdlibPath, projectRoot string, projectConfig *config.ProjectConfig) *REPL {
+	r := &REPL{
+		stdlibPath:    stdlibPath,
+		projectRoot:   projectRoot,
+		projectConfig: projectConfig,
+	}
+	r.reset()
+	return r
+}
+
+// reset 丢弃当前会话的所有状态，创建新的虚拟机
+func (r *REPL) reset() {
+	r.machine = vm.NewVM()
+	r.machine.SetStdlibPath(r.stdlibPath)
+	if r.projectConfig != nil {
+		r.machine.SetProjectConfig(r.projectRoot, r.projectConfig)
+	}
+}
+
+// Run 读取并执行输入，直到输入结束或执行 :quit
+func (r *REPL) Run(in io.Reader, out, errOut io.Writer) {
+	r.out = out
+	r.errOut = errOut
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var buffer []string
+	fmt.Fprint(r.out, prompt)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// 元命令只在新输入的第一行识别
+		if len(buffer) == 0 && strings.HasPrefix(strings.TrimSpace(line), ":") {
+			if quit := r.command(strings.TrimSpace(line)); quit {
+				return
+			}
+			fmt.Fprint(r.out, prompt)
+			continue
+		}
+
+		buffer = append(buffer, line)
+		source := strings.Join(buffer, "\n")
+		if needsMore(source) {
+			fmt.Fprint(r.out, continuePrompt)
+			continue
+		}
+		buffer = nil
+
+		if strings.TrimSpace(source) != "" {
+			r.eval(source, inputFileName, true)
+		}
+		fmt.Fprint(r.out, prompt)
+	}
+	fmt.Fprintln(r.out)
+}
+
+// ========== 元命令 ==========
+
+// command 执行元命令，返回是否退出
+func (r *REPL) command(line string) bool {
+	name, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	switch name {
+	case ":quit", ":exit", ":q":
+		return true
+	case ":help", ":h":
+		r.printHelp()
+	case ":reset":
+		r.reset()
+		fmt.Fprintln(r.out, "会话已重置")
+	case ":load":
+		if arg == "" {
+			fmt.Fprintln(r.errOut, "用法: :load <文件路径>")
+			break
+		}
+		content, err := ioutil.ReadFile(arg)
+		if err != nil {
+			fmt.Fprintf(r.errOut, "读取文件错误: %s\n", err)
+			break
+		}
+		if r.eval(string(content), arg, false) {
+			fmt.Fprintf(r.out, "已加载 %s\n", arg)
+		}
+	case ":bytecode":
+		if arg == "" {
+			fmt.Fprintln(r.errOut, "用法: :bytecode <表达式或语句>")
+			break
+		}
+		r.disassemble(arg)
+	default:
+		fmt.Fprintf(r.errOut, "未知命令: %s（输入 :help 查看可用命令）\n", name)
+	}
+	return false
+}
+
+// printHelp 输出元命令说明
+func (r *REPL) printHelp() {
+	fmt.Fprintln(r.out, "命令:")
+	fmt.Fprintln(r.out, "  :load <file>      在当前会话中执行文件（不调用入口点）")
+	fmt.Fprintln(r.out, "  :bytecode <code>  显示代码编译后的字节码（不执行）")
+	fmt.Fprintln(r.out, "  :reset            清空所有变量、导入和类定义")
+	fmt.Fprintln(r.out, "  :help             显示帮助")
+	fmt.Fprintln(r.out, "  :quit             退出")
+	fmt.Fprintln(r.out)
+	fmt.Fprintln(r.out, "括号未闭合时自动进入多行输入；表达式的结果（非 null）会自动输出。")
+}
+
+// ========== 执行 ==========
+
+// parse 解析源代码，有语法错误时输出诊断信息并返回 nil
+func (r *REPL) parse(source, fileName string) *parser.Program {
+	l := lexer.New(source)
+	l.SetFileName(fileName)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if diags := p.Diagnostics(); len(diags) != 0 {
+		fmt.Fprintln(r.errOut, diags.Format())
+		return nil
+	}
+	return program
+}
+
+// eval 编译并在会话虚拟机中执行源代码，返回是否成功
+// printResult 为 true 时，最后一条表达式语句的值（非 null）会被输出
+func (r *REPL) eval(source, fileName string, printResult bool) bool {
+	program := r.parse(source, fileName)
+	if program == nil {
+		return false
+	}
+
+	// 将最后一条表达式语句改为返回语句，使其值成为执行结果
+	if printResult && len(program.Statements) > 0 {
+		last := len(program.Statements) - 1
+		if stmt, ok := program.Statements[last].(*parser.ExpressionStatement); ok {
+			program.Statements[last] = &parser.ReturnStatement{Token: stmt.Token, ReturnValue: stmt.Expression}
+		} else {
+			printResult = false
+		}
+	}
+
+	comp := vm.NewCompiler()
+	comp.SetVM(r.machine)
+	comp.SetFileName(fileName)
+	bytecode, err := comp.Compile(program)
+	if err != nil {
+		fmt.Fprintln(r.errOut, diagnostic.FormatError(err))
+		return false
+	}
+
+	result, err := r.machine.Load(bytecode)
+	if err != nil {
+		if vmErr, ok := err.(*vm.VMError); ok {
+			d := vmErr.Diagnostic()
+			if d.File == fileName {
+				// 输入不是文件，源码片段需要从输入中提取
+				d.WithSource(source)
+			}
+			fmt.Fprintln(r.errOut, d.Format())
+		} else {
+			fmt.Fprintf(r.errOut, "运行时错误: %s\n", diagnostic.FormatError(err))
+		}
+		return false
+	}
+
+	if printResult && result != nil {
+		switch value := result.(type) {
+		case *interpreter.Null:
+		case *interpreter.String:
+			// 字符串加引号输出，与数字等其他值区分
+			fmt.Fprintf(r.out, "%q\n", value.Value)
+		default:
+			fmt.Fprintln(r.out, result.Inspect())
+		}
+	}
+	return true
+}
+
+// disassemble 编译代码并输出字节码，不关联虚拟机，因此不会产生任何副作用
+func (r *REPL) disassemble(source string) {
+	program := r.parse(source, inputFileName)
+	if program == nil {
+		return
+	}
+	comp := vm.NewCompiler()
+	comp.SetFileName(inputFileName)
+	bytecode, err := comp.Compile(program)
+	if err != nil {
+		fmt.Fprintln(r.errOut, diagnostic.FormatError(err))
+		return
+	}
+	fmt.Fprint(r.out, bytecode.Disassemble(inputFileName))
+}
+
+// ========== 多行输入 ==========
+
+// needsMore 检查输入中的括号是否未闭合（需要继续读取下一行）
+// 使用词法分析器计数，字符串和注释中的括号不会被误判
+func needsMore(source string) bool {
+	depth := 0
+	l := lexer.New(source)
+	for {
+		tok := l.NextToken()
+		switch tok.Type {
+		case lexer.EOF:
+			return depth > 0
+		case lexer.LBRACE, lexer.LPAREN, lexer.LBRACKET:
+			depth++
+		case lexer.RBRACE, lexer.RPAREN, lexer.RBRACKET:
+			depth--
+		}
+	}
+}
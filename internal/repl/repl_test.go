@@ -0,0 +1,130 @@
+package repl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// stdlibPath 测试在包目录下执行，标准库位于仓库根目录
+const stdlibPath = "../../stdlib"
+
+// session 执行一段脚本化输入，返回去掉提示符后的输出行和错误输出
+func session(t *testing.T, lines ...string) ([]string, string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	input := strings.Join(lines, "\n") + "\n"
+	New(stdlibPath, "", nil).Run(strings.NewReader(input), &out, &errOut)
+
+	var results []string
+	text := strings.NewReplacer(prompt, "", continuePrompt, "").Replace(out.String())
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			results = append(results, line)
+		}
+	}
+	return results, errOut.String()
+}
+
+// expectLines 比较输出行
+func expectLines(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("输出不一致:\n--- 期望\n%s\n--- 实际\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+// TestPersistence 变量、函数、类和 use 导入在多次输入之间保留
+func TestPersistence(t *testing.T) {
+	out, errOut := session(t,
+		"x := 40",
+		"x + 2",
+		"function twice(n: int) int {",
+		"    return n * 2",
+		"}",
+		"twice(x)",
+		"class Counter {",
+		"    public count int = 0",
+		"    public function next() int {",
+		"        this.count = this.count + 1",
+		"        return this.count",
+		"    }",
+		"}",
+		"c := new Counter()",
+		"c.next()",
+		"c.next()",
+		"use System.Exception",
+		`e := new Exception("saved")`,
+		"e.getMessage()",
+		"null",
+	)
+	if errOut != "" {
+		t.Fatalf("不应有错误输出:\n%s", errOut)
+	}
+	expectLines(t, out, "42", "80", "1", "2", `"saved"`)
+}
+
+// TestErrorRecovery 语法错误、编译错误和运行时错误之后会话继续可用，已有状态不受影响
+func TestErrorRecovery(t *testing.T) {
+	out, errOut := session(t,
+		"x := 1",
+		"y := ",
+		"undefinedName + 1",
+		"x",
+		"use System.Exception",
+		`throw new Exception("boom")`,
+		"x := x + 1",
+		"x",
+	)
+	expectLines(t, out, "1", "2")
+
+	for _, message := range []string{
+		"<repl>:1:6: 错误:",
+		"未定义的变量: undefinedName",
+		"boom",
+	} {
+		if !strings.Contains(errOut, message) {
+			t.Errorf("错误输出缺少 %q:\n%s", message, errOut)
+		}
+	}
+}
+
+// TestCommands 元命令：:reset 清空会话，:quit 之后的输入不再执行，未知命令报错
+func TestCommands(t *testing.T) {
+	out, errOut := session(t,
+		"x := 1",
+		":bogus",
+		"x",
+		":reset",
+		"x",
+		":quit",
+		"2 + 2",
+	)
+	expectLines(t, out, "1", "会话已重置")
+	if !strings.Contains(errOut, "未知命令: :bogus") {
+		t.Errorf("错误输出缺少未知命令提示:\n%s", errOut)
+	}
+	if !strings.Contains(errOut, "未定义的变量: x") {
+		t.Errorf(":reset 之后 x 应未定义:\n%s", errOut)
+	}
+}
+
+func TestNeedsMore(t *testing.T) {
+	tests := []struct {
+		source string
+		more   bool
+	}{
+		{"x := 1", false},
+		{"function f() {", true},
+		{"function f() {\n}", false},
+		{"call(1,", true},
+		{"items := []int{1, 2", true},
+		{`s := "{"`, false},
+		{"// {", false},
+	}
+	for _, tt := range tests {
+		if got := needsMore(tt.source); got != tt.more {
+			t.Errorf("needsMore(%q) = %v，期望 %v", tt.source, got, tt.more)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package testrunner
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// ========== JUnit XML 报告 ==========
+
+// junitTestSuites JUnit 报告根节点
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite 对应一个测试类
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	File     string          `xml:"file,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Errors   int             `xml:"errors,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase 对应一个测试方法
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+// junitProblem 失败或错误的详情
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit 将测试报告以 JUnit XML 格式写入文件（供 CI 系统读取）
+func WriteJUnit(report *Report, path string) error {
+	root := junitTestSuites{
+		Tests:    report.Total(),
+		Failures: report.Failed,
+		Errors:   report.Errors,
+		Time:     seconds(report.Duration.Seconds()),
+	}
+
+	// 按测试类分组，保持执行顺序
+	index := make(map[*Suite]int)
+	durations := make(map[*Suite]time.Duration)
+	for _, result := range report.Results {
+		i, ok := index[result.Suite]
+		if !ok {
+			i = len(root.Suites)
+			index[result.Suite] = i
+			root.Suites = append(root.Suites, junitTestSuite{
+				Name: result.Suite.FullName(),
+				File: result.Suite.File,
+			})
+		}
+		suite := &root.Suites[i]
+
+		testCase := junitTestCase{
+			Name:      result.Method,
+			ClassName: result.Suite.FullName(),
+			Time:      seconds(result.Duration.Seconds()),
+		}
+		problem := &junitProblem{Message: result.Message, Body: strings.Join(result.Details, "\n")}
+		switch result.Status {
+		case Failed:
+			testCase.Failure = problem
+			suite.Failures++
+		case Errored:
+			testCase.Error = problem
+			suite.Errors++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, testCase)
+
+		// 测试类的耗时为其测试方法耗时之和
+		durations[result.Suite] += result.Duration
+		suite.Time = seconds(durations[result.Suite].Seconds())
+	}
+
+	data, err := xml.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return fmt.Errorf("生成 JUnit 报告失败: %v", err)
+	}
+	data = append([]byte(xml.Header), data...)
+	data = append(data, '\n')
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入 JUnit 报告失败: %v", err)
+	}
+	return nil
+}
+
+// seconds 格式化秒数
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
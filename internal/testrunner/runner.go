@@ -0,0 +1,381 @@
+package testrunner
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tangzhangming/longlang/internal/config"
+	"github.com/tangzhangming/longlang/internal/diagnostic"
+	"github.com/tangzhangming/longlang/internal/interpreter"
+	"github.com/tangzhangming/longlang/internal/lexer"
+	"github.com/tangzhangming/longlang/internal/parser"
+	"github.com/tangzhangming/longlang/internal/vm"
+)
+
+// ========== 测试发现 ==========
+
+// 特殊方法名与注解名
+const (
+	testAnnotation   = "Test"                     // 标记测试方法的注解
+	setUpMethod      = "setUp"                    // 每个测试方法之前调用
+	tearDownMethod   = "tearDown"                 // 每个测试方法之后调用（无论成功与否）
+	assertionFailure = "AssertionFailedException" // 断言失败异常类名
+	driverFileName   = "<test>"                   // 驱动代码的虚拟文件名
+)
+
+// Suite 一个测试类：包含若干 @Test 方法
+type Suite struct {
+	File        string          // 源文件路径
+	Namespace   string          // 命名空间（可为空）
+	ClassName   string          // 类名
+	Methods     []string        // 测试方法名（按定义顺序）
+	HasSetUp    bool            // 是否定义了 setUp 方法
+	HasTearDown bool            // 是否定义了 tearDown 方法
+	program     *parser.Program // 已解析的程序
+}
+
+// FullName 返回带命名空间的类名
+func (s *Suite) FullName() string {
+	if s.Namespace == "" {
+		return s.ClassName
+	}
+	return s.Namespace + "." + s.ClassName
+}
+
+// Discover 递归查找目录下的 .long 文件，收集所有带 @Test 方法的类
+// 以 . 开头的目录会被跳过；有语法错误的文件返回诊断信息
+func Discover(dir string) ([]*Suite, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != dir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(path, ".long") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var suites []*Suite
+	var diags diagnostic.List
+	for _, file := range files {
+		found, fileDiags, err := discoverFile(file)
+		if err != nil {
+			return nil, err
+		}
+		diags = append(diags, fileDiags...)
+		suites = append(suites, found...)
+	}
+	if len(diags) > 0 {
+		return nil, diags
+	}
+	return suites, nil
+}
+
+// discoverFile 解析单个文件并收集其中的测试类
+func discoverFile(file string) ([]*Suite, diagnostic.List, error) {
+	input, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取文件 %s 失败: %v", file, err)
+	}
+
+	// 不含 @Test 的文件无需解析
+	if !strings.Contains(string(input), "@"+testAnnotation) {
+		return nil, nil, nil
+	}
+
+	l := lexer.New(string(input))
+	l.SetFileName(file)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if diags := p.Diagnostics(); len(diags) != 0 {
+		return nil, diags, nil
+	}
+
+	namespace := ""
+	var suites []*Suite
+	for _, stmt := range program.Statements {
+		switch s := stmt.(type) {
+		case *parser.NamespaceStatement:
+			namespace = s.Name.Value
+		case *parser.ClassStatement:
+			if s.IsAbstract {
+				continue
+			}
+			suite := &Suite{File: file, Namespace: namespace, ClassName: s.Name.Value, program: program}
+			for _, member := range s.Members {
+				method, ok := member.(*parser.ClassMethod)
+				if !ok || method.IsStatic || method.IsAbstract {
+					continue
+				}
+				switch method.Name.Value {
+				case setUpMethod:
+					suite.HasSetUp = true
+				case tearDownMethod:
+					suite.HasTearDown = true
+				}
+				if hasAnnotation(method.Annotations, testAnnotation) {
+					suite.Methods = append(suite.Methods, method.Name.Value)
+				}
+			}
+			if len(suite.Methods) > 0 {
+				suites = append(suites, suite)
+			}
+		}
+	}
+	return suites, nil, nil
+}
+
+// hasAnnotation 检查注解列表中是否包含指定名称的注解
+func hasAnnotation(annotations []*parser.Annotation, name string) bool {
+	for _, annotation := range annotations {
+		if annotation.Name != nil && annotation.Name.Value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ========== 测试结果 ==========
+
+// Status 测试结果状态
+type Status int
+
+const (
+	Passed  Status = iota // 通过
+	Failed                // 断言失败
+	Errored               // 其他异常或运行时错误
+)
+
+// String 返回状态的显示名称
+func (s Status) String() string {
+	switch s {
+	case Failed:
+		return "失败"
+	case Errored:
+		return "错误"
+	default:
+		return "通过"
+	}
+}
+
+// Result 单个测试方法的执行结果
+type Result struct {
+	Suite    *Suite
+	Method   string
+	Status   Status
+	Message  string   // 失败或错误消息
+	Details  []string // 堆栈跟踪等附加信息
+	Duration time.Duration
+}
+
+// Name 返回测试的显示名称：Class::method
+func (r *Result) Name() string {
+	return r.Suite.FullName() + "::" + r.Method
+}
+
+// Report 一次测试运行的汇总
+type Report struct {
+	Results  []*Result
+	Passed   int
+	Failed   int
+	Errors   int
+	Duration time.Duration
+}
+
+// Total 返回执行的测试总数
+func (r *Report) Total() int {
+	return len(r.Results)
+}
+
+// OK 所有测试都通过时返回 true
+func (r *Report) OK() bool {
+	return r.Failed == 0 && r.Errors == 0
+}
+
+// ========== 测试运行 ==========
+
+// Runner 测试运行器
+// 每个测试方法都在全新的虚拟机中执行，测试之间互不影响
+type Runner struct {
+	StdlibPath    string                // 标准库路径
+	ProjectRoot   string                // 项目根目录
+	ProjectConfig *config.ProjectConfig // 项目配置
+	Out           io.Writer             // 进度输出（为 nil 时不输出）
+}
+
+// Run 依次执行所有测试类中的测试方法
+func (r *Runner) Run(suites []*Suite) *Report {
+	report := &Report{}
+	start := time.Now()
+	for _, suite := range suites {
+		r.printf("%s (%s)\n", suite.FullName(), suite.File)
+		for _, method := range suite.Methods {
+			result := r.runTest(suite, method)
+			report.Results = append(report.Results, result)
+			switch result.Status {
+			case Passed:
+				report.Passed++
+				r.printf("  通过  %s (%s)\n", method, formatDuration(result.Duration))
+			case Failed:
+				report.Failed++
+				r.printf("  失败  %s: %s\n", method, result.Message)
+			default:
+				report.Errors++
+				r.printf("  错误  %s: %s\n", method, result.Message)
+			}
+			if result.Status != Passed {
+				for _, detail := range result.Details {
+					r.printf("    %s\n", detail)
+				}
+			}
+		}
+	}
+	report.Duration = time.Since(start)
+	r.printf("\n共 %d 个测试：通过 %d，失败 %d，错误 %d（耗时 %s）\n",
+		report.Total(), report.Passed, report.Failed, report.Errors, formatDuration(report.Duration))
+	return report
+}
+
+// runTest 在全新的虚拟机中执行单个测试方法
+func (r *Runner) runTest(suite *Suite, method string) (result *Result) {
+	result = &Result{Suite: suite, Method: method}
+	start := time.Now()
+	defer func() {
+		// 虚拟机内部错误不应中断整个测试运行
+		if rec := recover(); rec != nil {
+			result.Status = Errored
+			result.Message = fmt.Sprintf("内部错误: %v", rec)
+		}
+		result.Duration = time.Since(start)
+	}()
+
+	machine := vm.NewVM()
+	machine.SetStdlibPath(r.StdlibPath)
+	if r.ProjectConfig != nil {
+		machine.SetProjectConfig(r.ProjectRoot, r.ProjectConfig)
+	}
+
+	// 加载测试文件（执行顶层代码，注册类，但不调用入口点）
+	if err := r.load(machine, suite.program, suite.File); err != nil {
+		r.classify(result, err)
+		return result
+	}
+
+	// 生成并执行驱动代码
+	l := lexer.New(driverSource(suite, method))
+	l.SetFileName(driverFileName)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if diags := p.Diagnostics(); len(diags) != 0 {
+		r.classify(result, diags)
+		return result
+	}
+	if err := r.load(machine, program, driverFileName); err != nil {
+		r.classify(result, err)
+		return result
+	}
+
+	result.Status = Passed
+	return result
+}
+
+// load 编译程序并在虚拟机中执行
+func (r *Runner) load(machine *vm.VM, program *parser.Program, fileName string) error {
+	comp := vm.NewCompiler()
+	comp.SetVM(machine)
+	comp.SetFileName(fileName)
+	bytecode, err := comp.Compile(program)
+	if err != nil {
+		return err
+	}
+	_, err = machine.Load(bytecode)
+	return err
+}
+
+// driverSource 生成调用单个测试方法的驱动代码
+// tearDown 放在 finally 中，测试失败时同样会执行
+func driverSource(suite *Suite, method string) string {
+	var sb strings.Builder
+	if suite.Namespace != "" {
+		sb.WriteString("namespace " + suite.Namespace + "\n\n")
+	}
+	sb.WriteString("testCase := new " + suite.ClassName + "()\n")
+	sb.WriteString("try {\n")
+	if suite.HasSetUp {
+		sb.WriteString("    testCase." + setUpMethod + "()\n")
+	}
+	sb.WriteString("    testCase." + method + "()\n")
+	sb.WriteString("} finally {\n")
+	if suite.HasTearDown {
+		sb.WriteString("    testCase." + tearDownMethod + "()\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// classify 根据错误类型判定测试结果：断言失败计为失败，其余计为错误
+func (r *Runner) classify(result *Result, err error) {
+	result.Status = Errored
+	vmErr, ok := err.(*vm.VMError)
+	if !ok {
+		lines := strings.Split(diagnostic.FormatError(err), "\n")
+		result.Message = lines[0]
+		result.Details = lines[1:]
+		return
+	}
+
+	lines := strings.Split(vmErr.StackTrace(), "\n")
+	result.Message = lines[0]
+	for _, line := range lines[1:] {
+		// 驱动代码的调用帧对用户没有意义
+		if strings.Contains(line, "("+driverFileName+":") {
+			continue
+		}
+		result.Details = append(result.Details, strings.TrimSpace(line))
+	}
+	if instance, ok := vmErr.Exception.(*interpreter.Instance); ok {
+		if isInstanceOf(instance, assertionFailure) {
+			result.Status = Failed
+			result.Message = vmErr.Error()
+		}
+	}
+}
+
+// isInstanceOf 检查实例是否属于指定类（含父类）
+func isInstanceOf(instance *interpreter.Instance, className string) bool {
+	for class := instance.Class; class != nil; class = class.Parent {
+		if class.Name == className {
+			return true
+		}
+	}
+	return false
+}
+
+// printf 输出进度信息
+func (r *Runner) printf(format string, args ...interface{}) {
+	if r.Out != nil {
+		fmt.Fprintf(r.Out, format, args...)
+	}
+}
+
+// formatDuration 以毫秒为单位格式化耗时
+func formatDuration(d time.Duration) string {
+	return fmt.Sprintf("%.2fms", float64(d.Microseconds())/1000)
+}
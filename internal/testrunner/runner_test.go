@@ -0,0 +1,295 @@
+package testrunner
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tangzhangming/longlang/internal/diagnostic"
+)
+
+// stdlibPath 测试在包目录下执行，标准库位于仓库根目录
+const stdlibPath = "../../stdlib"
+
+// writeFiles 在临时目录中写入测试源文件（键为相对路径）
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// ========== 测试发现 ==========
+
+const calculatorTest = `namespace App.Tests
+
+use System.Test.Assert
+
+class CalculatorTest {
+    public function setUp() {
+    }
+
+    public function tearDown() {
+    }
+
+    @Test
+    public function testAdd() {
+        Assert::equals(3, 1 + 2)
+    }
+
+    // 没有 @Test 注解：不是测试方法
+    public function helper() {
+    }
+
+    // 静态方法即使有注解也会被忽略
+    @Test
+    public static function testStatic() {
+    }
+
+    @Test
+    public function testSub() {
+        Assert::equals(1, 2 - 1)
+    }
+}
+
+abstract class BaseTest {
+    @Test
+    public function testInherited() {
+    }
+}
+`
+
+func TestDiscover(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"calculator_test.long": calculatorTest,
+		"plain.long":           "class Plain {\n    public function run() {\n    }\n}\n",
+		"nested/math_test.long": `class MathTest {
+    @Test
+    public function testMax() {
+    }
+}
+`,
+		".hidden/skipped_test.long": `class SkippedTest {
+    @Test
+    public function testNever() {
+    }
+}
+`,
+	})
+
+	suites, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover 返回错误: %v", err)
+	}
+
+	type expectedSuite struct {
+		name        string
+		file        string
+		methods     []string
+		hasSetUp    bool
+		hasTearDown bool
+	}
+	expected := []expectedSuite{
+		{"App.Tests.CalculatorTest", "calculator_test.long", []string{"testAdd", "testSub"}, true, true},
+		{"MathTest", "nested/math_test.long", []string{"testMax"}, false, false},
+	}
+	if len(suites) != len(expected) {
+		names := make([]string, len(suites))
+		for i, suite := range suites {
+			names[i] = suite.FullName()
+		}
+		t.Fatalf("期望 %d 个测试类，实际 %d 个: %v", len(expected), len(suites), names)
+	}
+	for i, want := range expected {
+		got := suites[i]
+		if got.FullName() != want.name {
+			t.Errorf("测试类 %d: 期望 %s，实际 %s", i, want.name, got.FullName())
+		}
+		if got.File != filepath.Join(dir, filepath.FromSlash(want.file)) {
+			t.Errorf("%s: 文件为 %s", want.name, got.File)
+		}
+		if strings.Join(got.Methods, ",") != strings.Join(want.methods, ",") {
+			t.Errorf("%s: 期望测试方法 %v，实际 %v", want.name, want.methods, got.Methods)
+		}
+		if got.HasSetUp != want.hasSetUp || got.HasTearDown != want.hasTearDown {
+			t.Errorf("%s: setUp/tearDown 为 %v/%v", want.name, got.HasSetUp, got.HasTearDown)
+		}
+	}
+}
+
+func TestDiscoverSyntaxError(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"broken_test.long": "class BrokenTest {\n    @Test\n    public function testBroken( {\n    }\n}\n",
+	})
+
+	suites, err := Discover(dir)
+	if err == nil {
+		t.Fatalf("期望语法错误，实际发现 %d 个测试类", len(suites))
+	}
+	diags, ok := err.(diagnostic.List)
+	if !ok || len(diags) == 0 {
+		t.Fatalf("期望诊断信息列表，实际 %T: %v", err, err)
+	}
+	if !strings.Contains(diagnostic.FormatError(err), "broken_test.long") {
+		t.Errorf("诊断信息缺少文件名: %s", diagnostic.FormatError(err))
+	}
+}
+
+func TestDiscoverMissingDirectory(t *testing.T) {
+	if _, err := Discover(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Fatal("不存在的目录应返回错误")
+	}
+}
+
+// ========== 测试运行 ==========
+
+const mixedTest = `namespace App.Tests
+
+use System.Test.Assert
+use System.Exception
+
+class MixedTest {
+    private count int
+
+    public function setUp() {
+        this.count = 1
+    }
+
+    @Test
+    public function testPass() {
+        Assert::equals(1, this.count)
+        this.count = 2
+    }
+
+    // 每个测试使用全新的实例，上一个测试的修改不可见
+    @Test
+    public function testIsolated() {
+        Assert::equals(1, this.count)
+    }
+
+    @Test
+    public function testFail() {
+        Assert::equals(2, 3, "数值不同")
+    }
+
+    @Test
+    public function testError() {
+        throw new Exception("boom")
+    }
+}
+`
+
+func TestRunReport(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"mixed_test.long": mixedTest})
+	suites, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover 返回错误: %v", err)
+	}
+
+	var out bytes.Buffer
+	runner := &Runner{StdlibPath: stdlibPath, Out: &out}
+	report := runner.Run(suites)
+
+	expected := []struct {
+		method  string
+		status  Status
+		message string
+	}{
+		{"testPass", Passed, ""},
+		{"testIsolated", Passed, ""},
+		{"testFail", Failed, "数值不同"},
+		{"testError", Errored, "boom"},
+	}
+	if report.Total() != len(expected) {
+		t.Fatalf("期望 %d 个结果，实际 %d 个\n%s", len(expected), report.Total(), out.String())
+	}
+	for i, want := range expected {
+		got := report.Results[i]
+		if got.Method != want.method || got.Status != want.status {
+			t.Errorf("结果 %d: 期望 %s %s，实际 %s %s: %s", i, want.method, want.status, got.Method, got.Status, got.Message)
+		}
+		if !strings.Contains(got.Message, want.message) {
+			t.Errorf("%s: 消息 %q 不包含 %q", want.method, got.Message, want.message)
+		}
+		if got.Name() != "App.Tests.MixedTest::"+want.method {
+			t.Errorf("结果 %d: 名称为 %s", i, got.Name())
+		}
+	}
+	if report.Passed != 2 || report.Failed != 1 || report.Errors != 1 {
+		t.Errorf("统计为 通过 %d，失败 %d，错误 %d", report.Passed, report.Failed, report.Errors)
+	}
+	if report.OK() {
+		t.Error("有失败和错误时 OK() 应返回 false")
+	}
+
+	output := out.String()
+	for _, line := range []string{
+		"App.Tests.MixedTest (",
+		"  通过  testPass (",
+		"  失败  testFail: ",
+		"  错误  testError: ",
+		"共 4 个测试：通过 2，失败 1，错误 1",
+	} {
+		if !strings.Contains(output, line) {
+			t.Errorf("输出缺少 %q:\n%s", line, output)
+		}
+	}
+}
+
+func TestRunTearDownAfterFailure(t *testing.T) {
+	// tearDown 抛出的异常覆盖测试本身的失败，说明 tearDown 在失败后仍被调用
+	dir := writeFiles(t, map[string]string{"teardown_test.long": `use System.Test.Assert
+use System.Exception
+
+class TearDownTest {
+    public function tearDown() {
+        throw new Exception("tearDown 已执行")
+    }
+
+    @Test
+    public function testFail() {
+        Assert::fail("测试失败")
+    }
+}
+`})
+	suites, err := Discover(dir)
+	if err != nil {
+		t.Fatalf("Discover 返回错误: %v", err)
+	}
+
+	report := (&Runner{StdlibPath: stdlibPath}).Run(suites)
+	if report.Total() != 1 {
+		t.Fatalf("期望 1 个结果，实际 %d 个", report.Total())
+	}
+	if result := report.Results[0]; !strings.Contains(result.Message, "tearDown 已执行") {
+		t.Errorf("tearDown 没有执行: %s %s", result.Status, result.Message)
+	}
+}
+
+func TestReportOK(t *testing.T) {
+	tests := []struct {
+		name   string
+		report Report
+		ok     bool
+	}{
+		{"空运行", Report{}, true},
+		{"全部通过", Report{Passed: 3}, true},
+		{"有失败", Report{Passed: 2, Failed: 1}, false},
+		{"有错误", Report{Passed: 2, Errors: 1}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.report.OK(); got != tt.ok {
+			t.Errorf("%s: OK() = %v，期望 %v", tt.name, got, tt.ok)
+		}
+	}
+}
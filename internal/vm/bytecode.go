@@ -82,6 +82,7 @@ const (
 	OP_INVOKE_STATIC // 调用静态方法
 	OP_NEW          // 创建实例
 	OP_INHERIT      // 继承
+	OP_IMPLEMENT    // 实现接口（弹出接口，记录到栈顶的类或枚举）
 	OP_GET_SUPER    // 获取父类方法
 	OP_SUPER_INVOKE // 调用父类方法
 
@@ -93,15 +94,17 @@ const (
 	OP_SLICE     // 切片操作
 	OP_INSTANCE_OF // 检查实例是否属于类
 	OP_TYPE_ASSERT // 类型断言（操作数：目标类型名索引 + 是否安全断言标志）
+	OP_MATCH_FAIL  // match 未匹配到任何分支（弹出匹配值并抛出异常）
 
 	// 异常处理指令
 	OP_THROW        // 抛出异常
 	OP_PUSH_TRY     // 开始 try 块（操作数：catch 块偏移量）
 	OP_POP_TRY      // 结束 try 块
-	OP_SETUP_FINALLY // 设置 finally 块
+	OP_SETUP_FINALLY // 设置 finally 处理器（操作数：finally 异常路径偏移量）
 
 	// 协程指令
-	OP_GO // 启动协程
+	OP_GO // 启动协程（操作数：参数个数 + 是否返回 Task）
+	OP_SELECT // select 语句（操作数：分支个数 + 是否有 default）
 
 	// 其他指令
 	OP_POP  // 弹出栈顶值（丢弃）
@@ -124,6 +127,11 @@ const (
 	OP_CLOSURE_WIDE       // 创建闭包（16位函数索引）
 	OP_METHOD_WIDE        // 定义方法（16位索引）
 	OP_STATIC_METHOD_WIDE // 定义静态方法（16位索引）
+
+	// 反射元数据指令
+	OP_ANNOTATION        // 创建注解实例（操作数：注解名索引 + 参数个数），参数以键值对形式在栈上
+	OP_MEMBER_INFO       // 设置类变量的类型、访问修饰符和注解（操作数：变量名索引 + 注解个数）
+	OP_CLASS_ANNOTATIONS // 设置类的注解（操作数：注解个数）
 )
 
 // opcodeNames 操作码名称映射
@@ -180,6 +188,7 @@ var opcodeNames = map[Opcode]string{
 	OP_INVOKE_STATIC:    "OP_INVOKE_STATIC",
 	OP_NEW:            "OP_NEW",
 	OP_INHERIT:        "OP_INHERIT",
+	OP_IMPLEMENT:      "OP_IMPLEMENT",
 	OP_GET_SUPER:      "OP_GET_SUPER",
 	OP_SUPER_INVOKE:   "OP_SUPER_INVOKE",
 	OP_ARRAY:          "OP_ARRAY",
@@ -189,11 +198,13 @@ var opcodeNames = map[Opcode]string{
 	OP_SLICE:          "OP_SLICE",
 	OP_INSTANCE_OF:    "OP_INSTANCE_OF",
 	OP_TYPE_ASSERT:    "OP_TYPE_ASSERT",
+	OP_MATCH_FAIL:     "OP_MATCH_FAIL",
 	OP_THROW:          "OP_THROW",
 	OP_PUSH_TRY:       "OP_PUSH_TRY",
 	OP_POP_TRY:        "OP_POP_TRY",
 	OP_SETUP_FINALLY:  "OP_SETUP_FINALLY",
 	OP_GO:             "OP_GO",
+	OP_SELECT:         "OP_SELECT",
 	OP_POP:            "OP_POP",
 	OP_DUP:            "OP_DUP",
 	OP_SWAP:           "OP_SWAP",
@@ -208,6 +219,9 @@ var opcodeNames = map[Opcode]string{
 	OP_CLOSURE_WIDE:      "OP_CLOSURE_WIDE",
 	OP_METHOD_WIDE:       "OP_METHOD_WIDE",
 	OP_STATIC_METHOD_WIDE: "OP_STATIC_METHOD_WIDE",
+	OP_ANNOTATION:        "OP_ANNOTATION",
+	OP_MEMBER_INFO:       "OP_MEMBER_INFO",
+	OP_CLASS_ANNOTATIONS: "OP_CLASS_ANNOTATIONS",
 }
 
 // String 返回操作码的字符串表示
@@ -226,6 +240,30 @@ type Bytecode struct {
 	Constants    []interpreter.Object // 常量池
 	Instructions []byte               // 指令序列
 	Lines        []int                // 行号信息（用于错误报告）
+	FileName     string               // 源文件名（用于堆栈跟踪）
+	Directives   []Directive          // 编译期指令（只有文件顶层字节码才有）
+}
+
+// DirectiveKind 编译期指令类型
+type DirectiveKind byte
+
+const (
+	DirectiveNamespace DirectiveKind = iota // namespace 声明：切换当前命名空间
+	DirectiveUse                            // use 语句：加载并导入类
+	DirectiveEnum                           // 枚举声明：注册到当前命名空间
+	DirectiveInterface                      // 接口声明：注册到当前命名空间
+)
+
+// Directive 编译期指令
+// 编译器在编译 namespace、use、enum 和 interface 时会直接修改关联的虚拟机，
+// 这些副作用按出现顺序记录下来，从 .longc 文件或字节码缓存加载时由 ApplyDirectives 重放
+type Directive struct {
+	Kind   DirectiveKind      // 指令类型
+	Name   string             // 命名空间名（未经 root_namespace 解析）、use 路径或枚举/接口名
+	Alias  string             // use 别名
+	Line   int                // 源码行号（用于错误报告）
+	Column int                // 源码列号
+	Object interpreter.Object // 枚举或接口对象
 }
 
 // NewBytecode 创建新的字节码结构
@@ -363,14 +401,20 @@ func (b *Bytecode) disassembleInstruction(sb *strings.Builder, offset int) int {
 		return b.constantInstruction(sb, op.String(), offset)
 	case OP_GET_STATIC_FIELD, OP_SET_STATIC_FIELD:
 		return b.constantInstruction(sb, op.String(), offset)
-	case OP_INVOKE, OP_INVOKE_STATIC, OP_SUPER_INVOKE:
+	case OP_INVOKE, OP_INVOKE_STATIC, OP_SUPER_INVOKE, OP_ANNOTATION, OP_MEMBER_INFO:
 		return b.invokeInstruction(sb, op.String(), offset)
+	case OP_CLASS_ANNOTATIONS:
+		return b.byteInstruction(sb, op.String(), offset)
 	case OP_ARRAY, OP_MAP, OP_NEW:
 		return b.byteInstruction(sb, op.String(), offset)
-	case OP_PUSH_TRY:
+	case OP_PUSH_TRY, OP_SETUP_FINALLY:
 		return b.jumpInstruction(sb, op.String(), 1, offset)
 	case OP_INCREMENT, OP_DECREMENT:
 		return b.byteInstruction(sb, op.String(), offset)
+	case OP_GO:
+		return b.goInstruction(sb, offset)
+	case OP_SELECT:
+		return b.selectInstruction(sb, offset)
 	default:
 		sb.WriteString(fmt.Sprintf("%s\n", op.String()))
 		return offset + 1
@@ -429,6 +473,22 @@ func (b *Bytecode) closureInstruction(sb *strings.Builder, offset int) int {
 	return offset
 }
 
+// goInstruction 反汇编 go 指令
+func (b *Bytecode) goInstruction(sb *strings.Builder, offset int) int {
+	argCount := b.Instructions[offset+1]
+	returnsTask := b.Instructions[offset+2]
+	sb.WriteString(fmt.Sprintf("%-16s %4d (task=%t)\n", "OP_GO", argCount, returnsTask == 1))
+	return offset + 3
+}
+
+// selectInstruction 反汇编 select 指令
+func (b *Bytecode) selectInstruction(sb *strings.Builder, offset int) int {
+	caseCount := b.Instructions[offset+1]
+	hasDefault := b.Instructions[offset+2]
+	sb.WriteString(fmt.Sprintf("%-16s (%d cases, default=%t)\n", "OP_SELECT", caseCount, hasDefault == 1))
+	return offset + 3
+}
+
 // invokeInstruction 反汇编调用指令
 func (b *Bytecode) invokeInstruction(sb *strings.Builder, name string, offset int) int {
 	constant := b.Instructions[offset+1]
@@ -0,0 +1,152 @@
+package vm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tangzhangming/longlang/internal/diagnostic"
+	"github.com/tangzhangming/longlang/internal/interpreter"
+	"github.com/tangzhangming/longlang/internal/lexer"
+	"github.com/tangzhangming/longlang/internal/parser"
+)
+
+// ========== 字节码缓存 ==========
+
+// BytecodeCache 磁盘字节码缓存
+// 以（格式版本、编译器版本、文件路径、源码）的哈希为键保存编译结果，
+// 源码或编译器变化后键随之变化，旧条目自然失效
+type BytecodeCache struct {
+	dir             string // 缓存目录
+	compilerVersion string // 编译器版本（写入文件头并参与键的计算）
+}
+
+// NewBytecodeCache 创建字节码缓存
+func NewBytecodeCache(dir string, compilerVersion string) *BytecodeCache {
+	return &BytecodeCache{dir: dir, compilerVersion: compilerVersion}
+}
+
+// DefaultBytecodeCacheDir 返回默认的缓存目录
+// 优先使用环境变量 LONGLANG_CACHE_DIR，值为 off 时禁用缓存（返回空字符串）
+// 否则使用用户缓存目录下的 longlang/bytecode
+func DefaultBytecodeCacheDir() string {
+	if dir := os.Getenv("LONGLANG_CACHE_DIR"); dir != "" {
+		if dir == "off" {
+			return ""
+		}
+		return dir
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(base, "longlang", "bytecode")
+}
+
+// path 返回源文件对应的缓存文件路径
+func (c *BytecodeCache) path(fileName string, source string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\x00%d\x00%s\x00%s\x00", BytecodeFormatVersion, len(opcodeNames), c.compilerVersion, fileName)
+	h.Write([]byte(source))
+	sum := hex.EncodeToString(h.Sum(nil))
+	return filepath.Join(c.dir, sum[:2], sum[2:]+".longc")
+}
+
+// Load 读取缓存的字节码，未命中或缓存文件无效时返回 nil
+func (c *BytecodeCache) Load(fileName string, source string) *Bytecode {
+	bytecode, err := ReadBytecodeFile(c.path(fileName, source), c.compilerVersion)
+	if err != nil {
+		return nil
+	}
+	return bytecode
+}
+
+// Store 将编译结果写入缓存
+func (c *BytecodeCache) Store(fileName string, source string, bytecode *Bytecode) error {
+	return WriteBytecodeFile(c.path(fileName, source), bytecode, c.compilerVersion)
+}
+
+// ========== 编译与加载 ==========
+
+// SetBytecodeCache 设置字节码缓存（nil 表示不使用缓存）
+func (vm *VM) SetBytecodeCache(cache *BytecodeCache) {
+	vm.cache = cache
+}
+
+// CompileFile 编译源文件为字节码
+// 启用缓存时优先使用缓存的编译结果（并重放编译期指令），未命中时解析、编译并写入缓存
+// 缓存写入失败不影响执行
+func (vm *VM) CompileFile(fileName string, source string) (*Bytecode, error) {
+	if vm.cache != nil {
+		if bytecode := vm.cache.Load(fileName, source); bytecode != nil {
+			if err := vm.ApplyDirectives(bytecode); err != nil {
+				return nil, err
+			}
+			return bytecode, nil
+		}
+	}
+
+	l := lexer.NewFromFile(source, fileName)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if diags := p.Diagnostics(); len(diags) > 0 {
+		return nil, diags
+	}
+
+	compiler := NewCompiler()
+	compiler.SetVM(vm)
+	compiler.SetFileName(fileName)
+	bytecode, err := compiler.Compile(program)
+	if err != nil {
+		return nil, err
+	}
+
+	if vm.cache != nil {
+		vm.cache.Store(fileName, source, bytecode)
+	}
+	return bytecode, nil
+}
+
+// ApplyDirectives 按顺序重放字节码的编译期指令
+// 从 .longc 文件或缓存加载的字节码没有经过编译器，命名空间切换、use 导入以及
+// 枚举和接口的注册需要在执行前补上，效果与编译时一致
+func (vm *VM) ApplyDirectives(bytecode *Bytecode) error {
+	for _, d := range bytecode.Directives {
+		switch d.Kind {
+		case DirectiveNamespace:
+			namespaceName := d.Name
+			if vm.projectConfig != nil {
+				namespaceName = vm.projectConfig.ResolveNamespace(namespaceName)
+			}
+			vm.SetCurrentNamespace(namespaceName)
+
+		case DirectiveUse:
+			if err := vm.ProcessUseStatement(d.Name, d.Alias); err != nil {
+				switch err.(type) {
+				case *diagnostic.Diagnostic, diagnostic.List:
+					return err
+				}
+				if d.Line == 0 {
+					return err
+				}
+				return diagnostic.New(bytecode.FileName, d.Line, d.Column, "%s", err.Error())
+			}
+
+		case DirectiveEnum:
+			if enum, ok := d.Object.(*interpreter.Enum); ok && vm.currentNamespace != nil {
+				vm.currentNamespace.SetEnum(d.Name, enum)
+			}
+
+		case DirectiveInterface:
+			if iface, ok := d.Object.(*interpreter.Interface); ok && vm.currentNamespace != nil {
+				vm.currentNamespace.SetInterface(d.Name, iface)
+			}
+
+		default:
+			return fmt.Errorf("未知的编译期指令类型 %d", d.Kind)
+		}
+	}
+	return nil
+}
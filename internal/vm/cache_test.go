@@ -0,0 +1,114 @@
+package vm
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const cacheSource = "function double(n: int) int {\n    return n * 2\n}\nreturn double(21)\n"
+
+// cacheFiles 返回缓存目录中的 .longc 文件
+func cacheFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*", "*.longc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// TestCacheHit 相同的文件名、源码和编译器版本命中缓存，加载结果可以执行
+func TestCacheHit(t *testing.T) {
+	dir := t.TempDir()
+	machine := newTestVM()
+	machine.SetBytecodeCache(NewBytecodeCache(dir, "test"))
+	if _, err := machine.CompileFile("double.long", cacheSource); err != nil {
+		t.Fatalf("编译失败: %v", err)
+	}
+	if files := cacheFiles(t, dir); len(files) != 1 {
+		t.Fatalf("期望写入 1 个缓存文件，实际 %d 个", len(files))
+	}
+
+	cached := NewBytecodeCache(dir, "test").Load("double.long", cacheSource)
+	if cached == nil {
+		t.Fatal("相同的源码应命中缓存")
+	}
+	if got := runLoaded(t, cached); got != "42" {
+		t.Errorf("缓存的字节码执行结果为 %s，期望 42", got)
+	}
+}
+
+// TestCacheMiss 源码、文件名或编译器版本变化，或缓存文件的格式版本不同时不命中
+func TestCacheMiss(t *testing.T) {
+	dir := t.TempDir()
+	bytecode, err := newTestVM().CompileFile("double.long", cacheSource)
+	if err != nil {
+		t.Fatalf("编译失败: %v", err)
+	}
+	cache := NewBytecodeCache(dir, "test")
+	if err := cache.Store("double.long", cacheSource, bytecode); err != nil {
+		t.Fatalf("写入缓存失败: %v", err)
+	}
+
+	if cache.Load("double.long", cacheSource+"\n") != nil {
+		t.Error("源码变化后不应命中缓存")
+	}
+	if cache.Load("other.long", cacheSource) != nil {
+		t.Error("文件名变化后不应命中缓存")
+	}
+	if NewBytecodeCache(dir, "other").Load("double.long", cacheSource) != nil {
+		t.Error("编译器版本变化后不应命中缓存")
+	}
+
+	// 旧版本写入的文件即使位于同一路径也不会被加载
+	path := cache.path("double.long", cacheSource)
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary.BigEndian.PutUint16(data[len(BytecodeMagic):], BytecodeFormatVersion-1)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Load("double.long", cacheSource) != nil {
+		t.Error("格式版本不同的缓存文件不应被加载")
+	}
+
+	// 损坏的缓存文件同样视为未命中，重新编译后覆盖
+	if err := os.WriteFile(path, []byte("LONGC"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	machine := newTestVM()
+	machine.SetBytecodeCache(cache)
+	recompiled, err := machine.CompileFile("double.long", cacheSource)
+	if err != nil {
+		t.Fatalf("缓存损坏时编译失败: %v", err)
+	}
+	if got, _ := machine.Load(recompiled); got == nil || got.Inspect() != "42" {
+		t.Errorf("重新编译后执行结果为 %v，期望 42", got)
+	}
+	if cache.Load("double.long", cacheSource) == nil {
+		t.Error("重新编译后应覆盖损坏的缓存文件")
+	}
+}
+
+// TestCacheKey 缓存路径由格式版本、编译器版本、文件名和源码共同决定
+func TestCacheKey(t *testing.T) {
+	cache := NewBytecodeCache("dir", "test")
+	base := cache.path("a.long", "x := 1")
+	for name, path := range map[string]string{
+		"源码":    cache.path("a.long", "x := 2"),
+		"文件名":   cache.path("b.long", "x := 1"),
+		"编译器版本": NewBytecodeCache("dir", "other").path("a.long", "x := 1"),
+	} {
+		if path == base {
+			t.Errorf("%s变化后缓存路径不变", name)
+		}
+	}
+	if cache.path("a.long", "x := 1") != base {
+		t.Error("相同的输入应得到相同的缓存路径")
+	}
+}
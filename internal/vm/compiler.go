@@ -16,10 +16,13 @@ type Compiler struct {
 	scopeStack       []*Scope            // 作用域栈
 	currentScope     *Scope              // 当前作用域
 	loopStack        []*LoopInfo         // 循环栈（用于 break/continue）
+	tryStack         []*TryInfo          // try 栈（用于 return/break/continue 时执行 finally）
 	classStack       []*ClassInfo        // 类编译栈
 	globals          map[string]int      // 全局变量索引
 	vm               *VM                 // 关联的虚拟机（用于运行时加载）
 	currentNamespace string              // 当前命名空间
+	currentEnum      *interpreter.Enum   // 当前正在编译方法的枚举（用于解析字段名）
+	directives       []Directive         // 编译期指令（按出现顺序）
 }
 
 // Scope 作用域
@@ -41,9 +44,17 @@ type Local struct {
 
 // LoopInfo 循环信息
 type LoopInfo struct {
-	start      int   // 循环开始位置
-	breakJumps []int // break 跳转位置列表
-	scopeDepth int   // 循环的作用域深度
+	start         int   // 循环开始位置
+	breakJumps    []int // break 跳转位置列表
+	continueJumps []int // continue 跳转位置列表（跳到增量部分）
+	scopeDepth    int   // 循环的作用域深度
+	tryDepth      int   // 进入循环时的 try 嵌套层数
+}
+
+// TryInfo try 语句编译信息
+// 在 try 块（以及有 finally 时的 catch 块）内提前退出时，需要弹出异常处理器并执行 finally
+type TryInfo struct {
+	finallyBlock *parser.BlockStatement // finally 块（可能为 nil）
 }
 
 // ClassInfo 类编译信息
@@ -79,6 +90,11 @@ func (c *Compiler) SetVM(vm *VM) {
 	c.vm = vm
 }
 
+// SetFileName 设置源文件名（记录到字节码中，用于堆栈跟踪）
+func (c *Compiler) SetFileName(fileName string) {
+	c.bytecode.FileName = fileName
+}
+
 // Compile 编译 AST
 func (c *Compiler) Compile(program *parser.Program) (*Bytecode, error) {
 	for _, stmt := range program.Statements {
@@ -90,6 +106,7 @@ func (c *Compiler) Compile(program *parser.Program) (*Bytecode, error) {
 	// 添加 HALT 指令
 	c.emit(OP_HALT, 0)
 
+	c.bytecode.Directives = c.directives
 	return c.bytecode, nil
 }
 
@@ -102,6 +119,14 @@ func (c *Compiler) CompileFunctionWithContext(fn *parser.FunctionLiteral, isInst
 	// 保存当前字节码
 	prevBytecode := c.bytecode
 	c.bytecode = NewBytecode()
+	c.bytecode.FileName = prevBytecode.FileName
+
+	// 循环和 try 上下文不跨越函数边界
+	prevLoopStack, prevTryStack := c.loopStack, c.tryStack
+	c.loopStack, c.tryStack = make([]*LoopInfo, 0), make([]*TryInfo, 0)
+	defer func() {
+		c.loopStack, c.tryStack = prevLoopStack, prevTryStack
+	}()
 
 	// 创建新函数作用域
 	c.beginFunctionScope()
@@ -168,6 +193,9 @@ func (c *Compiler) CompileFunctionWithContext(fn *parser.FunctionLiteral, isInst
 	if fn.Name != nil {
 		compiledFn.Name = fn.Name.Value
 	}
+	if n := len(fn.Parameters); n > 0 && fn.Parameters[n-1].IsVariadic {
+		compiledFn.IsVariadic = true
+	}
 
 	// 恢复字节码
 	c.bytecode = prevBytecode
@@ -177,8 +205,13 @@ func (c *Compiler) CompileFunctionWithContext(fn *parser.FunctionLiteral, isInst
 
 // ========== 语句编译 ==========
 
-// compileStatement 编译语句
+// compileStatement 编译语句（编译错误附带语句所在的源码位置）
 func (c *Compiler) compileStatement(stmt parser.Statement) error {
+	return c.errorAt(stmt, c.compileStatementNode(stmt))
+}
+
+// compileStatementNode 按语句类型分派编译
+func (c *Compiler) compileStatementNode(stmt parser.Statement) error {
 	switch s := stmt.(type) {
 	case *parser.LetStatement:
 		return c.compileLetStatement(s)
@@ -220,6 +253,11 @@ func (c *Compiler) compileStatement(stmt parser.Statement) error {
 		return c.compileInterfaceStatement(s)
 	case *parser.GoStatement:
 		return c.compileGoStatement(s)
+	case *parser.SelectStatement:
+		return c.compileSelectStatement(s)
+	case *parser.AnnotationDefinition:
+		// 注解定义不生成代码：注解实例只记录使用处显式给出的参数
+		return nil
 	default:
 		return fmt.Errorf("不支持的语句类型: %T", stmt)
 	}
@@ -300,6 +338,10 @@ func (c *Compiler) compileReturnStatement(stmt *parser.ReturnStatement) error {
 	} else {
 		c.emit(OP_NULL, stmt.Token.Line)
 	}
+	// 返回值留在栈顶，先退出所有 try 并执行 finally
+	if err := c.emitTryUnwind(0, stmt.Token.Line); err != nil {
+		return err
+	}
 	c.emit(OP_RETURN, stmt.Token.Line)
 	return nil
 }
@@ -386,6 +428,9 @@ func (c *Compiler) compileForStatement(stmt *parser.ForStatement) error {
 		return err
 	}
 
+	// continue 跳到这里
+	c.patchContinues()
+
 	// 增量
 	if stmt.Post != nil {
 		if err := c.compileStatement(stmt.Post); err != nil {
@@ -506,6 +551,9 @@ func (c *Compiler) compileForRangeStatement(stmt *parser.ForRangeStatement) erro
 		return err
 	}
 
+	// continue 跳到这里
+	c.patchContinues()
+
 	// === 增加索引：__index__++ ===
 	c.emitWithOperand(OP_GET_LOCAL, byte(indexSlot), stmt.Token.Line)
 	oneConst := c.addConstant(&interpreter.Integer{Value: 1})
@@ -535,10 +583,14 @@ func (c *Compiler) compileBreakStatement(stmt *parser.BreakStatement) error {
 		return fmt.Errorf("break 只能在循环中使用")
 	}
 
+	loop := c.loopStack[len(c.loopStack)-1]
+	if err := c.emitLoopExit(loop, stmt.Token.Line); err != nil {
+		return err
+	}
+
 	// 发出跳转指令，稍后修补
 	jump := c.emitJump(OP_JUMP, stmt.Token.Line)
-	c.loopStack[len(c.loopStack)-1].breakJumps = append(
-		c.loopStack[len(c.loopStack)-1].breakJumps, jump)
+	loop.breakJumps = append(loop.breakJumps, jump)
 
 	return nil
 }
@@ -549,9 +601,14 @@ func (c *Compiler) compileContinueStatement(stmt *parser.ContinueStatement) erro
 		return fmt.Errorf("continue 只能在循环中使用")
 	}
 
-	// 跳回循环开始
-	loopStart := c.loopStack[len(c.loopStack)-1].start
-	c.emitLoop(loopStart, stmt.Token.Line)
+	loop := c.loopStack[len(c.loopStack)-1]
+	if err := c.emitLoopExit(loop, stmt.Token.Line); err != nil {
+		return err
+	}
+
+	// 跳到循环的增量部分，稍后修补
+	jump := c.emitJump(OP_JUMP, stmt.Token.Line)
+	loop.continueJumps = append(loop.continueJumps, jump)
 
 	return nil
 }
@@ -581,14 +638,19 @@ func (c *Compiler) compileSwitchStatement(stmt *parser.SwitchStatement) error {
 				}
 				c.emit(OP_EQ, stmt.Token.Line)
 
-				// 编译其他值（用 OR 连接）
+				// 编译其他值（用 OR 连接：任一相等即跳到比较结束处，栈顶为 true）
+				orJumps := make([]int, 0, len(caseClause.Values)-1)
 				for i := 1; i < len(caseClause.Values); i++ {
+					orJumps = append(orJumps, c.emitJump(OP_JUMP_IF_TRUE, stmt.Token.Line))
+					c.emit(OP_POP, stmt.Token.Line) // 弹出上一个比较结果
 					c.emit(OP_DUP, stmt.Token.Line) // 复制 switch 值
 					if err := c.compileExpression(caseClause.Values[i]); err != nil {
 						return err
 					}
 					c.emit(OP_EQ, stmt.Token.Line)
-					// OR 操作（简化处理）
+				}
+				for _, jump := range orJumps {
+					c.patchJump(jump)
 				}
 			}
 		} else if caseClause.IsCondition && caseClause.Condition != nil {
@@ -643,17 +705,36 @@ func (c *Compiler) compileSwitchStatement(stmt *parser.SwitchStatement) error {
 }
 
 // compileTryStatement 编译 try 语句
+// 有 finally 时保证它在每条退出路径上执行：
+//   - 正常结束 try 或 catch：弹出处理器后内联执行 finally
+//   - return/break/continue：由 emitTryUnwind 在跳转前内联执行 finally
+//   - 异常逃出 try 或 catch：OP_SETUP_FINALLY 处理器跳到异常路径，执行 finally 后重新抛出
 func (c *Compiler) compileTryStatement(stmt *parser.TryStatement) error {
-	// 发出 PUSH_TRY 指令
-	tryJump := c.emitJump(OP_PUSH_TRY, stmt.Token.Line)
+	hasCatch := len(stmt.CatchClauses) > 0
+	hasFinally := stmt.FinallyBlock != nil
+
+	// 发出异常处理器：有 catch 时跳到 catch 分发，否则直接跳到 finally 异常路径
+	var tryJump int
+	if hasCatch {
+		tryJump = c.emitJump(OP_PUSH_TRY, stmt.Token.Line)
+	} else {
+		tryJump = c.emitJump(OP_SETUP_FINALLY, stmt.Token.Line)
+	}
 
 	// 编译 try 块
+	c.pushTry(stmt.FinallyBlock)
 	if err := c.compileStatement(stmt.TryBlock); err != nil {
 		return err
 	}
+	c.popTry()
 
-	// 发出 POP_TRY 指令
+	// 正常结束：弹出处理器，执行 finally
 	c.emit(OP_POP_TRY, stmt.Token.Line)
+	if hasFinally {
+		if err := c.compileStatement(stmt.FinallyBlock); err != nil {
+			return err
+		}
+	}
 
 	// 跳过 catch 块
 	var endJumps []int
@@ -662,63 +743,102 @@ func (c *Compiler) compileTryStatement(stmt *parser.TryStatement) error {
 	// 修补 try 跳转（指向 catch 块开始）
 	c.patchJump(tryJump)
 
-	// 此时栈顶是异常对象
-	// 编译 catch 块
-	var nextCatchJump int = -1
-
-	for i, catchClause := range stmt.CatchClauses {
-		if nextCatchJump != -1 {
-			c.patchJump(nextCatchJump)
-			c.emit(OP_POP, catchClause.Token.Line) // 弹出上一个 catch 检查留下的 false
-			nextCatchJump = -1
+	if hasCatch {
+		// 此时栈顶是异常对象
+		// 有 finally 时，catch 分发和 catch 体中的异常也要先执行 finally
+		finallyJump := -1
+		if hasFinally {
+			finallyJump = c.emitJump(OP_SETUP_FINALLY, stmt.Token.Line)
+			c.pushTry(stmt.FinallyBlock)
 		}
 
-		// 如果有类型限制
-		if catchClause.ExceptionType != nil {
-			c.emit(OP_DUP, catchClause.Token.Line) // 复制异常对象进行检查
-			// 加载异常类
-			if err := c.compileExpression(catchClause.ExceptionType); err != nil {
+		catchEndJumps := make([]int, 0, len(stmt.CatchClauses))
+		var nextCatchJump int = -1
+
+		for i, catchClause := range stmt.CatchClauses {
+			if nextCatchJump != -1 {
+				c.patchJump(nextCatchJump)
+				c.emit(OP_POP, catchClause.Token.Line) // 弹出上一个 catch 检查留下的 false
+				nextCatchJump = -1
+			}
+
+			// 如果有类型限制
+			if catchClause.ExceptionType != nil {
+				c.emit(OP_DUP, catchClause.Token.Line) // 复制异常对象进行检查
+				// 加载异常类
+				if err := c.compileExpression(catchClause.ExceptionType); err != nil {
+					return err
+				}
+				c.emit(OP_INSTANCE_OF, catchClause.Token.Line)
+				nextCatchJump = c.emitJump(OP_JUMP_IF_FALSE, catchClause.Token.Line)
+				c.emit(OP_POP, catchClause.Token.Line) // 弹出 true
+			}
+
+			c.beginScope()
+			// 此时栈顶是异常对象，直接作为异常变量（由 endScope 弹出）
+			c.declareVariable(catchClause.ExceptionVar.Value)
+			c.defineVariable(catchClause.ExceptionVar.Value)
+
+			slot, _ := c.resolveLocal(catchClause.ExceptionVar.Value)
+			c.emitWithOperand(OP_SET_LOCAL, byte(slot), catchClause.Token.Line)
+
+			// 编译 catch 体
+			if err := c.compileStatement(catchClause.Body); err != nil {
 				return err
 			}
-			c.emit(OP_INSTANCE_OF, catchClause.Token.Line)
-			nextCatchJump = c.emitJump(OP_JUMP_IF_FALSE, catchClause.Token.Line)
-			c.emit(OP_POP, catchClause.Token.Line) // 弹出 true
-		}
 
-		c.beginScope()
-		// 此时栈顶是异常对象
-		// 将异常对象存储到变量并弹出
-		c.declareVariable(catchClause.ExceptionVar.Value)
-		c.defineVariable(catchClause.ExceptionVar.Value)
-		
-		slot, _ := c.resolveLocal(catchClause.ExceptionVar.Value)
-		c.emitWithOperand(OP_SET_LOCAL, byte(slot), catchClause.Token.Line)
-		c.emit(OP_POP, catchClause.Token.Line) // 弹出异常对象
+			c.endScope()
 
-		// 编译 catch 体
-		if err := c.compileStatement(catchClause.Body); err != nil {
-			return err
+			// 跳到 catch 结束
+			catchEndJumps = append(catchEndJumps, c.emitJump(OP_JUMP, catchClause.Token.Line))
+
+			if i == len(stmt.CatchClauses)-1 {
+				// 最后一个 catch 的 nextCatchJump 需要修补到 re-throw
+				if nextCatchJump != -1 {
+					c.patchJump(nextCatchJump)
+					c.emit(OP_POP, stmt.Token.Line) // 弹出 false
+					nextCatchJump = -1
+					c.emit(OP_THROW, stmt.Token.Line) // 没有匹配的 catch，重新抛出
+				}
+			}
 		}
 
-		c.endScope()
+		for _, jump := range catchEndJumps {
+			c.patchJump(jump)
+		}
 
-		// 跳到 try-catch 结束
-		endJumps = append(endJumps, c.emitJump(OP_JUMP, catchClause.Token.Line))
-		
-		if i == len(stmt.CatchClauses)-1 {
-			// 最后一个 catch 的 nextCatchJump 需要修补到 re-throw
-			if nextCatchJump != -1 {
-				c.patchJump(nextCatchJump)
-				c.emit(OP_POP, stmt.Token.Line) // 弹出 false
-				nextCatchJump = -1
-				c.emit(OP_THROW, stmt.Token.Line) // 没有匹配的 catch，重新抛出
+		if hasFinally {
+			// catch 正常结束：弹出处理器，执行 finally
+			c.popTry()
+			c.emit(OP_POP_TRY, stmt.Token.Line)
+			if err := c.compileStatement(stmt.FinallyBlock); err != nil {
+				return err
 			}
+			endJumps = append(endJumps, c.emitJump(OP_JUMP, stmt.Token.Line))
+
+			// catch 中抛出的异常：栈上为 [原异常, 新异常]，丢弃原异常
+			c.patchJump(finallyJump)
+			c.emit(OP_SWAP, stmt.Token.Line)
+			c.emit(OP_POP, stmt.Token.Line)
 		}
+	} else if !hasFinally {
+		// 既没有 catch 也没有 finally：重新抛出
+		c.emit(OP_THROW, stmt.Token.Line)
 	}
 
-	// 如果没有任何 catch 块（虽然语法上不常见），直接 re-throw
-	if len(stmt.CatchClauses) == 0 {
+	if hasFinally {
+		// 异常路径：栈顶是异常对象，保存后执行 finally 再重新抛出
+		c.beginScope()
+		c.declareVariable("__finally_exception__")
+		slot, _ := c.resolveLocal("__finally_exception__")
+		c.emitWithOperand(OP_SET_LOCAL, byte(slot), stmt.Token.Line)
+		c.defineVariable("__finally_exception__")
+		if err := c.compileStatement(stmt.FinallyBlock); err != nil {
+			return err
+		}
+		c.emitWithOperand(OP_GET_LOCAL, byte(slot), stmt.Token.Line)
 		c.emit(OP_THROW, stmt.Token.Line)
+		c.dropScope()
 	}
 
 	// 修补所有结束跳转
@@ -726,13 +846,6 @@ func (c *Compiler) compileTryStatement(stmt *parser.TryStatement) error {
 		c.patchJump(jump)
 	}
 
-	// 编译 finally 块
-	if stmt.FinallyBlock != nil {
-		if err := c.compileStatement(stmt.FinallyBlock); err != nil {
-			return err
-		}
-	}
-
 	return nil
 }
 
@@ -797,6 +910,19 @@ func (c *Compiler) compileClassStatement(stmt *parser.ClassStatement) error {
 		}
 	}
 
+	// 实现的接口
+	if err := c.compileImplements(stmt.Interfaces, stmt.Token.Line); err != nil {
+		return err
+	}
+
+	// 类注解
+	if len(stmt.Annotations) > 0 {
+		if err := c.compileAnnotations(stmt.Annotations); err != nil {
+			return err
+		}
+		c.emitWithOperand(OP_CLASS_ANNOTATIONS, byte(len(stmt.Annotations)), stmt.Token.Line)
+	}
+
 	// 弹出类
 	c.emit(OP_POP, stmt.Token.Line)
 
@@ -806,6 +932,17 @@ func (c *Compiler) compileClassStatement(stmt *parser.ClassStatement) error {
 	return nil
 }
 
+// compileImplements 为栈顶的类或枚举记录实现的接口
+func (c *Compiler) compileImplements(interfaces []*parser.Identifier, line int) error {
+	for _, iface := range interfaces {
+		if err := c.compileExpression(iface); err != nil {
+			return err
+		}
+		c.emit(OP_IMPLEMENT, line)
+	}
+	return nil
+}
+
 // compileClassVariable 编译类变量
 func (c *Compiler) compileClassVariable(variable *parser.ClassVariable) error {
 	// 变量名常量
@@ -828,6 +965,47 @@ func (c *Compiler) compileClassVariable(variable *parser.ClassVariable) error {
 		c.emitWithOperand(OP_CLASS_VAR, byte(nameIndex), variable.Token.Line)
 	}
 	
+	// 变量的类型、访问修饰符和注解（供反射使用）
+	typeName := ""
+	if variable.Type != nil {
+		typeName = variable.Type.Value
+	}
+	typeIndex := c.addConstant(&interpreter.String{Value: typeName})
+	c.emitWithOperand(OP_CONST, byte(typeIndex), variable.Token.Line)
+	accessIndex := c.addConstant(&interpreter.String{Value: variable.AccessModifier})
+	c.emitWithOperand(OP_CONST, byte(accessIndex), variable.Token.Line)
+	if err := c.compileAnnotations(variable.Annotations); err != nil {
+		return err
+	}
+	c.emitWithOperand(OP_MEMBER_INFO, byte(nameIndex), variable.Token.Line)
+	c.bytecode.Instructions = append(c.bytecode.Instructions, byte(len(variable.Annotations)))
+	c.bytecode.Lines = append(c.bytecode.Lines, variable.Token.Line)
+	
+	return nil
+}
+
+// compileAnnotations 编译注解列表，每个注解在栈上留下一个注解实例
+// 注解参数在类定义时求值
+func (c *Compiler) compileAnnotations(annotations []*parser.Annotation) error {
+	if len(annotations) > 255 {
+		return fmt.Errorf("注解过多")
+	}
+	for _, ann := range annotations {
+		if len(ann.ArgOrder) > 255 {
+			return fmt.Errorf("注解 @%s 的参数过多", ann.Name.Value)
+		}
+		for _, key := range ann.ArgOrder {
+			keyIndex := c.addConstant(&interpreter.String{Value: key})
+			c.emitWithOperand(OP_CONST, byte(keyIndex), ann.Token.Line)
+			if err := c.compileExpression(ann.Arguments[key]); err != nil {
+				return err
+			}
+		}
+		nameIndex := c.addConstant(&interpreter.String{Value: ann.Name.Value})
+		c.emitWithOperand(OP_ANNOTATION, byte(nameIndex), ann.Token.Line)
+		c.bytecode.Instructions = append(c.bytecode.Instructions, byte(len(ann.ArgOrder)))
+		c.bytecode.Lines = append(c.bytecode.Lines, ann.Token.Line)
+	}
 	return nil
 }
 
@@ -932,6 +1110,7 @@ func (c *Compiler) compileIncrementStatement(stmt *parser.IncrementStatement) er
 		c.emit(OP_CONST, stmt.Token.Line)
 		oneConst := c.addConstant(&interpreter.Integer{Value: 1})
 		c.bytecode.Instructions = append(c.bytecode.Instructions, byte(oneConst))
+		c.bytecode.Lines = append(c.bytecode.Lines, stmt.Token.Line)
 		if stmt.Operator == "++" {
 			c.emit(OP_ADD, stmt.Token.Line)
 		} else {
@@ -942,10 +1121,16 @@ func (c *Compiler) compileIncrementStatement(stmt *parser.IncrementStatement) er
 	return nil
 }
 
-// compileGoStatement 编译 go 语句
+// compileGoStatement 编译 go 语句（不返回 Task，协程中未捕获的异常直接报告）
 func (c *Compiler) compileGoStatement(stmt *parser.GoStatement) error {
+	return c.compileGoCall(stmt.Call, false, stmt.Token.Line)
+}
+
+// compileGoCall 编译 go 语句或 go 表达式的调用部分
+// 压入函数和参数后发出 OP_GO，第二个操作数表示是否在栈上留下 Task（go 表达式）
+func (c *Compiler) compileGoCall(expr parser.Expression, returnsTask bool, line int) error {
 	// 编译函数调用表达式
-	call, ok := stmt.Call.(*parser.CallExpression)
+	call, ok := expr.(*parser.CallExpression)
 	if !ok {
 		return fmt.Errorf("go 后面必须是函数调用")
 	}
@@ -963,7 +1148,98 @@ func (c *Compiler) compileGoStatement(stmt *parser.GoStatement) error {
 	}
 
 	// 发出 GO 指令
-	c.emitWithOperand(OP_GO, byte(len(call.Arguments)), stmt.Token.Line)
+	taskFlag := byte(0)
+	if returnsTask {
+		taskFlag = 1
+	}
+	c.emitWithOperand(OP_GO, byte(len(call.Arguments)), line)
+	c.bytecode.Instructions = append(c.bytecode.Instructions, taskFlag)
+	c.bytecode.Lines = append(c.bytecode.Lines, line)
+
+	return nil
+}
+
+// compileSelectStatement 编译 select 语句
+// 每个分支依次压入分支种类、通道（after 分支为 null）和操作数（send 的值或 after 的毫秒数，receive 为 null），
+// OP_SELECT 等待任一分支就绪，压入接收到的值和选中分支的下标（default 为 -1），
+// 之后与 switch 一样逐个比较下标跳到对应的分支体；接收绑定的变量是分支作用域内的局部变量
+func (c *Compiler) compileSelectStatement(stmt *parser.SelectStatement) error {
+	line := stmt.Token.Line
+	if len(stmt.Cases) > 255 {
+		return fmt.Errorf("select 分支过多（最多 255 个）")
+	}
+
+	for _, selectCase := range stmt.Cases {
+		kindIndex := c.addConstant(&interpreter.Integer{Value: int64(selectCase.Kind)})
+		c.emitWithOperand(OP_CONST, byte(kindIndex), selectCase.Token.Line)
+		for _, operand := range []parser.Expression{selectCase.Channel, selectCase.Value} {
+			if operand == nil {
+				c.emit(OP_NULL, selectCase.Token.Line)
+				continue
+			}
+			if err := c.compileExpression(operand); err != nil {
+				return err
+			}
+		}
+	}
+
+	hasDefault := byte(0)
+	if stmt.Default != nil {
+		hasDefault = 1
+	}
+	c.emitWithOperand(OP_SELECT, byte(len(stmt.Cases)), line)
+	c.bytecode.Instructions = append(c.bytecode.Instructions, hasDefault)
+	c.bytecode.Lines = append(c.bytecode.Lines, line)
+
+	endJumps := make([]int, 0, len(stmt.Cases))
+	for idx, selectCase := range stmt.Cases {
+		// 比较选中的下标
+		c.emit(OP_DUP, line)
+		indexConst := c.addConstant(&interpreter.Integer{Value: int64(idx)})
+		c.emitWithOperand(OP_CONST, byte(indexConst), line)
+		c.emit(OP_EQ, line)
+		nextCase := c.emitJump(OP_JUMP_IF_FALSE, line)
+		c.emit(OP_POP, line) // 弹出比较结果
+		c.emit(OP_POP, line) // 弹出分支下标
+
+		// 接收到的值留在栈顶：绑定时直接作为局部变量，否则丢弃
+		c.beginScope()
+		if selectCase.Binding != nil {
+			c.declareVariable(selectCase.Binding.Value)
+			slot, ok := c.resolveLocal(selectCase.Binding.Value)
+			if !ok {
+				return fmt.Errorf("无法解析局部变量: %s", selectCase.Binding.Value)
+			}
+			c.emitWithOperand(OP_SET_LOCAL, byte(slot), selectCase.Token.Line)
+			c.defineVariable(selectCase.Binding.Value)
+		} else {
+			c.emit(OP_POP, line)
+		}
+		for _, bodyStmt := range selectCase.Body.Statements {
+			if err := c.compileStatement(bodyStmt); err != nil {
+				return err
+			}
+		}
+		c.endScope()
+
+		endJumps = append(endJumps, c.emitJump(OP_JUMP, line))
+
+		c.patchJump(nextCase)
+		c.emit(OP_POP, line) // 弹出比较结果
+	}
+
+	// default 分支
+	c.emit(OP_POP, line) // 弹出分支下标
+	c.emit(OP_POP, line) // 弹出接收到的值
+	if stmt.Default != nil {
+		if err := c.compileBlockStatement(stmt.Default); err != nil {
+			return err
+		}
+	}
+
+	for _, jump := range endJumps {
+		c.patchJump(jump)
+	}
 
 	return nil
 }
@@ -972,6 +1248,7 @@ func (c *Compiler) compileGoStatement(stmt *parser.GoStatement) error {
 func (c *Compiler) compileNamespaceStatement(stmt *parser.NamespaceStatement) error {
 	// 记录当前命名空间
 	c.currentNamespace = stmt.Name.Value
+	c.addDirective(stmt, Directive{Kind: DirectiveNamespace, Name: stmt.Name.Value})
 
 	// 如果有关联的 VM，设置当前命名空间
 	if c.vm != nil {
@@ -993,6 +1270,7 @@ func (c *Compiler) compileUseStatement(stmt *parser.UseStatement) error {
 	if stmt.Alias != nil {
 		alias = stmt.Alias.Value
 	}
+	c.addDirective(stmt, Directive{Kind: DirectiveUse, Name: fullPath, Alias: alias})
 
 	// 如果有关联的 VM，在运行时加载
 	if c.vm != nil {
@@ -1012,60 +1290,128 @@ func (c *Compiler) compileEnumStatement(stmt *parser.EnumStatement) error {
 	enum := &interpreter.Enum{
 		Name:       enumName,
 		Members:    make(map[string]*interpreter.EnumValue),
+		MemberList: make([]*interpreter.EnumValue, 0, len(stmt.Members)),
 		Methods:    make(map[string]*interpreter.ClassMethod),
+		Variables:  make(map[string]*interpreter.ClassVariable),
 		IsPublic:   stmt.IsPublic,
 		IsInternal: stmt.IsInternal,
 		Namespace:  c.currentNamespace,
 	}
 
-	// 编译枚举成员
-	for i, member := range stmt.Members {
-		var value interpreter.Object = &interpreter.Integer{Value: int64(i)}
-		if member.Value != nil {
-			// 如果有显式值，求值
-			if intLit, ok := member.Value.(*parser.IntegerLiteral); ok {
-				value = &interpreter.Integer{Value: intLit.Value}
+	// 设置底层类型
+	if stmt.BackingType != nil {
+		enum.BackingType = stmt.BackingType.Value
+	}
+
+	// 编译枚举字段（默认值必须是常量表达式）
+	for _, variable := range stmt.Variables {
+		var defaultValue interpreter.Object
+		if variable.Value != nil {
+			defaultValue = evalConstantExpression(variable.Value)
+			if defaultValue == nil {
+				return fmt.Errorf("枚举 %s 的字段 %s 默认值必须是常量表达式", enumName, variable.Name.Value)
 			}
 		}
+		fieldType := ""
+		if variable.Type != nil {
+			fieldType = variable.Type.Value
+		}
+		enum.Variables[variable.Name.Value] = &interpreter.ClassVariable{
+			Name:           variable.Name.Value,
+			Type:           fieldType,
+			AccessModifier: variable.AccessModifier,
+			DefaultValue:   defaultValue,
+		}
+	}
 
+	// 编译枚举成员
+	for i, member := range stmt.Members {
 		enumValue := &interpreter.EnumValue{
 			Name:    member.Name.Value,
 			Ordinal: i,
-			Value:   value,
 			Enum:    enum,
+			Fields:  make(map[string]interpreter.Object),
 		}
+
+		// 成员值（简单枚举没有值）
+		if member.Value != nil {
+			value := evalConstantExpression(member.Value)
+			if value == nil {
+				return fmt.Errorf("枚举成员 %s::%s 的值必须是常量表达式", enumName, member.Name.Value)
+			}
+			enumValue.Value = value
+		}
+
+		// 构造参数按字段声明顺序赋值（复杂枚举）
+		for idx, variable := range stmt.Variables {
+			if idx < len(member.Arguments) {
+				value := evalConstantExpression(member.Arguments[idx])
+				if value == nil {
+					return fmt.Errorf("枚举成员 %s::%s 的参数必须是常量表达式", enumName, member.Name.Value)
+				}
+				enumValue.Fields[variable.Name.Value] = value
+			} else if defaultValue := enum.Variables[variable.Name.Value].DefaultValue; defaultValue != nil {
+				enumValue.Fields[variable.Name.Value] = defaultValue
+			} else {
+				enumValue.Fields[variable.Name.Value] = &interpreter.Null{}
+			}
+		}
+
 		enum.Members[member.Name.Value] = enumValue
+		enum.MemberList = append(enum.MemberList, enumValue)
 	}
 
-	// 编译枚举方法
+	// 编译枚举方法为闭包（实例方法的 this 为枚举值）
+	prevEnum := c.currentEnum
+	c.currentEnum = enum
 	for _, method := range stmt.Methods {
-		// 转换参数类型
-		params := make([]interface{}, len(method.Parameters))
-		for i, p := range method.Parameters {
-			params[i] = p
+		fn := &parser.FunctionLiteral{
+			Token:      method.Token,
+			Name:       method.Name,
+			Parameters: method.Parameters,
+			ReturnType: method.ReturnType,
+			Body:       method.Body,
 		}
-		// 转换返回类型
+		compiledFn, _, err := c.CompileFunctionWithContext(fn, !method.IsStatic)
+		if err != nil {
+			c.currentEnum = prevEnum
+			return err
+		}
+		compiledFn.ClassName = enumName
+		if c.currentNamespace != "" {
+			compiledFn.ClassName = c.currentNamespace + "." + enumName
+		}
+
 		returnTypes := make([]string, len(method.ReturnType))
 		for i, rt := range method.ReturnType {
 			returnTypes[i] = rt.Value
 		}
 
-		classMethod := &interpreter.ClassMethod{
+		enum.Methods[method.Name.Value] = &interpreter.ClassMethod{
 			Name:           method.Name.Value,
-			Parameters:     params,
-			Body:           method.Body,
+			Body:           NewClosure(compiledFn),
 			ReturnType:     returnTypes,
 			AccessModifier: method.AccessModifier,
 			IsStatic:       method.IsStatic,
 		}
-		enum.Methods[method.Name.Value] = classMethod
 	}
+	c.currentEnum = prevEnum
 
 	// 将枚举添加到常量池并定义为全局变量
 	enumIndex := c.addConstant(enum)
 	c.emitWithOperand(OP_CONST, byte(enumIndex), stmt.Token.Line)
 	nameIndex := c.addConstant(&interpreter.String{Value: enumName})
 	c.emitWithOperand(OP_DEFINE_GLOBAL, byte(nameIndex), stmt.Token.Line)
+	c.addDirective(stmt, Directive{Kind: DirectiveEnum, Name: enumName, Object: enum})
+
+	// 实现的接口在运行时解析（接口可能来自其他文件）
+	if len(stmt.Interfaces) > 0 {
+		c.emitWithOperand(OP_CONST, byte(enumIndex), stmt.Token.Line)
+		if err := c.compileImplements(stmt.Interfaces, stmt.Token.Line); err != nil {
+			return err
+		}
+		c.emit(OP_POP, stmt.Token.Line)
+	}
 
 	// 如果有关联的 VM，注册到命名空间
 	if c.vm != nil && c.vm.currentNamespace != nil {
@@ -1118,6 +1464,7 @@ func (c *Compiler) compileInterfaceStatement(stmt *parser.InterfaceStatement) er
 	c.emitWithOperand(OP_CONST, byte(ifaceIndex), stmt.Token.Line)
 	nameIndex := c.addConstant(&interpreter.String{Value: interfaceName})
 	c.emitWithOperand(OP_DEFINE_GLOBAL, byte(nameIndex), stmt.Token.Line)
+	c.addDirective(stmt, Directive{Kind: DirectiveInterface, Name: interfaceName, Object: iface})
 
 	// 如果有关联的 VM，注册到命名空间
 	if c.vm != nil && c.vm.currentNamespace != nil {
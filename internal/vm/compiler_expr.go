@@ -9,8 +9,13 @@ import (
 
 // ========== 表达式编译 ==========
 
-// compileExpression 编译表达式
+// compileExpression 编译表达式（编译错误附带表达式所在的源码位置）
 func (c *Compiler) compileExpression(expr parser.Expression) error {
+	return c.errorAt(expr, c.compileExpressionNode(expr))
+}
+
+// compileExpressionNode 按表达式类型分派编译
+func (c *Compiler) compileExpressionNode(expr parser.Expression) error {
 	switch e := expr.(type) {
 	case *parser.IntegerLiteral:
 		return c.compileIntegerLiteral(e)
@@ -50,6 +55,10 @@ func (c *Compiler) compileExpression(expr parser.Expression) error {
 		return c.compileNewExpression(e)
 	case *parser.TernaryExpression:
 		return c.compileTernaryExpression(e)
+	case *parser.MatchExpression:
+		return c.compileMatchExpression(e)
+	case *parser.GoExpression:
+		return c.compileGoCall(e.Call, true, e.Token.Line)
 	case *parser.ThisExpression:
 		return c.compileThisExpression(e)
 	case *parser.SuperExpression:
@@ -58,6 +67,8 @@ func (c *Compiler) compileExpression(expr parser.Expression) error {
 		return c.compileStaticCallExpression(e)
 	case *parser.StaticAccessExpression:
 		return c.compileStaticAccessExpression(e)
+	case *parser.EnumAccessExpression:
+		return c.compileEnumAccessExpression(e)
 	case *parser.ClassLiteralExpression:
 		return c.compileClassLiteralExpression(e)
 	case *parser.InterpolatedStringLiteral:
@@ -122,6 +133,18 @@ func (c *Compiler) compileIdentifier(ident *parser.Identifier) error {
 		return nil
 	}
 
+	// 枚举方法中直接访问字段名：等价于 this.field
+	if c.currentEnum != nil {
+		if _, isField := c.currentEnum.Variables[ident.Value]; isField {
+			if slot, ok := c.resolveLocal("this"); ok {
+				c.emitWithOperand(OP_GET_LOCAL, byte(slot), ident.Token.Line)
+				nameIndex := c.addConstant(&interpreter.String{Value: ident.Value})
+				c.emitWithOperand(OP_GET_PROPERTY, byte(nameIndex), ident.Token.Line)
+				return nil
+			}
+		}
+	}
+
 	// 处理 self 和 static：获取 __called_class_name 并解析为类
 	if ident.Value == "self" || ident.Value == "static" {
 		nameIndex := c.addConstant(&interpreter.String{Value: "__called_class_name"})
@@ -608,6 +631,174 @@ func (c *Compiler) compileTernaryExpression(expr *parser.TernaryExpression) erro
 	return nil
 }
 
+// compileMatchExpression 编译 match 表达式
+// 匹配值保存在隐藏局部变量 __match__ 中，每个分支依次比较，命中后把结果留在栈顶并跳到结尾
+// 带代码块的分支先把结果写入隐藏变量 __match_result__，以便块内局部变量正常出栈
+func (c *Compiler) compileMatchExpression(expr *parser.MatchExpression) error {
+	line := expr.Token.Line
+
+	// 编译匹配值并存入隐藏变量
+	if err := c.compileExpression(expr.Value); err != nil {
+		return err
+	}
+	c.beginScope()
+	c.declareVariable("__match__")
+	matchSlot, _ := c.resolveLocal("__match__")
+	c.emitWithOperand(OP_SET_LOCAL, byte(matchSlot), line)
+	c.defineVariable("__match__")
+
+	// 存在代码块分支时需要结果变量
+	resultSlot := -1
+	for _, arm := range expr.Arms {
+		if arm.Body != nil {
+			c.emit(OP_NULL, line)
+			c.declareVariable("__match_result__")
+			resultSlot, _ = c.resolveLocal("__match_result__")
+			c.emitWithOperand(OP_SET_LOCAL, byte(resultSlot), line)
+			c.defineVariable("__match_result__")
+			break
+		}
+	}
+
+	endJumps := make([]int, 0)
+	hasWildcard := false
+
+	for _, arm := range expr.Arms {
+		armLine := arm.Token.Line
+
+		if arm.IsWildcard {
+			// 通配符 _ 总是匹配，之后的分支不可达
+			if err := c.compileMatchArmResult(arm, resultSlot); err != nil {
+				return err
+			}
+			endJumps = append(endJumps, c.emitJump(OP_JUMP, armLine))
+			hasWildcard = true
+			break
+		}
+
+		if arm.Binding != nil {
+			// 带守卫的绑定变量: identifier if guard => ...
+			c.beginScope()
+			c.emitWithOperand(OP_GET_LOCAL, byte(matchSlot), armLine)
+			c.declareVariable(arm.Binding.Value)
+			bindingSlot, _ := c.resolveLocal(arm.Binding.Value)
+			c.emitWithOperand(OP_SET_LOCAL, byte(bindingSlot), armLine)
+			c.defineVariable(arm.Binding.Value)
+
+			if err := c.compileExpression(arm.Guard); err != nil {
+				return err
+			}
+			nextArm := c.emitJump(OP_JUMP_IF_FALSE, armLine)
+			c.emit(OP_POP, armLine) // 弹出守卫结果
+
+			if err := c.compileMatchArmResult(arm, resultSlot); err != nil {
+				return err
+			}
+			// 丢弃绑定变量，保留结果
+			c.emit(OP_SWAP, armLine)
+			c.emit(OP_POP, armLine)
+			endJumps = append(endJumps, c.emitJump(OP_JUMP, armLine))
+
+			c.patchJump(nextArm)
+			c.emit(OP_POP, armLine) // 弹出守卫结果
+			c.emit(OP_POP, armLine) // 弹出绑定变量
+			c.dropScope()
+			continue
+		}
+
+		// 普通模式匹配: pattern1, pattern2, ... => ...
+		bodyJumps := make([]int, 0, len(arm.Patterns))
+		for _, pattern := range arm.Patterns {
+			c.emitWithOperand(OP_GET_LOCAL, byte(matchSlot), armLine)
+			if err := c.compileExpression(pattern); err != nil {
+				return err
+			}
+			c.emit(OP_EQ, armLine)
+			bodyJumps = append(bodyJumps, c.emitJump(OP_JUMP_IF_TRUE, armLine))
+			c.emit(OP_POP, armLine) // 弹出比较结果
+		}
+		nextArm := c.emitJump(OP_JUMP, armLine)
+
+		for _, jump := range bodyJumps {
+			c.patchJump(jump)
+		}
+		c.emit(OP_POP, armLine) // 弹出比较结果
+		if err := c.compileMatchArmResult(arm, resultSlot); err != nil {
+			return err
+		}
+		endJumps = append(endJumps, c.emitJump(OP_JUMP, armLine))
+
+		c.patchJump(nextArm)
+	}
+
+	// 没有分支匹配：抛出运行时异常
+	if !hasWildcard {
+		c.emitWithOperand(OP_GET_LOCAL, byte(matchSlot), line)
+		c.emit(OP_MATCH_FAIL, line)
+	}
+
+	for _, jump := range endJumps {
+		c.patchJump(jump)
+	}
+
+	// 丢弃隐藏变量，保留结果
+	if resultSlot != -1 {
+		c.emit(OP_SWAP, line)
+		c.emit(OP_POP, line)
+	}
+	c.emit(OP_SWAP, line)
+	c.emit(OP_POP, line)
+	c.dropScope()
+
+	return nil
+}
+
+// compileMatchArmResult 编译 match 分支的结果，结果留在栈顶
+func (c *Compiler) compileMatchArmResult(arm *parser.MatchArm, resultSlot int) error {
+	if arm.Body == nil {
+		if arm.Result == nil {
+			c.emit(OP_NULL, arm.Token.Line)
+			return nil
+		}
+		return c.compileExpression(arm.Result)
+	}
+
+	// 代码块形式：最后一个表达式语句（或 return 的值）作为结果
+	c.beginScope()
+	hasResult := false
+	for i, stmt := range arm.Body.Statements {
+		if ret, ok := stmt.(*parser.ReturnStatement); ok {
+			if ret.ReturnValue != nil {
+				if err := c.compileExpression(ret.ReturnValue); err != nil {
+					return err
+				}
+			} else {
+				c.emit(OP_NULL, ret.Token.Line)
+			}
+			hasResult = true
+			break
+		}
+		if exprStmt, ok := stmt.(*parser.ExpressionStatement); ok && i == len(arm.Body.Statements)-1 {
+			if err := c.compileExpression(exprStmt.Expression); err != nil {
+				return err
+			}
+			hasResult = true
+			break
+		}
+		if err := c.compileStatement(stmt); err != nil {
+			return err
+		}
+	}
+	if !hasResult {
+		c.emit(OP_NULL, arm.Token.Line)
+	}
+	c.emitWithOperand(OP_SET_LOCAL, byte(resultSlot), arm.Token.Line)
+	c.emit(OP_POP, arm.Token.Line)
+	c.endScope()
+	c.emitWithOperand(OP_GET_LOCAL, byte(resultSlot), arm.Token.Line)
+	return nil
+}
+
 // compileThisExpression 编译 this 表达式
 func (c *Compiler) compileThisExpression(expr *parser.ThisExpression) error {
 	// 查找 this 的槽位
@@ -708,6 +899,16 @@ func (c *Compiler) compileStaticAccessExpression(expr *parser.StaticAccessExpres
 	return nil
 }
 
+// compileEnumAccessExpression 编译枚举成员访问 EnumName::Member
+func (c *Compiler) compileEnumAccessExpression(expr *parser.EnumAccessExpression) error {
+	if err := c.compileExpression(expr.EnumName); err != nil {
+		return err
+	}
+	nameIndex := c.addConstant(&interpreter.String{Value: expr.Member.Value})
+	c.emitWithOperand(OP_GET_STATIC_FIELD, byte(nameIndex), expr.Token.Line)
+	return nil
+}
+
 // compileClassLiteralExpression 编译类名字面量表达式
 // ClassName::class 返回类名字符串
 func (c *Compiler) compileClassLiteralExpression(expr *parser.ClassLiteralExpression) error {
@@ -723,6 +924,7 @@ func (c *Compiler) compileInterpolatedString(expr *parser.InterpolatedStringLite
 		c.emit(OP_CONST, expr.Token.Line)
 		index := c.addConstant(&interpreter.String{Value: ""})
 		c.bytecode.Instructions = append(c.bytecode.Instructions, byte(index))
+		c.bytecode.Lines = append(c.bytecode.Lines, expr.Token.Line)
 		return nil
 	}
 
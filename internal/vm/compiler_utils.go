@@ -1,6 +1,7 @@
 package vm
 
 import (
+	"github.com/tangzhangming/longlang/internal/diagnostic"
 	"github.com/tangzhangming/longlang/internal/interpreter"
 	"github.com/tangzhangming/longlang/internal/parser"
 )
@@ -61,6 +62,19 @@ func (c *Compiler) endScope() {
 	}
 }
 
+// dropScope 结束作用域但不发出弹栈指令
+// 用于表达式内部的隐藏变量：它们在栈上的值已由调用者自行清理
+func (c *Compiler) dropScope() {
+	c.currentScope.scopeDepth--
+	for len(c.currentScope.locals) > 0 {
+		local := c.currentScope.locals[len(c.currentScope.locals)-1]
+		if local.depth <= c.currentScope.scopeDepth {
+			break
+		}
+		c.currentScope.locals = c.currentScope.locals[:len(c.currentScope.locals)-1]
+	}
+}
+
 // declareVariable 声明变量
 func (c *Compiler) declareVariable(name string) {
 	if c.currentScope.scopeDepth == 0 {
@@ -114,44 +128,49 @@ func (c *Compiler) resolveLocal(name string) (int, bool) {
 
 // resolveUpvalue 解析 upvalue
 func (c *Compiler) resolveUpvalue(name string) (int, bool) {
-	if c.currentScope.parent == nil {
+	return c.resolveUpvalueIn(c.currentScope, name)
+}
+
+// resolveUpvalueIn 在 scope 的外层函数中解析 upvalue
+// 外层函数的局部变量直接捕获；更外层的变量先登记为外层函数的 upvalue，再逐层传递
+func (c *Compiler) resolveUpvalueIn(scope *Scope, name string) (int, bool) {
+	parent := scope.parent
+	if parent == nil {
 		return -1, false
 	}
 
 	// 在父作用域的局部变量中查找
-	for i := len(c.currentScope.parent.locals) - 1; i >= 0; i-- {
-		if c.currentScope.parent.locals[i].name == name {
-			c.currentScope.parent.locals[i].isCaptured = true
-			return c.addUpvalue(i, true), true
+	for i := len(parent.locals) - 1; i >= 0; i-- {
+		if parent.locals[i].name == name {
+			parent.locals[i].isCaptured = true
+			return c.addUpvalue(scope, i, true), true
 		}
 	}
 
-	// 在父作用域的 upvalue 中查找
-	for i, upvalue := range c.currentScope.parent.upvalues {
-		if c.currentScope.parent.locals[upvalue.Index].name == name {
-			return c.addUpvalue(i, false), true
-		}
+	// 在更外层的作用域中查找，通过父作用域的 upvalue 传递
+	if index, ok := c.resolveUpvalueIn(parent, name); ok {
+		return c.addUpvalue(scope, index, false), true
 	}
 
 	return -1, false
 }
 
-// addUpvalue 添加 upvalue
-func (c *Compiler) addUpvalue(index int, isLocal bool) int {
+// addUpvalue 为作用域添加 upvalue
+func (c *Compiler) addUpvalue(scope *Scope, index int, isLocal bool) int {
 	// 检查是否已存在
-	for i, upvalue := range c.currentScope.upvalues {
+	for i, upvalue := range scope.upvalues {
 		if upvalue.Index == index && upvalue.IsLocal == isLocal {
 			return i
 		}
 	}
 
 	// 添加新的 upvalue
-	c.currentScope.upvalues = append(c.currentScope.upvalues, UpvalueDesc{
+	scope.upvalues = append(scope.upvalues, UpvalueDesc{
 		Index:   index,
 		IsLocal: isLocal,
 	})
 
-	return len(c.currentScope.upvalues) - 1
+	return len(scope.upvalues) - 1
 }
 
 // ========== 循环管理 ==========
@@ -159,9 +178,11 @@ func (c *Compiler) addUpvalue(index int, isLocal bool) int {
 // pushLoop 压入循环
 func (c *Compiler) pushLoop(start int) {
 	c.loopStack = append(c.loopStack, &LoopInfo{
-		start:      start,
-		breakJumps: make([]int, 0),
-		scopeDepth: c.currentScope.scopeDepth,
+		start:         start,
+		breakJumps:    make([]int, 0),
+		continueJumps: make([]int, 0),
+		scopeDepth:    c.currentScope.scopeDepth,
+		tryDepth:      len(c.tryStack),
 	})
 }
 
@@ -181,6 +202,93 @@ func (c *Compiler) patchBreaks() {
 	}
 }
 
+// patchContinues 修补 continue 跳转到当前位置
+func (c *Compiler) patchContinues() {
+	if len(c.loopStack) == 0 {
+		return
+	}
+	loop := c.loopStack[len(c.loopStack)-1]
+	for _, jump := range loop.continueJumps {
+		c.patchJump(jump)
+	}
+	loop.continueJumps = loop.continueJumps[:0]
+}
+
+// emitLoopExit 发出跳出循环体前的清理指令
+// 依次退出循环内的 try（弹出处理器并执行 finally），再弹出循环体内声明的局部变量
+func (c *Compiler) emitLoopExit(loop *LoopInfo, line int) error {
+	if err := c.emitTryUnwind(loop.tryDepth, line); err != nil {
+		return err
+	}
+	for i := len(c.currentScope.locals) - 1; i >= 0; i-- {
+		local := c.currentScope.locals[i]
+		if local.depth <= loop.scopeDepth {
+			break
+		}
+		if local.isCaptured {
+			c.emit(OP_CLOSE_UPVALUE, line)
+		} else {
+			c.emit(OP_POP, line)
+		}
+	}
+	return nil
+}
+
+// ========== try/finally 管理 ==========
+
+// emitTryUnwind 从内到外退出 try 栈中 depth 以上的 try：弹出异常处理器并内联执行 finally
+// 编译 finally 时临时移除当前及更内层的 try，避免 finally 中的 return 再次执行自身
+func (c *Compiler) emitTryUnwind(depth int, line int) error {
+	saved := c.tryStack
+	defer func() { c.tryStack = saved }()
+
+	for i := len(saved) - 1; i >= depth; i-- {
+		c.emit(OP_POP_TRY, line)
+		if saved[i].finallyBlock != nil {
+			c.tryStack = saved[:i]
+			if err := c.compileStatement(saved[i].finallyBlock); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// pushTry 压入 try 编译上下文
+func (c *Compiler) pushTry(finallyBlock *parser.BlockStatement) {
+	c.tryStack = append(c.tryStack, &TryInfo{finallyBlock: finallyBlock})
+}
+
+// popTry 弹出 try 编译上下文
+func (c *Compiler) popTry() {
+	c.tryStack = c.tryStack[:len(c.tryStack)-1]
+}
+
+// ========== 诊断信息 ==========
+
+// errorAt 将编译错误转换为带源码位置的诊断信息
+// 已经是诊断信息的错误（如内层节点或加载的其他文件产生的）原样返回
+func (c *Compiler) errorAt(node parser.Node, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch err.(type) {
+	case *diagnostic.Diagnostic, diagnostic.List:
+		return err
+	}
+	line, column := parser.NodePosition(node)
+	if line == 0 {
+		return err
+	}
+	return diagnostic.New(c.bytecode.FileName, line, column, "%s", err.Error())
+}
+
+// addDirective 记录编译期指令（附带语句的源码位置）
+func (c *Compiler) addDirective(node parser.Node, directive Directive) {
+	directive.Line, directive.Column = parser.NodePosition(node)
+	c.directives = append(c.directives, directive)
+}
+
 // ========== 字节码发出 ==========
 
 // emit 发出指令
@@ -0,0 +1,482 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/tangzhangming/longlang/internal/diagnostic"
+	"github.com/tangzhangming/longlang/internal/interpreter"
+	"github.com/tangzhangming/longlang/internal/parser"
+)
+
+// ========== 协程共享状态 ==========
+
+// GoroutineErrorHandler 协程中未捕获异常的处理函数
+// err 通常是 *VMError，可以通过 Diagnostic() 获取位置和堆栈
+type GoroutineErrorHandler func(err error)
+
+// sharedState 同一程序的所有协程共享的锁和错误记录
+// 每个协程有独立的栈和调用帧，全局变量表、命名空间等程序状态在协程间共享
+type sharedState struct {
+	mu     sync.RWMutex // 保护全局变量表和类的静态字段
+	loadMu sync.Mutex   // 串行化命名空间文件的加载
+
+	errMu           sync.Mutex
+	errorHandler    GoroutineErrorHandler // 为 nil 时输出到标准错误
+	goroutineErrors []error               // 所有协程中未捕获的异常
+}
+
+// newGoroutineVM 创建协程使用的虚拟机实例
+// 新实例有自己的栈、调用帧和 try 块栈，其余程序状态与当前虚拟机共享
+// 必须在父协程中调用，以免与父协程对当前命名空间等字段的修改产生竞争
+func (vm *VM) newGoroutineVM() *VM {
+	return &VM{
+		stack:             make([]interpreter.Object, StackSize),
+		frames:            make([]*Frame, FrameSize),
+		globals:           vm.globals,
+		builtins:          vm.builtins,
+		bytecode:          vm.bytecode,
+		tryStack:          make([]*TryState, MaxTryDepth),
+		projectRoot:       vm.projectRoot,
+		projectConfig:     vm.projectConfig,
+		namespaceMgr:      vm.namespaceMgr,
+		currentNamespace:  vm.currentNamespace,
+		loadedNamespaces:  vm.loadedNamespaces,
+		loadingNamespaces: vm.loadingNamespaces,
+		stdlibPath:        vm.stdlibPath,
+		cache:             vm.cache,
+		shared:            vm.shared,
+		debug:             vm.debug,
+	}
+}
+
+// getGlobal 读取全局变量
+func (vm *VM) getGlobal(name string) (interpreter.Object, bool) {
+	vm.shared.mu.RLock()
+	defer vm.shared.mu.RUnlock()
+	value, ok := vm.globals[name]
+	return value, ok
+}
+
+// setGlobal 设置全局变量
+func (vm *VM) setGlobal(name string, value interpreter.Object) {
+	vm.shared.mu.Lock()
+	defer vm.shared.mu.Unlock()
+	vm.globals[name] = value
+}
+
+// globalValues 返回全局变量值的快照，用于遍历
+func (vm *VM) globalValues() []interpreter.Object {
+	vm.shared.mu.RLock()
+	defer vm.shared.mu.RUnlock()
+	values := make([]interpreter.Object, 0, len(vm.globals))
+	for _, value := range vm.globals {
+		values = append(values, value)
+	}
+	return values
+}
+
+// getStaticField 读取类的静态字段
+func (vm *VM) getStaticField(class *interpreter.Class, name string) (interpreter.Object, bool) {
+	vm.shared.mu.RLock()
+	defer vm.shared.mu.RUnlock()
+	value, ok := class.StaticFields[name]
+	return value, ok
+}
+
+// setStaticField 设置类的静态字段
+func (vm *VM) setStaticField(class *interpreter.Class, name string, value interpreter.Object) {
+	vm.shared.mu.Lock()
+	defer vm.shared.mu.Unlock()
+	class.StaticFields[name] = value
+}
+
+// SetGoroutineErrorHandler 设置协程中未捕获异常的处理函数（nil 恢复默认的输出到标准错误）
+// 对当前虚拟机以及它启动的所有协程生效
+func (vm *VM) SetGoroutineErrorHandler(handler GoroutineErrorHandler) {
+	vm.shared.errMu.Lock()
+	defer vm.shared.errMu.Unlock()
+	vm.shared.errorHandler = handler
+}
+
+// GoroutineErrors 返回目前为止所有协程中未捕获的异常
+// 无论是否设置了处理函数都会记录，调用者可据此决定退出码
+func (vm *VM) GoroutineErrors() []error {
+	vm.shared.errMu.Lock()
+	defer vm.shared.errMu.Unlock()
+	return append([]error(nil), vm.shared.goroutineErrors...)
+}
+
+// reportGoroutineError 记录协程中未捕获的异常，并交给处理函数或输出到标准错误
+func (vm *VM) reportGoroutineError(err error) {
+	vm.shared.errMu.Lock()
+	vm.shared.goroutineErrors = append(vm.shared.goroutineErrors, err)
+	handler := vm.shared.errorHandler
+	if handler == nil {
+		// 持有锁输出，避免多个协程的错误信息交错
+		fmt.Fprintf(os.Stderr, "协程中未捕获的异常:\n%s\n", formatGoroutineError(err))
+	}
+	vm.shared.errMu.Unlock()
+
+	if handler != nil {
+		handler(err)
+	}
+}
+
+// formatGoroutineError 格式化协程中的错误（与主程序的运行时错误输出一致）
+func formatGoroutineError(err error) string {
+	if vmErr, ok := err.(*VMError); ok {
+		return vmErr.Diagnostic().Format()
+	}
+	return "运行时错误: " + diagnostic.FormatError(err)
+}
+
+// ========== 内置并发类型 ==========
+
+// BuiltinType 内置类型（Channel、WaitGroup、Mutex、Atomic、Task）
+// 以类名注册为全局变量，new 表达式通过它创建对应的内置对象，Task::all 等静态调用也以它为接收者
+type BuiltinType struct {
+	Name string // 类型名
+}
+
+func (t *BuiltinType) Type() interpreter.ObjectType { return "BUILTIN_TYPE" }
+func (t *BuiltinType) Inspect() string              { return "builtin type " + t.Name }
+
+// concurrencyTypes 内置并发类型（Task 由 go 表达式创建，不能 new）
+var concurrencyTypes = []string{"Channel", "WaitGroup", "Mutex", "Atomic", "Task"}
+
+// registerConcurrencyTypes 注册内置并发类型
+func (vm *VM) registerConcurrencyTypes() {
+	for _, name := range concurrencyTypes {
+		vm.globals[name] = &BuiltinType{Name: name}
+	}
+}
+
+// newBuiltinInstance 创建内置类型的实例（与解释器的 evalNewExpression 一致）
+func newBuiltinInstance(name string, args []interpreter.Object) (interpreter.Object, error) {
+	switch name {
+	case "Channel":
+		capacity := 0
+		if len(args) > 0 {
+			if intArg, ok := args[0].(*interpreter.Integer); ok {
+				capacity = int(intArg.Value)
+			}
+		}
+		return interpreter.NewChannel(capacity), nil
+
+	case "WaitGroup":
+		return interpreter.NewWaitGroup(), nil
+
+	case "Mutex":
+		return interpreter.NewMutex(), nil
+
+	case "Atomic":
+		var initialValue interpreter.Object = &interpreter.Null{}
+		if len(args) > 0 {
+			initialValue = args[0]
+		}
+		return interpreter.NewAtomic(initialValue), nil
+
+	case "Task":
+		return nil, fmt.Errorf("Task 不能用 new 创建，请使用 go 表达式（t := go f()）")
+	}
+	return nil, fmt.Errorf("未知的内置类型: %s", name)
+}
+
+// invokeBuiltinTypeStatic 调用内置类型的静态方法（目前只有 Task::all、Task::any）
+func (vm *VM) invokeBuiltinTypeStatic(t *BuiltinType, name string, argCount int) error {
+	args := vm.popArgs(argCount)
+	if t.Name != "Task" {
+		return fmt.Errorf("内置类型 %s 没有静态方法: %s", t.Name, name)
+	}
+	task, err := interpreter.TaskStaticCall(name, args)
+	if err != nil {
+		return err
+	}
+	vm.push(task)
+	return nil
+}
+
+// popArgs 弹出方法调用的参数和接收者，返回参数列表
+func (vm *VM) popArgs(argCount int) []interpreter.Object {
+	args := make([]interpreter.Object, argCount)
+	for i := argCount - 1; i >= 0; i-- {
+		args[i] = vm.pop()
+	}
+	vm.pop() // 弹出接收者
+	return args
+}
+
+// ========== Select ==========
+
+// executeSelect 执行 select 指令
+// 栈上每个分支有三个值：分支种类、通道、操作数（见 compileSelectStatement）
+// 执行后压入接收到的值和选中分支的下标（default 为 -1）
+func (vm *VM) executeSelect(caseCount int, hasDefault bool) error {
+	operands := vm.stack[vm.sp-caseCount*3 : vm.sp]
+	cases := make([]interpreter.SelectCase, caseCount)
+	for i := range cases {
+		kind := parser.SelectCaseKind(operands[i*3].(*interpreter.Integer).Value)
+		selectCase, err := interpreter.NewSelectCase(kind, operands[i*3+1], operands[i*3+2])
+		if err != nil {
+			return err
+		}
+		cases[i] = selectCase
+	}
+	vm.sp -= caseCount * 3
+
+	chosen, received, err := interpreter.Select(cases, hasDefault)
+	if err != nil {
+		return err
+	}
+	vm.push(received)
+	vm.push(&interpreter.Integer{Value: int64(chosen)})
+	return nil
+}
+
+// ========== Channel 方法 ==========
+
+// invokeChannelMethod 调用 Channel 方法
+func (vm *VM) invokeChannelMethod(ch *interpreter.ChannelObject, name string, argCount int) error {
+	args := vm.popArgs(argCount)
+
+	var result interpreter.Object = &interpreter.Null{}
+
+	switch name {
+	case "send":
+		if len(args) != 1 {
+			return fmt.Errorf("send() 需要1个参数")
+		}
+		if ch.IsClosed() {
+			return fmt.Errorf("不能向已关闭的通道发送数据")
+		}
+		ch.Send(args[0])
+
+	case "receive":
+		if value, ok := ch.Receive(); ok {
+			result = value
+		}
+
+	case "tryReceive":
+		if value, ok := ch.TryReceive(); ok && value != nil {
+			result = value
+		}
+
+	case "close":
+		ch.Close()
+
+	case "isClosed":
+		result = &interpreter.Boolean{Value: ch.IsClosed()}
+
+	case "isEmpty":
+		result = &interpreter.Boolean{Value: ch.Len() == 0}
+
+	case "len":
+		result = &interpreter.Integer{Value: int64(ch.Len())}
+
+	case "cap":
+		result = &interpreter.Integer{Value: int64(ch.Cap())}
+
+	case "forEach":
+		if len(args) != 1 {
+			return fmt.Errorf("forEach() 需要1个回调函数参数")
+		}
+		// 遍历通道直到关闭，回调出错时停止
+		for {
+			value, ok := ch.Receive()
+			if !ok {
+				break
+			}
+			if _, err := vm.callFunction(args[0], []interpreter.Object{value}); err != nil {
+				return err
+			}
+		}
+
+	default:
+		return fmt.Errorf("Channel 没有方法: %s", name)
+	}
+
+	vm.push(result)
+	return nil
+}
+
+// ========== WaitGroup 方法 ==========
+
+// invokeWaitGroupMethod 调用 WaitGroup 方法
+func (vm *VM) invokeWaitGroupMethod(wg *interpreter.WaitGroupObject, name string, argCount int) error {
+	args := vm.popArgs(argCount)
+
+	switch name {
+	case "add":
+		if len(args) != 1 {
+			return fmt.Errorf("add() 需要1个参数")
+		}
+		delta, ok := args[0].(*interpreter.Integer)
+		if !ok {
+			return fmt.Errorf("add() 参数必须是整数")
+		}
+		wg.Add(int(delta.Value))
+
+	case "done":
+		wg.Done()
+
+	case "wait":
+		wg.Wait()
+
+	default:
+		return fmt.Errorf("WaitGroup 没有方法: %s", name)
+	}
+
+	vm.push(&interpreter.Null{})
+	return nil
+}
+
+// ========== Mutex 方法 ==========
+
+// invokeMutexMethod 调用 Mutex 方法
+func (vm *VM) invokeMutexMethod(m *interpreter.MutexObject, name string, argCount int) error {
+	args := vm.popArgs(argCount)
+
+	var result interpreter.Object = &interpreter.Null{}
+
+	switch name {
+	case "lock":
+		m.Lock()
+
+	case "unlock":
+		m.Unlock()
+
+	case "tryLock":
+		result = &interpreter.Boolean{Value: m.TryLock()}
+
+	case "withLock":
+		if len(args) != 1 {
+			return fmt.Errorf("withLock() 需要1个回调函数参数")
+		}
+		// 回调返回或出错后都会释放锁
+		m.Lock()
+		value, err := vm.callFunction(args[0], nil)
+		m.Unlock()
+		if err != nil {
+			return err
+		}
+		result = value
+
+	default:
+		return fmt.Errorf("Mutex 没有方法: %s", name)
+	}
+
+	vm.push(result)
+	return nil
+}
+
+// ========== Atomic 方法 ==========
+
+// invokeAtomicMethod 调用 Atomic 方法
+func (vm *VM) invokeAtomicMethod(a *interpreter.AtomicObject, name string, argCount int) error {
+	args := vm.popArgs(argCount)
+
+	var result interpreter.Object = &interpreter.Null{}
+
+	switch name {
+	case "get":
+		result = a.Get()
+
+	case "set":
+		if len(args) != 1 {
+			return fmt.Errorf("set() 需要1个参数")
+		}
+		a.Set(args[0])
+
+	case "add", "increment", "decrement":
+		var delta int64
+		switch name {
+		case "add":
+			if len(args) != 1 {
+				return fmt.Errorf("add() 需要1个参数")
+			}
+			intArg, ok := args[0].(*interpreter.Integer)
+			if !ok {
+				return fmt.Errorf("add() 参数必须是整数")
+			}
+			delta = intArg.Value
+		case "increment":
+			delta = 1
+		case "decrement":
+			delta = -1
+		}
+		value, ok := a.AddInt(delta)
+		if !ok {
+			return fmt.Errorf("Atomic 值不是整数，无法使用 %s()", name)
+		}
+		result = value
+
+	case "compareAndSwap":
+		if len(args) != 2 {
+			return fmt.Errorf("compareAndSwap() 需要2个参数")
+		}
+		result = &interpreter.Boolean{Value: a.CompareAndSwap(args[0], args[1])}
+
+	case "update":
+		if len(args) != 1 {
+			return fmt.Errorf("update() 需要1个回调函数参数")
+		}
+		value, err := a.Update(func(current interpreter.Object) (interpreter.Object, error) {
+			return vm.callFunction(args[0], []interpreter.Object{current})
+		})
+		if err != nil {
+			return err
+		}
+		result = value
+
+	default:
+		return fmt.Errorf("Atomic 没有方法: %s", name)
+	}
+
+	vm.push(result)
+	return nil
+}
+
+// ========== Task 方法 ==========
+
+// invokeTaskMethod 调用 Task 方法
+// await() 遇到任务失败时返回协程中的原始错误，抛出的异常实例因此原样传给调用方的 catch
+func (vm *VM) invokeTaskMethod(t *interpreter.TaskObject, name string, argCount int) error {
+	args := vm.popArgs(argCount)
+
+	var result interpreter.Object = &interpreter.Null{}
+
+	switch name {
+	case "await":
+		timeout, err := interpreter.TaskAwaitTimeout(args)
+		if err != nil {
+			return err
+		}
+		value, err := t.Wait(timeout)
+		if errors.Is(err, interpreter.ErrTaskCancelled) {
+			// 等待已取消的任务抛出 CancelledException
+			return vm.throwBuiltinException(interpreter.NewCancelledException(err.Error()))
+		}
+		if err != nil {
+			return err
+		}
+		result = value
+
+	case "cancel":
+		result = &interpreter.Boolean{Value: t.Cancel()}
+
+	case "isDone":
+		result = &interpreter.Boolean{Value: t.IsDone()}
+
+	case "isCancelled":
+		result = &interpreter.Boolean{Value: t.IsCancelled()}
+
+	default:
+		return fmt.Errorf("Task 没有方法: %s", name)
+	}
+
+	vm.push(result)
+	return nil
+}
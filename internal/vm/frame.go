@@ -153,6 +153,20 @@ func (u *Upvalue) Close() {
 	u.Location = nil
 }
 
+// detached 返回供协程使用的闭包副本
+// 开放的 upvalue 指向父协程的栈，父函数返回或复用栈槽时会被改写，
+// 因此替换为持有当前值的已关闭 upvalue；已关闭的 upvalue 位于堆上，继续共享
+func (c *Closure) detached() *Closure {
+	upvalues := make([]*Upvalue, len(c.Upvalues))
+	for i, upvalue := range c.Upvalues {
+		if upvalue != nil && !upvalue.Closed {
+			upvalue = &Upvalue{Value: *upvalue.Location, Closed: true}
+		}
+		upvalues[i] = upvalue
+	}
+	return &Closure{Fn: c.Fn, Upvalues: upvalues}
+}
+
 // ========== 调用信息 ==========
 
 // CallInfo 调用信息（用于错误堆栈）
@@ -278,12 +278,32 @@ func (vm *VM) isEqual(a, b interpreter.Object) bool {
 	case *interpreter.Null:
 		_, ok := b.(*interpreter.Null)
 		return ok
+	case *interpreter.EnumValue:
+		if bv, ok := b.(*interpreter.EnumValue); ok {
+			return av.Enum.Name == bv.Enum.Name && av.Name == bv.Name
+		}
 	}
 
 	// 引用相等
 	return a == b
 }
 
+// checkEnumComparison 检查枚举值比较：不同枚举类型不能比较
+func (vm *VM) checkEnumComparison(a, b interpreter.Object) error {
+	av, ok := a.(*interpreter.EnumValue)
+	if !ok {
+		return nil
+	}
+	bv, ok := b.(*interpreter.EnumValue)
+	if !ok {
+		return nil
+	}
+	if av.Enum.Name != bv.Enum.Name {
+		return fmt.Errorf("不能比较不同枚举类型: %s 和 %s", av.Enum.Name, bv.Enum.Name)
+	}
+	return nil
+}
+
 // isTruthy 判断值是否为真
 func (vm *VM) isTruthy(obj interpreter.Object) bool {
 	if obj == nil {
@@ -345,6 +365,33 @@ func (vm *VM) callValue(callee interpreter.Object, argCount int) error {
 	return fmt.Errorf("不能调用 %s 类型", callee.Type())
 }
 
+// callFunction 从 Go 代码中同步调用函数（闭包、绑定方法或内置函数），执行到函数返回
+// 用于内置方法调用回调函数（如 Channel.forEach、Mutex.withLock）；出错时恢复调用前的栈和调用栈
+func (vm *VM) callFunction(callee interpreter.Object, args []interpreter.Object) (interpreter.Object, error) {
+	baseFrame, baseTry, baseSP := vm.frameCount, vm.tryCount, vm.sp
+
+	vm.push(callee)
+	for _, arg := range args {
+		vm.push(arg)
+	}
+
+	err := vm.callValue(callee, len(args))
+	if err == nil && vm.frameCount == baseFrame {
+		// 内置函数已经直接压入了结果
+		return vm.pop(), nil
+	}
+	var result interpreter.Object
+	if err == nil {
+		result, err = vm.executeFrom(baseFrame, baseTry)
+	}
+	if err != nil {
+		vm.closeUpvalues(baseSP)
+		vm.frameCount, vm.tryCount, vm.sp = baseFrame, baseTry, baseSP
+		return nil, err
+	}
+	return result, nil
+}
+
 // callClosure 调用闭包
 func (vm *VM) callClosure(closure *Closure, argCount int) error {
 	// 检查参数数量
@@ -353,6 +400,10 @@ func (vm *VM) callClosure(closure *Closure, argCount int) error {
 			closure.Fn.Name, closure.Fn.NumParams, argCount)
 	}
 
+	if closure.Fn.IsVariadic {
+		argCount = vm.packVariadicArgs(closure.Fn, argCount)
+	}
+
 	// 如果参数不足，用默认值填充
 	if argCount < closure.Fn.NumParams {
 		for i := argCount; i < closure.Fn.NumParams; i++ {
@@ -362,6 +413,10 @@ func (vm *VM) callClosure(closure *Closure, argCount int) error {
 			}
 			vm.push(defaultVal)
 		}
+		if closure.Fn.IsVariadic {
+			// 实参少于固定参数时，可变参数位置填充的是 null，换成空数组
+			vm.stack[vm.sp-1] = &interpreter.Array{Elements: []interpreter.Object{}, ElementType: "any"}
+		}
 		argCount = closure.Fn.NumParams
 	}
 
@@ -373,6 +428,21 @@ func (vm *VM) callClosure(closure *Closure, argCount int) error {
 	return nil
 }
 
+// packVariadicArgs 把可变参数位置及之后的实参收集为一个数组，返回收集后的参数数量
+// 实参少于固定参数时不收集，由调用方在填充默认值后放入空数组
+func (vm *VM) packVariadicArgs(fn *CompiledFunction, argCount int) int {
+	fixed := fn.NumParams - 1
+	if argCount < fixed {
+		return argCount
+	}
+	n := argCount - fixed
+	elements := make([]interpreter.Object, n)
+	copy(elements, vm.stack[vm.sp-n:vm.sp])
+	vm.sp -= n
+	vm.push(&interpreter.Array{Elements: elements, ElementType: "any", Capacity: int64(n)})
+	return fn.NumParams
+}
+
 // callMethod 调用方法（方法调用没有函数对象在栈上）
 func (vm *VM) callMethod(closure *Closure, argCount int) error {
 	// 允许参数数量小于等于 NumParams（支持默认参数）
@@ -381,6 +451,10 @@ func (vm *VM) callMethod(closure *Closure, argCount int) error {
 			closure.Fn.Name, closure.Fn.NumParams, argCount)
 	}
 
+	if closure.Fn.IsVariadic {
+		argCount = vm.packVariadicArgs(closure.Fn, argCount)
+	}
+
 	// 如果参数不足，用默认值填充
 	// 注意：对于实例方法，NumParams 包含 this，但 DefaultValues 不包含
 	// 所以默认值的索引需要减 1
@@ -394,6 +468,10 @@ func (vm *VM) callMethod(closure *Closure, argCount int) error {
 			}
 			vm.push(defaultVal)
 		}
+		if closure.Fn.IsVariadic {
+			// 实参少于固定参数时，可变参数位置填充的是 null，换成空数组
+			vm.stack[vm.sp-1] = &interpreter.Array{Elements: []interpreter.Object{}, ElementType: "any"}
+		}
 		argCount = closure.Fn.NumParams
 	}
 
@@ -412,6 +490,10 @@ func (vm *VM) callConstructor(closure *Closure, argCount int) error {
 			closure.Fn.Name, closure.Fn.NumParams, argCount)
 	}
 
+	if closure.Fn.IsVariadic {
+		argCount = vm.packVariadicArgs(closure.Fn, argCount)
+	}
+
 	// 如果参数不足，用默认值填充
 	// 注意：对于实例方法/构造函数，NumParams 包含 this，但 DefaultValues 不包含
 	// 所以默认值的索引需要减 1
@@ -425,6 +507,10 @@ func (vm *VM) callConstructor(closure *Closure, argCount int) error {
 			}
 			vm.push(defaultVal)
 		}
+		if closure.Fn.IsVariadic {
+			// 实参少于固定参数时，可变参数位置填充的是 null，换成空数组
+			vm.stack[vm.sp-1] = &interpreter.Array{Elements: []interpreter.Object{}, ElementType: "any"}
+		}
 		argCount = closure.Fn.NumParams
 	}
 
@@ -447,11 +533,60 @@ func (vm *VM) callBuiltinFn(builtin *interpreter.Builtin, argCount int) error {
 	if err, ok := result.(*interpreter.Error); ok {
 		return fmt.Errorf("%s", err.Message)
 	}
+	if te, ok := result.(*interpreter.ThrownException); ok {
+		return vm.throwBuiltinException(te)
+	}
 
 	vm.push(result)
 	return nil
 }
 
+// throwBuiltinException 抛出内置函数返回的异常（如 IOException、CancelledException）
+// 内置函数创建的异常类只是占位，这里换成标准库中真正的 System.Xxx 类，
+// 使 catch (IOException e) 这样的类型匹配在 VM 中生效
+func (vm *VM) throwBuiltinException(te *interpreter.ThrownException) error {
+	typeName := te.GetExceptionType()
+	class, ok := vm.getClassByName("System." + typeName)
+	if !ok {
+		// 程序没有 use 该异常类时按需加载（与 ProcessUseStatement 一样串行加载）
+		if vm.loadDepth == 0 {
+			vm.shared.loadMu.Lock()
+			defer vm.shared.loadMu.Unlock()
+		}
+		vm.loadDepth++
+		err := vm.loadNamespaceFile("System", typeName)
+		vm.loadDepth--
+		if err == nil {
+			class, ok = vm.getClassByName("System." + typeName)
+		}
+	}
+	if !ok {
+		return fmt.Errorf("%s: %s", typeName, te.GetMessage())
+	}
+
+	instance := &interpreter.Instance{
+		Class:  class,
+		Fields: make(map[string]interpreter.Object),
+	}
+	for c := class; c != nil; c = c.Parent {
+		for name, variable := range c.Variables {
+			if _, exists := instance.Fields[name]; exists {
+				continue
+			}
+			if variable.DefaultValue != nil {
+				instance.Fields[name] = variable.DefaultValue
+			} else {
+				instance.Fields[name] = &interpreter.Null{}
+			}
+		}
+	}
+	instance.Fields["message"] = &interpreter.String{Value: te.GetMessage()}
+	instance.Fields["code"] = &interpreter.Integer{Value: 0}
+
+	vm.recordStackTrace(instance)
+	return &VMError{Message: "异常抛出", Exception: instance}
+}
+
 // callClass 调用类（创建实例）
 func (vm *VM) callClass(class *interpreter.Class, argCount int) error {
 	// 创建实例
@@ -532,6 +667,24 @@ func (vm *VM) invoke(name string, argCount int) error {
 	case *interpreter.Map:
 		return vm.invokeMapMethod(obj, name, argCount)
 
+	case *interpreter.EnumValue:
+		return vm.invokeEnumMethod(obj, name, argCount)
+
+	case *interpreter.ChannelObject:
+		return vm.invokeChannelMethod(obj, name, argCount)
+
+	case *interpreter.WaitGroupObject:
+		return vm.invokeWaitGroupMethod(obj, name, argCount)
+
+	case *interpreter.MutexObject:
+		return vm.invokeMutexMethod(obj, name, argCount)
+
+	case *interpreter.AtomicObject:
+		return vm.invokeAtomicMethod(obj, name, argCount)
+
+	case *interpreter.TaskObject:
+		return vm.invokeTaskMethod(obj, name, argCount)
+
 	case *interpreter.BuiltinObject:
 		// 命名空间方法调用
 		if field, ok := obj.GetField(name); ok {
@@ -591,6 +744,12 @@ func (vm *VM) invokeStatic(name string, argCount int) error {
 		}
 		return fmt.Errorf("内置对象 %s 没有方法: %s", obj.Name, name)
 
+	case *interpreter.Enum:
+		return vm.invokeEnumStaticMethod(obj, name, argCount)
+
+	case *BuiltinType:
+		return vm.invokeBuiltinTypeStatic(obj, name, argCount)
+
 	case *interpreter.String:
 		// 通过类名字符串调用静态方法（用于 self:: 和 static::）
 		className := obj.Value
@@ -619,11 +778,131 @@ func (vm *VM) callBuiltinStatic(builtin *interpreter.Builtin, argCount int) erro
 	if err, ok := result.(*interpreter.Error); ok {
 		return fmt.Errorf("%s", err.Message)
 	}
+	if te, ok := result.(*interpreter.ThrownException); ok {
+		return vm.throwBuiltinException(te)
+	}
 
 	vm.push(result)
 	return nil
 }
 
+// ========== 枚举方法 ==========
+
+// invokeEnumMethod 调用枚举值方法
+// 栈上为 [enumValue, arg0, arg1, ...]，自定义方法以枚举值作为 this 调用
+func (vm *VM) invokeEnumMethod(ev *interpreter.EnumValue, name string, argCount int) error {
+	switch name {
+	case "name", "ordinal", "value":
+		vm.sp -= argCount + 1
+		result, err := vm.enumBuiltinMethod(ev, name)
+		if err != nil {
+			return err
+		}
+		vm.push(result)
+		return nil
+	}
+
+	method, ok := ev.Enum.GetMethod(name)
+	if !ok || method.IsStatic {
+		return fmt.Errorf("枚举 %s 没有方法 %s", ev.Enum.Name, name)
+	}
+	closure, ok := method.Body.(*Closure)
+	if !ok {
+		return fmt.Errorf("枚举方法体无效")
+	}
+	return vm.callMethod(closure, argCount+1)
+}
+
+// enumBuiltinMethod 执行枚举值内置方法 name/ordinal/value
+func (vm *VM) enumBuiltinMethod(ev *interpreter.EnumValue, name string) (interpreter.Object, error) {
+	switch name {
+	case "name":
+		return &interpreter.String{Value: ev.Name}, nil
+	case "ordinal":
+		return &interpreter.Integer{Value: int64(ev.Ordinal)}, nil
+	case "value":
+		if ev.Value != nil {
+			return ev.Value, nil
+		}
+		return nil, fmt.Errorf("简单枚举没有 value() 方法，请使用带值枚举")
+	}
+	return nil, fmt.Errorf("枚举值没有成员: %s", name)
+}
+
+// invokeEnumStaticMethod 调用枚举静态方法
+// 栈上为 [enum, arg0, arg1, ...]
+func (vm *VM) invokeEnumStaticMethod(enum *interpreter.Enum, name string, argCount int) error {
+	switch name {
+	case "cases", "count", "from", "tryFrom", "valueOf":
+		args := make([]interpreter.Object, argCount)
+		for i := argCount - 1; i >= 0; i-- {
+			args[i] = vm.pop()
+		}
+		vm.pop() // 弹出枚举本身
+
+		result, err := vm.enumBuiltinStaticMethod(enum, name, args)
+		if err != nil {
+			return err
+		}
+		vm.push(result)
+		return nil
+	}
+
+	method, ok := enum.GetMethod(name)
+	if !ok || !method.IsStatic {
+		return fmt.Errorf("枚举 %s 没有静态方法 %s", enum.Name, name)
+	}
+	closure, ok := method.Body.(*Closure)
+	if !ok {
+		return fmt.Errorf("枚举静态方法体无效")
+	}
+	return vm.callClosure(closure, argCount)
+}
+
+// enumBuiltinStaticMethod 执行枚举内置静态方法
+func (vm *VM) enumBuiltinStaticMethod(enum *interpreter.Enum, name string, args []interpreter.Object) (interpreter.Object, error) {
+	switch name {
+	case "cases":
+		elements := make([]interpreter.Object, len(enum.MemberList))
+		for i, member := range enum.MemberList {
+			elements[i] = member
+		}
+		return &interpreter.Array{Elements: elements}, nil
+
+	case "count":
+		return &interpreter.Integer{Value: int64(len(enum.MemberList))}, nil
+
+	case "from", "tryFrom":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("%s() 需要1个参数", name)
+		}
+		for _, member := range enum.MemberList {
+			if member.Value != nil && member.Value.Type() == args[0].Type() && vm.isEqual(member.Value, args[0]) {
+				return member, nil
+			}
+		}
+		if name == "tryFrom" {
+			return &interpreter.Null{}, nil
+		}
+		return nil, fmt.Errorf("无效的枚举值: %s，枚举 %s 没有此值", args[0].Inspect(), enum.Name)
+
+	case "valueOf":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("valueOf() 需要1个参数")
+		}
+		nameStr, ok := args[0].(*interpreter.String)
+		if !ok {
+			return nil, fmt.Errorf("valueOf() 参数必须是字符串")
+		}
+		member, found := enum.GetMember(nameStr.Value)
+		if !found {
+			return nil, fmt.Errorf("无效的枚举名称: %s，枚举 %s 没有此成员", nameStr.Value, enum.Name)
+		}
+		return member, nil
+	}
+	return nil, fmt.Errorf("枚举 %s 没有静态方法 %s", enum.Name, name)
+}
+
 // ========== 字符串方法 ==========
 
 // invokeStringMethod 调用字符串方法
@@ -1089,26 +1368,37 @@ func (vm *VM) indexSet(obj, index, value interpreter.Object) error {
 
 // ========== 协程 ==========
 
-// runGoroutine 运行协程
-func (vm *VM) runGoroutine(closure *Closure, argCount int) {
-	// 创建新的虚拟机实例
-	newVM := NewVM()
-
-	// 复制参数
-	args := make([]interpreter.Object, argCount)
-	for i := 0; i < argCount; i++ {
-		args[i] = vm.stack[vm.sp-argCount+i]
+// runGoroutine 在协程的虚拟机实例（由 newGoroutineVM 创建）中运行闭包
+// args 是启动时复制的参数，父协程随后会继续修改自己的栈
+// task 不为 nil（go 表达式）时返回值和未捕获的异常交给 Task，由 await() 的调用方处理；
+// 否则未捕获的异常（以及 Go 层面的 panic）交给 reportGoroutineError 处理
+func (vm *VM) runGoroutine(closure *Closure, args []interpreter.Object, task *interpreter.TaskObject) {
+	finish := func(result interpreter.Object, err error) {
+		if task != nil {
+			task.Complete(result, err)
+		} else if err != nil {
+			vm.reportGoroutineError(err)
+		}
 	}
+	defer func() {
+		if r := recover(); r != nil {
+			finish(nil, fmt.Errorf("协程崩溃: %v", r))
+		}
+	}()
 
-	// 设置参数
-	for i, arg := range args {
-		newVM.push(arg)
-		_ = i
+	// 与 Run 相同，在栈底压入哨兵值，再压入函数和参数
+	vm.push(&interpreter.Null{})
+	vm.push(closure)
+	for _, arg := range args {
+		vm.push(arg)
 	}
 
 	// 执行闭包
-	newVM.pushFrame(closure, 0)
-	newVM.execute()
+	if err := vm.callClosure(closure, len(args)); err != nil {
+		finish(nil, err)
+		return
+	}
+	finish(vm.execute())
 }
 
 // ========== 辅助函数 ==========
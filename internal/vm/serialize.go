@@ -0,0 +1,635 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/tangzhangming/longlang/internal/interpreter"
+)
+
+// ========== 字节码文件格式 ==========
+//
+// .longc 文件布局：
+//   magic "LONGC" | 格式版本 (uint16) | 操作码数量 (uvarint) | 编译器版本 (string) | 顶层字节码
+//
+// 字节码：
+//   文件名 | 指令 (bytes) | 行号表（游程编码） | 常量池 | 编译期指令
+//
+// 整数使用 varint 编码，字符串和字节序列带长度前缀
+// 常量带类型标签；函数常量递归包含自己的字节码，upvalue 描述符内联在 OP_CLOSURE 指令之后
+// 枚举和接口可能被常量池和编译期指令同时引用，第二次出现时只写入引用编号
+
+const (
+	// BytecodeMagic .longc 文件的魔数
+	BytecodeMagic = "LONGC"
+
+	// BytecodeFormatVersion 字节码文件格式版本（格式或指令语义变化时递增）
+	BytecodeFormatVersion = 4
+)
+
+// 常量类型标签
+const (
+	tagNil       byte = iota // Go nil（默认值列表中没有默认值的参数）
+	tagNull                  // null
+	tagInteger               // 整数
+	tagFloat                 // 浮点数
+	tagString                // 字符串
+	tagBoolean               // 布尔值
+	tagFunction              // 编译后的函数
+	tagEnum                  // 枚举
+	tagInterface             // 接口
+	tagRef                   // 已写入的枚举或接口的引用
+)
+
+// ========== 序列化 ==========
+
+// bytecodeEncoder 字节码编码器
+type bytecodeEncoder struct {
+	buf  bytes.Buffer
+	refs map[interpreter.Object]uint64 // 已写入的枚举和接口 -> 引用编号
+}
+
+// MarshalBytecode 将顶层字节码序列化为 .longc 格式
+func MarshalBytecode(bytecode *Bytecode, compilerVersion string) ([]byte, error) {
+	e := &bytecodeEncoder{refs: make(map[interpreter.Object]uint64)}
+	e.buf.WriteString(BytecodeMagic)
+	var version [2]byte
+	binary.BigEndian.PutUint16(version[:], BytecodeFormatVersion)
+	e.buf.Write(version[:])
+	e.uint(uint64(len(opcodeNames)))
+	e.string(compilerVersion)
+	if err := e.bytecode(bytecode); err != nil {
+		return nil, err
+	}
+	return e.buf.Bytes(), nil
+}
+
+func (e *bytecodeEncoder) uint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	e.buf.Write(tmp[:n])
+}
+
+func (e *bytecodeEncoder) int(v int64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(tmp[:], v)
+	e.buf.Write(tmp[:n])
+}
+
+func (e *bytecodeEncoder) bool(v bool) {
+	if v {
+		e.buf.WriteByte(1)
+	} else {
+		e.buf.WriteByte(0)
+	}
+}
+
+func (e *bytecodeEncoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf.WriteString(s)
+}
+
+func (e *bytecodeEncoder) strings(list []string) {
+	e.uint(uint64(len(list)))
+	for _, s := range list {
+		e.string(s)
+	}
+}
+
+// bytecode 写入字节码（指令、行号表、常量池和编译期指令）
+func (e *bytecodeEncoder) bytecode(b *Bytecode) error {
+	if len(b.Lines) != len(b.Instructions) {
+		return fmt.Errorf("字节码行号表长度 %d 与指令长度 %d 不一致", len(b.Lines), len(b.Instructions))
+	}
+	e.string(b.FileName)
+	e.uint(uint64(len(b.Instructions)))
+	e.buf.Write(b.Instructions)
+
+	// 行号表：每个字节一个行号，连续相同的行号很多，使用 (行号, 重复次数) 游程编码
+	var runs [][2]int
+	for i, line := range b.Lines {
+		if i > 0 && line == b.Lines[i-1] {
+			runs[len(runs)-1][1]++
+			continue
+		}
+		runs = append(runs, [2]int{line, 1})
+	}
+	e.uint(uint64(len(runs)))
+	for _, run := range runs {
+		e.int(int64(run[0]))
+		e.uint(uint64(run[1]))
+	}
+
+	e.uint(uint64(len(b.Constants)))
+	for _, constant := range b.Constants {
+		if err := e.object(constant); err != nil {
+			return err
+		}
+	}
+
+	e.uint(uint64(len(b.Directives)))
+	for _, d := range b.Directives {
+		e.buf.WriteByte(byte(d.Kind))
+		e.string(d.Name)
+		e.string(d.Alias)
+		e.int(int64(d.Line))
+		e.int(int64(d.Column))
+		if err := e.object(d.Object); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// object 写入带类型标签的常量
+func (e *bytecodeEncoder) object(obj interpreter.Object) error {
+	if ref, ok := e.refs[obj]; ok && obj != nil {
+		e.buf.WriteByte(tagRef)
+		e.uint(ref)
+		return nil
+	}
+
+	switch o := obj.(type) {
+	case nil:
+		e.buf.WriteByte(tagNil)
+	case *interpreter.Null:
+		e.buf.WriteByte(tagNull)
+	case *interpreter.Integer:
+		e.buf.WriteByte(tagInteger)
+		e.int(o.Value)
+	case *interpreter.Float:
+		e.buf.WriteByte(tagFloat)
+		var tmp [8]byte
+		binary.BigEndian.PutUint64(tmp[:], math.Float64bits(o.Value))
+		e.buf.Write(tmp[:])
+	case *interpreter.String:
+		e.buf.WriteByte(tagString)
+		e.string(o.Value)
+	case *interpreter.Boolean:
+		e.buf.WriteByte(tagBoolean)
+		e.bool(o.Value)
+	case *CompiledFunction:
+		e.buf.WriteByte(tagFunction)
+		return e.function(o)
+	case *interpreter.Enum:
+		e.refs[obj] = uint64(len(e.refs))
+		e.buf.WriteByte(tagEnum)
+		return e.enum(o)
+	case *interpreter.Interface:
+		e.refs[obj] = uint64(len(e.refs))
+		e.buf.WriteByte(tagInterface)
+		e.iface(o)
+	default:
+		return fmt.Errorf("无法序列化 %s 类型的常量", obj.Type())
+	}
+	return nil
+}
+
+// function 写入编译后的函数
+func (e *bytecodeEncoder) function(fn *CompiledFunction) error {
+	e.string(fn.Name)
+	e.string(fn.ClassName)
+	e.uint(uint64(fn.NumLocals))
+	e.uint(uint64(fn.NumParams))
+	e.uint(uint64(fn.UpvalueCount))
+	e.bool(fn.IsVariadic)
+	e.bool(fn.IsConstructor)
+	e.uint(uint64(len(fn.DefaultValues)))
+	for _, value := range fn.DefaultValues {
+		if err := e.object(value); err != nil {
+			return err
+		}
+	}
+	return e.bytecode(fn.Bytecode)
+}
+
+// enum 写入枚举（字段、成员和方法；map 按键排序以保证输出稳定）
+func (e *bytecodeEncoder) enum(enum *interpreter.Enum) error {
+	e.string(enum.Name)
+	e.string(enum.BackingType)
+	e.bool(enum.IsPublic)
+	e.bool(enum.IsInternal)
+	e.string(enum.Namespace)
+
+	e.uint(uint64(len(enum.Variables)))
+	for _, name := range sortedKeys(enum.Variables) {
+		v := enum.Variables[name]
+		e.string(v.Name)
+		e.string(v.Type)
+		e.string(v.AccessModifier)
+		if err := e.object(v.DefaultValue); err != nil {
+			return err
+		}
+	}
+
+	e.uint(uint64(len(enum.MemberList)))
+	for _, member := range enum.MemberList {
+		e.string(member.Name)
+		if err := e.object(member.Value); err != nil {
+			return err
+		}
+		e.uint(uint64(len(member.Fields)))
+		for _, name := range sortedKeys(member.Fields) {
+			e.string(name)
+			if err := e.object(member.Fields[name]); err != nil {
+				return err
+			}
+		}
+	}
+
+	e.uint(uint64(len(enum.Methods)))
+	for _, name := range sortedKeys(enum.Methods) {
+		method := enum.Methods[name]
+		closure, ok := method.Body.(*Closure)
+		if !ok {
+			return fmt.Errorf("无法序列化枚举 %s 的方法 %s", enum.Name, name)
+		}
+		e.string(method.Name)
+		e.string(method.AccessModifier)
+		e.bool(method.IsStatic)
+		e.strings(method.ReturnType)
+		if err := e.function(closure.Fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iface 写入接口
+func (e *bytecodeEncoder) iface(iface *interpreter.Interface) {
+	e.string(iface.Name)
+	e.bool(iface.IsPublic)
+	e.bool(iface.IsInternal)
+	e.string(iface.Namespace)
+	e.uint(uint64(len(iface.Methods)))
+	for _, name := range sortedKeys(iface.Methods) {
+		method := iface.Methods[name]
+		e.string(method.Name)
+		e.strings(method.Parameters)
+		e.strings(method.ReturnType)
+	}
+}
+
+// sortedKeys 返回 map 的键（已排序）
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ========== 反序列化 ==========
+
+// bytecodeDecoder 字节码解码器
+// 遇到错误后记录第一个错误，后续读取全部返回零值
+type bytecodeDecoder struct {
+	data []byte
+	pos  int
+	err  error
+	refs []interpreter.Object // 按引用编号排列的枚举和接口
+}
+
+// UnmarshalBytecode 从 .longc 格式还原顶层字节码
+// 格式版本、操作码数量或编译器版本不一致时返回错误（需要重新编译）
+func UnmarshalBytecode(data []byte, compilerVersion string) (*Bytecode, error) {
+	if len(data) < len(BytecodeMagic)+2 || string(data[:len(BytecodeMagic)]) != BytecodeMagic {
+		return nil, fmt.Errorf("不是有效的 LongLang 字节码文件")
+	}
+	d := &bytecodeDecoder{data: data, pos: len(BytecodeMagic)}
+	version := binary.BigEndian.Uint16(data[d.pos:])
+	d.pos += 2
+	if version != BytecodeFormatVersion {
+		return nil, fmt.Errorf("字节码格式版本 %d 与当前版本 %d 不兼容，请重新编译", version, BytecodeFormatVersion)
+	}
+	opcodeCount := d.uint()
+	fileVersion := d.string()
+	if d.err == nil && (opcodeCount != uint64(len(opcodeNames)) || fileVersion != compilerVersion) {
+		return nil, fmt.Errorf("字节码由编译器 %s 生成，与当前版本 %s 不兼容，请重新编译", fileVersion, compilerVersion)
+	}
+
+	bytecode := d.bytecode()
+	if d.err == nil && d.pos != len(d.data) {
+		d.fail("文件末尾有多余的数据")
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return bytecode, nil
+}
+
+func (d *bytecodeDecoder) fail(format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf("字节码文件已损坏: "+format, args...)
+	}
+}
+
+func (d *bytecodeDecoder) uint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data[d.pos:])
+	if n <= 0 {
+		d.fail("无效的整数（偏移 %d）", d.pos)
+		return 0
+	}
+	d.pos += n
+	return v
+}
+
+func (d *bytecodeDecoder) int() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.data[d.pos:])
+	if n <= 0 {
+		d.fail("无效的整数（偏移 %d）", d.pos)
+		return 0
+	}
+	d.pos += n
+	return v
+}
+
+// length 读取长度并检查不超过剩余数据（防止损坏的文件导致超大分配）
+func (d *bytecodeDecoder) length() int {
+	n := d.uint()
+	if n > uint64(len(d.data)-d.pos) {
+		d.fail("长度 %d 超出文件范围（偏移 %d）", n, d.pos)
+		return 0
+	}
+	return int(n)
+}
+
+func (d *bytecodeDecoder) bytes() []byte {
+	n := d.length()
+	if d.err != nil {
+		return nil
+	}
+	b := make([]byte, n)
+	copy(b, d.data[d.pos:d.pos+n])
+	d.pos += n
+	return b
+}
+
+func (d *bytecodeDecoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	if d.pos >= len(d.data) {
+		d.fail("意外的文件结尾")
+		return 0
+	}
+	b := d.data[d.pos]
+	d.pos++
+	return b
+}
+
+func (d *bytecodeDecoder) bool() bool {
+	return d.byte() != 0
+}
+
+func (d *bytecodeDecoder) string() string {
+	return string(d.bytes())
+}
+
+func (d *bytecodeDecoder) strings() []string {
+	n := d.length()
+	list := make([]string, 0, n)
+	for i := 0; i < n && d.err == nil; i++ {
+		list = append(list, d.string())
+	}
+	return list
+}
+
+// bytecode 读取字节码
+func (d *bytecodeDecoder) bytecode() *Bytecode {
+	b := NewBytecode()
+	b.FileName = d.string()
+	b.Instructions = d.bytes()
+
+	runs := d.length()
+	for i := 0; i < runs && d.err == nil; i++ {
+		line := int(d.int())
+		count := d.uint()
+		if count > uint64(len(b.Instructions)-len(b.Lines)) {
+			d.fail("行号表与指令长度不一致")
+			break
+		}
+		for j := uint64(0); j < count; j++ {
+			b.Lines = append(b.Lines, line)
+		}
+	}
+	if d.err == nil && len(b.Lines) != len(b.Instructions) {
+		d.fail("行号表与指令长度不一致")
+	}
+
+	constants := d.length()
+	for i := 0; i < constants && d.err == nil; i++ {
+		b.Constants = append(b.Constants, d.object())
+	}
+
+	directives := d.length()
+	for i := 0; i < directives && d.err == nil; i++ {
+		b.Directives = append(b.Directives, Directive{
+			Kind:   DirectiveKind(d.byte()),
+			Name:   d.string(),
+			Alias:  d.string(),
+			Line:   int(d.int()),
+			Column: int(d.int()),
+			Object: d.object(),
+		})
+	}
+	return b
+}
+
+// object 读取带类型标签的常量
+func (d *bytecodeDecoder) object() interpreter.Object {
+	tag := d.byte()
+	if d.err != nil {
+		return nil
+	}
+	switch tag {
+	case tagNil:
+		return nil
+	case tagNull:
+		return &interpreter.Null{}
+	case tagInteger:
+		return &interpreter.Integer{Value: d.int()}
+	case tagFloat:
+		if d.pos+8 > len(d.data) {
+			d.fail("意外的文件结尾")
+			return nil
+		}
+		bits := binary.BigEndian.Uint64(d.data[d.pos:])
+		d.pos += 8
+		return &interpreter.Float{Value: math.Float64frombits(bits)}
+	case tagString:
+		return &interpreter.String{Value: d.string()}
+	case tagBoolean:
+		return &interpreter.Boolean{Value: d.bool()}
+	case tagFunction:
+		return d.function()
+	case tagEnum:
+		return d.enum()
+	case tagInterface:
+		return d.iface()
+	case tagRef:
+		ref := d.uint()
+		if ref >= uint64(len(d.refs)) {
+			d.fail("无效的引用编号 %d", ref)
+			return nil
+		}
+		return d.refs[ref]
+	}
+	d.fail("未知的常量类型标签 %d", tag)
+	return nil
+}
+
+// function 读取编译后的函数
+func (d *bytecodeDecoder) function() *CompiledFunction {
+	fn := &CompiledFunction{
+		Name:          d.string(),
+		ClassName:     d.string(),
+		NumLocals:     int(d.uint()),
+		NumParams:     int(d.uint()),
+		UpvalueCount:  int(d.uint()),
+		IsVariadic:    d.bool(),
+		IsConstructor: d.bool(),
+	}
+	defaults := d.length()
+	for i := 0; i < defaults && d.err == nil; i++ {
+		fn.DefaultValues = append(fn.DefaultValues, d.object())
+	}
+	fn.Bytecode = d.bytecode()
+	return fn
+}
+
+// enum 读取枚举（先登记引用，成员通过 Enum 字段指回枚举本身）
+func (d *bytecodeDecoder) enum() *interpreter.Enum {
+	enum := &interpreter.Enum{
+		Members:   make(map[string]*interpreter.EnumValue),
+		Methods:   make(map[string]*interpreter.ClassMethod),
+		Variables: make(map[string]*interpreter.ClassVariable),
+	}
+	d.refs = append(d.refs, enum)
+	enum.Name = d.string()
+	enum.BackingType = d.string()
+	enum.IsPublic = d.bool()
+	enum.IsInternal = d.bool()
+	enum.Namespace = d.string()
+
+	variables := d.length()
+	for i := 0; i < variables && d.err == nil; i++ {
+		v := &interpreter.ClassVariable{
+			Name:           d.string(),
+			Type:           d.string(),
+			AccessModifier: d.string(),
+			DefaultValue:   d.object(),
+		}
+		enum.Variables[v.Name] = v
+	}
+
+	members := d.length()
+	for i := 0; i < members && d.err == nil; i++ {
+		member := &interpreter.EnumValue{
+			Enum:    enum,
+			Name:    d.string(),
+			Ordinal: i,
+			Value:   d.object(),
+			Fields:  make(map[string]interpreter.Object),
+		}
+		fields := d.length()
+		for j := 0; j < fields && d.err == nil; j++ {
+			name := d.string()
+			member.Fields[name] = d.object()
+		}
+		enum.Members[member.Name] = member
+		enum.MemberList = append(enum.MemberList, member)
+	}
+
+	methods := d.length()
+	for i := 0; i < methods && d.err == nil; i++ {
+		method := &interpreter.ClassMethod{
+			Name:           d.string(),
+			AccessModifier: d.string(),
+			IsStatic:       d.bool(),
+			ReturnType:     d.strings(),
+		}
+		method.Body = NewClosure(d.function())
+		enum.Methods[method.Name] = method
+	}
+	return enum
+}
+
+// iface 读取接口
+func (d *bytecodeDecoder) iface() *interpreter.Interface {
+	iface := &interpreter.Interface{Methods: make(map[string]*interpreter.InterfaceMethod)}
+	d.refs = append(d.refs, iface)
+	iface.Name = d.string()
+	iface.IsPublic = d.bool()
+	iface.IsInternal = d.bool()
+	iface.Namespace = d.string()
+	methods := d.length()
+	for i := 0; i < methods && d.err == nil; i++ {
+		method := &interpreter.InterfaceMethod{
+			Name:       d.string(),
+			Parameters: d.strings(),
+			ReturnType: d.strings(),
+		}
+		iface.Methods[method.Name] = method
+	}
+	return iface
+}
+
+// ========== 文件读写 ==========
+
+// WriteBytecodeFile 将字节码写入 .longc 文件
+// 先写入同目录下的临时文件再重命名，并发写入同一文件时不会留下不完整的内容
+func WriteBytecodeFile(path string, bytecode *Bytecode, compilerVersion string) error {
+	data, err := MarshalBytecode(bytecode, compilerVersion)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// ReadBytecodeFile 从 .longc 文件读取字节码
+func ReadBytecodeFile(path string, compilerVersion string) (*Bytecode, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	bytecode, err := UnmarshalBytecode(data, compilerVersion)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return bytecode, nil
+}
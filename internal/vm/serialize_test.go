@@ -0,0 +1,192 @@
+package vm
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// stdlibPath 测试在包目录下执行，标准库位于仓库根目录
+const stdlibPath = "../../stdlib"
+
+// roundTripSource 覆盖各类常量（函数、闭包、枚举、接口、浮点数）和编译期指令（use、枚举、接口）
+// 最后一条语句返回结果，便于比较两次执行
+const roundTripSource = `use System.Exception
+
+interface Named {
+    function name() string
+}
+
+enum Level: int implements Named {
+    Low = 1,
+    High = 2
+
+    public function label() string {
+        return "level " + this.name()
+    }
+}
+
+class Counter implements Named {
+    public static created int = 0
+    private count int = 0
+
+    public function __construct(start: int) {
+        this.count = start
+        Counter::created = Counter::created + 1
+    }
+
+    public function name() string {
+        return "counter"
+    }
+
+    public function next() int {
+        this.count = this.count + 1
+        return this.count
+    }
+}
+
+function add(a: int, b: int = 10) int {
+    return a + b
+}
+
+function sum(...nums: int) int {
+    total := 0
+    for _, n := range nums {
+        total = total + n
+    }
+    return total
+}
+
+function adder(base: int) any {
+    return function(n: int) int {
+        return base + n
+    }
+}
+
+c := new Counter(5)
+c.next()
+plus := adder(100)
+caught := ""
+try {
+    throw new Exception("boom")
+} catch (Exception e) {
+    caught = e.getMessage()
+}
+scores := map[string]int{"a": 1, "b": 2}
+parts := []string{
+    Level::High.label(),
+    toString(Level::from(1).value()),
+    (Level::Low as Named).name(),
+    (c as Named).name(),
+    toString(c.next()),
+    toString(add(1)),
+    toString(sum(1, 2, 3)),
+    toString(plus(5)),
+    caught,
+    toString(scores["b"]),
+    toString(Counter::created),
+    toString(1.5 * 2),
+    toString(true && !false)
+}
+return parts.join(",")
+`
+
+const roundTripResult = "level High,1,Low,counter,7,11,6,105,boom,2,1,3,true"
+
+// newTestVM 创建使用仓库标准库的虚拟机
+func newTestVM() *VM {
+	machine := NewVM()
+	machine.SetStdlibPath(stdlibPath)
+	return machine
+}
+
+// runLoaded 重放编译期指令并在新的虚拟机中执行字节码，返回结果的显示形式
+func runLoaded(t *testing.T, bytecode *Bytecode) string {
+	t.Helper()
+	machine := newTestVM()
+	if err := machine.ApplyDirectives(bytecode); err != nil {
+		t.Fatalf("重放编译期指令失败: %v", err)
+	}
+	result, err := machine.Load(bytecode)
+	if err != nil {
+		t.Fatalf("执行失败: %v", err)
+	}
+	return result.Inspect()
+}
+
+// TestBytecodeRoundTrip 编译 → 序列化 → 反序列化 → 执行，结果与直接执行一致
+func TestBytecodeRoundTrip(t *testing.T) {
+	machine := newTestVM()
+	bytecode, err := machine.CompileFile("roundtrip.long", roundTripSource)
+	if err != nil {
+		t.Fatalf("编译失败: %v", err)
+	}
+	data, err := MarshalBytecode(bytecode, "test")
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+
+	result, err := machine.Load(bytecode)
+	if err != nil {
+		t.Fatalf("执行失败: %v", err)
+	}
+	if result.Inspect() != roundTripResult {
+		t.Fatalf("直接执行结果为 %s，期望 %s", result.Inspect(), roundTripResult)
+	}
+
+	loaded, err := UnmarshalBytecode(data, "test")
+	if err != nil {
+		t.Fatalf("反序列化失败: %v", err)
+	}
+	if got, want := loaded.Disassemble("roundtrip"), bytecode.Disassemble("roundtrip"); got != want {
+		t.Errorf("反序列化后的字节码与原字节码不一致:\n--- 原字节码\n%s--- 反序列化\n%s", want, got)
+	}
+	if got := runLoaded(t, loaded); got != roundTripResult {
+		t.Errorf("反序列化后执行结果为 %s，期望 %s", got, roundTripResult)
+	}
+
+	// 再次序列化得到完全相同的数据
+	again, err := MarshalBytecode(loaded, "test")
+	if err != nil {
+		t.Fatalf("再次序列化失败: %v", err)
+	}
+	if string(again) != string(data) {
+		t.Error("反序列化后再次序列化的数据与原数据不一致")
+	}
+}
+
+// TestUnmarshalRejectsIncompatible 格式版本、编译器版本不一致或数据损坏时拒绝加载
+func TestUnmarshalRejectsIncompatible(t *testing.T) {
+	bytecode, err := newTestVM().CompileFile("small.long", "x := 1 + 2\nreturn x\n")
+	if err != nil {
+		t.Fatalf("编译失败: %v", err)
+	}
+	data, err := MarshalBytecode(bytecode, "test")
+	if err != nil {
+		t.Fatalf("序列化失败: %v", err)
+	}
+
+	oldFormat := append([]byte(nil), data...)
+	binary.BigEndian.PutUint16(oldFormat[len(BytecodeMagic):], BytecodeFormatVersion-1)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		version string
+		err     string
+	}{
+		{"格式版本", oldFormat, "test", "字节码格式版本"},
+		{"编译器版本", data, "other", "与当前版本 other 不兼容"},
+		{"魔数", append([]byte("NOPE!"), data[len(BytecodeMagic):]...), "test", "不是有效的 LongLang 字节码文件"},
+		{"截断", data[:len(data)-3], "test", "字节码文件已损坏"},
+		{"多余数据", append(append([]byte(nil), data...), 0), "test", "多余的数据"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := UnmarshalBytecode(tt.data, tt.version)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("期望包含 %q 的错误，实际为 %v", tt.err, err)
+			}
+		})
+	}
+}
@@ -3,13 +3,11 @@ package vm
 import (
 	"fmt"
 	"io/ioutil"
-	"path/filepath"
 	"strings"
 
 	"github.com/tangzhangming/longlang/internal/config"
+	"github.com/tangzhangming/longlang/internal/diagnostic"
 	"github.com/tangzhangming/longlang/internal/interpreter"
-	"github.com/tangzhangming/longlang/internal/lexer"
-	"github.com/tangzhangming/longlang/internal/parser"
 )
 
 // ========== 虚拟机常量 ==========
@@ -63,6 +61,13 @@ type VM struct {
 	// 标准库路径
 	stdlibPath string
 
+	// 字节码缓存（nil 表示不使用）
+	cache *BytecodeCache
+
+	// 与同一程序的其他协程共享的锁和错误处理
+	shared    *sharedState
+	loadDepth int // 当前协程嵌套加载命名空间文件的深度
+
 	// 调试信息
 	debug bool
 }
@@ -82,6 +87,7 @@ func NewVM() *VM {
 		loadedNamespaces:  make(map[string]bool),
 		loadingNamespaces: make(map[string]bool),
 		stdlibPath:        "stdlib",
+		shared:            &sharedState{},
 		debug:             false,
 	}
 
@@ -106,6 +112,9 @@ func (vm *VM) initBuiltins() {
 
 	// 注册 VM 专有的反射内置函数，覆盖解释器的版本（如果存在）
 	vm.registerVMReflectionBuiltins()
+
+	// 注册内置并发类型
+	vm.registerConcurrencyTypes()
 }
 
 // registerVMReflectionBuiltins 注册 VM 专用的反射内置函数
@@ -137,23 +146,31 @@ func (vm *VM) registerVMReflectionBuiltins() {
 		}
 		class, ok := vm.getClassByName(className.Value)
 		if !ok {
-			return &interpreter.Map{Pairs: make(map[string]interpreter.Object), Keys: []string{}}
+			return &interpreter.Map{Pairs: make(map[string]interpreter.Object), Keys: []string{}, KeyType: "string", ValueType: "any"}
 		}
+		return interpreter.ClassFieldsToMap(class)
+	}}
 
-		fields := make(map[string]interpreter.Object)
-		keys := make([]string, 0)
-		for name, variable := range class.Variables {
-			fieldInfo := &interpreter.Map{
-				Pairs: map[string]interpreter.Object{
-					"name": &interpreter.String{Value: name},
-					"type": &interpreter.String{Value: variable.Type},
-				},
-				Keys: []string{"name", "type"},
-			}
-			fields[name] = fieldInfo
-			keys = append(keys, name)
+	// __get_field_annotations(className, fieldName)
+	vm.globals["__get_field_annotations"] = &interpreter.Builtin{Fn: func(args ...interpreter.Object) interpreter.Object {
+		if len(args) != 2 {
+			return &interpreter.Error{Message: "__get_field_annotations 需要2个参数"}
+		}
+		className, ok1 := args[0].(*interpreter.String)
+		fieldName, ok2 := args[1].(*interpreter.String)
+		if !ok1 || !ok2 {
+			return &interpreter.Error{Message: "__get_field_annotations 参数必须是字符串"}
+		}
+
+		class, ok := vm.getClassByName(className.Value)
+		if !ok {
+			return &interpreter.Array{Elements: []interpreter.Object{}}
 		}
-		return &interpreter.Map{Pairs: fields, Keys: keys}
+		variable, ok := class.GetVariable(fieldName.Value)
+		if !ok {
+			return &interpreter.Array{Elements: []interpreter.Object{}}
+		}
+		return vm.annotationsToArray(variable.Annotations)
 	}}
 
 	// __get_field_annotation(className, fieldName, annName)
@@ -173,7 +190,7 @@ func (vm *VM) registerVMReflectionBuiltins() {
 			return &interpreter.Null{}
 		}
 
-		variable, ok := class.Variables[fieldName.Value]
+		variable, ok := class.GetVariable(fieldName.Value)
 		if !ok {
 			return &interpreter.Null{}
 		}
@@ -203,7 +220,7 @@ func (vm *VM) registerVMReflectionBuiltins() {
 			return &interpreter.Boolean{Value: false}
 		}
 
-		variable, ok := class.Variables[fieldName.Value]
+		variable, ok := class.GetVariable(fieldName.Value)
 		if !ok {
 			return &interpreter.Boolean{Value: false}
 		}
@@ -277,6 +294,53 @@ func (vm *VM) registerVMReflectionBuiltins() {
 		return &interpreter.String{Value: ""}
 	}}
 
+	// __new_instance(className) - 创建实例但不调用构造函数
+	vm.globals["__new_instance"] = &interpreter.Builtin{Fn: func(args ...interpreter.Object) interpreter.Object {
+		if len(args) != 1 {
+			return &interpreter.Error{Message: "__new_instance 需要1个参数"}
+		}
+		className, ok := args[0].(*interpreter.String)
+		if !ok {
+			return &interpreter.Error{Message: "__new_instance 参数必须是字符串"}
+		}
+
+		class, ok := vm.getClassByName(className.Value)
+		if !ok {
+			return &interpreter.Error{Message: "未找到类: " + className.Value}
+		}
+		return interpreter.NewBareInstance(class)
+	}}
+
+	// __resolve_class_name(typeName, declaringClassName)
+	// 依次按完全限定名、声明类所在的命名空间、唯一的同名类解析，找不到时返回空字符串
+	vm.globals["__resolve_class_name"] = &interpreter.Builtin{Fn: func(args ...interpreter.Object) interpreter.Object {
+		if len(args) != 2 {
+			return &interpreter.Error{Message: "__resolve_class_name 需要2个参数"}
+		}
+		typeName, ok1 := args[0].(*interpreter.String)
+		declaring, ok2 := args[1].(*interpreter.String)
+		if !ok1 || !ok2 {
+			return &interpreter.Error{Message: "__resolve_class_name 参数必须是字符串"}
+		}
+
+		name := typeName.Value
+		if strings.Contains(name, ".") {
+			if _, ok := vm.getClassByName(name); ok {
+				return &interpreter.String{Value: name}
+			}
+			return &interpreter.String{Value: ""}
+		}
+		if namespace, _, err := interpreter.ResolveClassName(declaring.Value); err == nil {
+			if _, ok := vm.getClassByName(namespace + "." + name); ok {
+				return &interpreter.String{Value: namespace + "." + name}
+			}
+		}
+		if namespaces := vm.namespaceMgr.FindClassNamespaces(name); len(namespaces) == 1 {
+			return &interpreter.String{Value: namespaces[0] + "." + name}
+		}
+		return &interpreter.String{Value: ""}
+	}}
+
 	// __create_instance(className, ...)
 	vm.globals["__create_instance"] = &interpreter.Builtin{Fn: func(args ...interpreter.Object) interpreter.Object {
 		if len(args) < 1 {
@@ -336,6 +400,20 @@ func (vm *VM) getClassByName(name string) (*interpreter.Class, bool) {
 	return ns.GetClass(className)
 }
 
+// popAnnotations 弹出栈顶的 count 个注解实例（按压栈顺序返回）
+func (vm *VM) popAnnotations(count int) []*interpreter.AnnotationInstance {
+	if count == 0 {
+		return nil
+	}
+	annotations := make([]*interpreter.AnnotationInstance, count)
+	base := vm.sp - count
+	for i := 0; i < count; i++ {
+		annotations[i] = vm.stack[base+i].(*interpreter.AnnotationInstance)
+	}
+	vm.sp = base
+	return annotations
+}
+
 // annotationsToArray 将注解列表转换为数组
 func (vm *VM) annotationsToArray(annotations []*interpreter.AnnotationInstance) *interpreter.Array {
 	elements := make([]interpreter.Object, len(annotations))
@@ -410,64 +488,8 @@ func (vm *VM) loadNamespaceFile(namespace string, className string) error {
 		delete(vm.loadingNamespaces, fullKey)
 	}()
 
-	// 将命名空间转换为文件路径
-	// 例如：Mycompany.Myapp.Models -> Mycompany/Myapp/Models
-	namespacePath := strings.ReplaceAll(namespace, ".", string(filepath.Separator))
-
 	// 构建可能的文件路径
-	var filePaths []string
-
-	// 如果有项目根目录，搜索项目目录
-	if vm.projectRoot != "" {
-		// 如果有 root_namespace，计算相对路径
-		relativeNamespacePath := namespacePath
-		if vm.projectConfig != nil && vm.projectConfig.RootNamespace != "" {
-			rootNsPath := strings.ReplaceAll(vm.projectConfig.RootNamespace, ".", string(filepath.Separator))
-			// 确保 rootNsPath 以路径分隔符结尾，以便正确匹配前缀
-			rootNsPathWithSep := rootNsPath + string(filepath.Separator)
-			if strings.HasPrefix(namespacePath, rootNsPathWithSep) {
-				// 去掉 root_namespace 前缀
-				relativeNamespacePath = strings.TrimPrefix(namespacePath, rootNsPathWithSep)
-			} else if namespacePath == rootNsPath {
-				// 命名空间正好是 root_namespace
-				relativeNamespacePath = ""
-			}
-		}
-
-		// 1. 使用相对路径在 src 目录下查找（优先）
-		if relativeNamespacePath != "" {
-			srcRelPath := filepath.Join(vm.projectRoot, "src", relativeNamespacePath, className+".long")
-			filePaths = append(filePaths, srcRelPath)
-		} else {
-			// 如果相对路径为空，直接在 src 下查找
-			srcRelPath := filepath.Join(vm.projectRoot, "src", className+".long")
-			filePaths = append(filePaths, srcRelPath)
-		}
-
-		// 2. 使用完整路径在 src 目录下查找
-		srcPath := filepath.Join(vm.projectRoot, "src", namespacePath, className+".long")
-		filePaths = append(filePaths, srcPath)
-
-		// 3. 在项目根目录下查找（相对路径）
-		if relativeNamespacePath != "" {
-			rootRelPath := filepath.Join(vm.projectRoot, relativeNamespacePath, className+".long")
-			filePaths = append(filePaths, rootRelPath)
-		}
-
-		// 4. 在项目根目录下查找（完整路径）
-		rootPath := filepath.Join(vm.projectRoot, namespacePath, className+".long")
-		filePaths = append(filePaths, rootPath)
-
-		// 5. 在 vendor 目录下查找
-		vendorPath := filepath.Join(vm.projectRoot, "vendor", namespacePath, className+".long")
-		filePaths = append(filePaths, vendorPath)
-	}
-
-	// 6. 在标准库目录下查找
-	if vm.stdlibPath != "" {
-		stdlibPath := filepath.Join(vm.stdlibPath, namespacePath, className+".long")
-		filePaths = append(filePaths, stdlibPath)
-	}
+	filePaths := config.NamespaceFilePaths(vm.projectRoot, vm.projectConfig, vm.stdlibPath, namespace, className)
 
 	// 尝试加载文件
 	var loadedPath string
@@ -487,15 +509,6 @@ func (vm *VM) loadNamespaceFile(namespace string, className string) error {
 	// 标记为已加载
 	vm.loadedNamespaces[fullKey] = true
 
-	// 解析文件（使用文件路径判断是否为标准库）
-	l := lexer.NewFromFile(content, loadedPath)
-	p := parser.New(l)
-	program := p.ParseProgram()
-
-	if len(p.Errors()) > 0 {
-		return fmt.Errorf("解析文件 %s 错误: %s", loadedPath, p.Errors()[0])
-	}
-
 	// 保存当前状态
 	savedNamespace := vm.currentNamespace
 	savedConfig := vm.projectConfig
@@ -506,14 +519,12 @@ func (vm *VM) loadNamespaceFile(namespace string, className string) error {
 		vm.projectConfig = nil
 	}
 
-	// 编译并执行文件
-	compiler := NewCompiler()
-	compiler.SetVM(vm)
-	bytecode, err := compiler.Compile(program)
+	// 编译（或从字节码缓存加载）并执行文件
+	bytecode, err := vm.CompileFile(loadedPath, content)
 	if err != nil {
 		vm.projectConfig = savedConfig
 		vm.currentNamespace = savedNamespace
-		return fmt.Errorf("编译文件 %s 错误: %s", loadedPath, err)
+		return err
 	}
 
 	// 执行字节码
@@ -553,7 +564,7 @@ func (vm *VM) runBytecode(bytecode *Bytecode) (interpreter.Object, error) {
 	vm.pushFrame(mainClosure, vm.sp)
 
 	// 执行指令
-	result, err := vm.execute()
+	result, err := vm.executeFrom(savedFrameCount, vm.tryCount)
 
 	// 恢复状态
 	vm.bytecode = savedBytecode
@@ -572,6 +583,14 @@ func (vm *VM) ProcessUseStatement(fullPath string, alias string) error {
 		return fmt.Errorf("无效的 use 路径: %s", fullPath)
 	}
 
+	// 命名空间文件的加载在所有协程间串行进行，同一协程内的嵌套加载不重复加锁
+	if vm.loadDepth == 0 {
+		vm.shared.loadMu.Lock()
+		defer vm.shared.loadMu.Unlock()
+	}
+	vm.loadDepth++
+	defer func() { vm.loadDepth-- }()
+
 	// 尝试加载命名空间文件
 	loadErr := vm.loadNamespaceFile(namespace, symbolName)
 
@@ -633,6 +652,11 @@ func (vm *VM) ProcessUseStatement(fullPath string, alias string) error {
 		if loadErr != nil {
 			return loadErr
 		}
+		// 循环依赖：目标文件正在加载中（如 DateTime 方法体内的 use 引用了反过来 use DateTime 的文件），
+		// 其中的类定义会在加载完成后注册，这里不报错
+		if vm.loadingNamespaces[namespace+"."+symbolName] {
+			return nil
+		}
 		return fmt.Errorf("命名空间 %s 中没有找到 %s", namespace, symbolName)
 	}
 
@@ -643,7 +667,7 @@ func (vm *VM) ProcessUseStatement(fullPath string, alias string) error {
 	}
 
 	// 将符号注册到全局作用域
-	vm.globals[importName] = symbol
+	vm.setGlobal(importName, symbol)
 
 	return nil
 }
@@ -686,10 +710,25 @@ func (vm *VM) Run(bytecode *Bytecode) (interpreter.Object, error) {
 	return result, nil
 }
 
+// Load 执行字节码的顶层代码（定义类、函数等），不调用入口点，返回顶层代码的结果
+// 用于测试运行器、REPL 等先加载代码、再执行其他字节码的场景；可多次调用，全局状态会保留
+func (vm *VM) Load(bytecode *Bytecode) (interpreter.Object, error) {
+	if vm.sp == 0 {
+		// 与 Run 相同，在栈底压入哨兵值
+		vm.push(&interpreter.Null{})
+	}
+	result, err := vm.runBytecode(bytecode)
+	if err != nil {
+		// 出错时调用栈已被丢弃，关闭指向已释放栈槽的 upvalue，保证后续加载不受影响
+		vm.closeUpvalues(vm.sp)
+	}
+	return result, err
+}
+
 // callEntryPoint 查找并调用入口点
 func (vm *VM) callEntryPoint() (interpreter.Object, error) {
 	// 遍历全局变量，查找包含 main 静态方法的类
-	for _, obj := range vm.globals {
+	for _, obj := range vm.globalValues() {
 		if class, ok := obj.(*interpreter.Class); ok {
 			if method, ok := class.GetStaticMethod("main"); ok {
 				// 找到入口点，调用它
@@ -721,13 +760,20 @@ func (vm *VM) callEntryPoint() (interpreter.Object, error) {
 
 // execute 执行指令循环
 func (vm *VM) execute() (interpreter.Object, error) {
-	for vm.frameCount > 0 {
+	return vm.executeFrom(0, 0)
+}
+
+// executeFrom 执行指令，直到调用栈回到 baseFrame 层
+// 只有 baseTry 之上的 try 块参与异常处理：嵌套执行（如内置方法调用回调函数）时，
+// 回调内部没有捕获的异常作为错误返回给调用者，而不是跳到外层代码的 catch 块
+func (vm *VM) executeFrom(baseFrame, baseTry int) (interpreter.Object, error) {
+	for vm.frameCount > baseFrame {
 		frame := vm.currentFrame()
 
 		// 检查是否到达指令末尾
 		if frame.ip >= len(frame.Instructions()) {
-			// 如果是主函数，返回栈顶值
-			if vm.frameCount == 1 {
+			// 如果是最外层函数，返回栈顶值
+			if vm.frameCount == baseFrame+1 {
 				if vm.sp > 0 {
 					return vm.pop(), nil
 				}
@@ -746,13 +792,13 @@ func (vm *VM) execute() (interpreter.Object, error) {
 		err := vm.executeInstruction(op, frame)
 		if err != nil {
 			// 检查是否有 try-catch
-			if vm.tryCount > 0 {
+			if vm.tryCount > baseTry {
 				// 尝试处理异常
 				if handled := vm.handleException(err); handled {
 					continue
 				}
 			}
-			return nil, err
+			return nil, vm.withStackTrace(err)
 		}
 	}
 
@@ -814,7 +860,7 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 			vm.push(&interpreter.Null{})
 			return nil
 		}
-		value, ok := vm.globals[name]
+		value, ok := vm.getGlobal(name)
 		if !ok {
 			return fmt.Errorf("未定义的变量: %s", name)
 		}
@@ -822,12 +868,12 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 
 	case OP_SET_GLOBAL:
 		name := frame.ReadConstant().(*interpreter.String).Value
-		vm.globals[name] = vm.peek(0)
+		vm.setGlobal(name, vm.peek(0))
 
 	case OP_DEFINE_GLOBAL:
 		name := frame.ReadConstant().(*interpreter.String).Value
 		value := vm.pop()
-		vm.globals[name] = value
+		vm.setGlobal(name, value)
 
 	case OP_GET_GLOBAL_WIDE:
 		name := frame.ReadConstant16().(*interpreter.String).Value
@@ -847,7 +893,7 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 			vm.push(&interpreter.Null{})
 			return nil
 		}
-		value, ok := vm.globals[name]
+		value, ok := vm.getGlobal(name)
 		if !ok {
 			return fmt.Errorf("未定义的变量: %s", name)
 		}
@@ -856,7 +902,7 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 	case OP_DEFINE_GLOBAL_WIDE:
 		name := frame.ReadConstant16().(*interpreter.String).Value
 		value := vm.pop()
-		vm.globals[name] = value
+		vm.setGlobal(name, value)
 
 	case OP_GET_UPVALUE:
 		slot := frame.ReadByte()
@@ -903,11 +949,17 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 	case OP_EQ:
 		b := vm.pop()
 		a := vm.pop()
+		if err := vm.checkEnumComparison(a, b); err != nil {
+			return err
+		}
 		vm.push(&interpreter.Boolean{Value: vm.isEqual(a, b)})
 
 	case OP_NE:
 		b := vm.pop()
 		a := vm.pop()
+		if err := vm.checkEnumComparison(a, b); err != nil {
+			return err
+		}
 		vm.push(&interpreter.Boolean{Value: !vm.isEqual(a, b)})
 
 	case OP_LT:
@@ -1109,9 +1161,12 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 		name := frame.ReadConstant().(*interpreter.String).Value
 		defaultValue := vm.pop()
 		class := vm.peek(0).(*interpreter.Class)
+		if _, exists := class.Variables[name]; !exists {
+			class.FieldOrder = append(class.FieldOrder, name)
+		}
 		class.Variables[name] = &interpreter.ClassVariable{
 			Name:           name,
-			Type:           "", // 类型信息在运行时不关键
+			Type:           "", // 由随后的 OP_MEMBER_INFO 设置
 			DefaultValue:   defaultValue,
 			AccessModifier: "public", // 默认公开
 		}
@@ -1130,6 +1185,46 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 		// 同时初始化静态字段
 		class.StaticFields[name] = defaultValue
 
+	case OP_ANNOTATION:
+		name := frame.ReadConstant().(*interpreter.String).Value
+		argCount := int(frame.ReadByte())
+		annotation := &interpreter.AnnotationInstance{
+			Name:      name,
+			Arguments: make(map[string]interpreter.Object, argCount),
+		}
+		base := vm.sp - argCount*2
+		for i := 0; i < argCount; i++ {
+			key := vm.stack[base+i*2].(*interpreter.String).Value
+			annotation.Arguments[key] = vm.stack[base+i*2+1]
+		}
+		vm.sp = base
+		vm.push(annotation)
+
+	case OP_MEMBER_INFO:
+		name := frame.ReadConstant().(*interpreter.String).Value
+		annCount := int(frame.ReadByte())
+		annotations := vm.popAnnotations(annCount)
+		access := vm.pop().(*interpreter.String).Value
+		typeName := vm.pop().(*interpreter.String).Value
+		class := vm.peek(0).(*interpreter.Class)
+		variable, ok := class.Variables[name]
+		if !ok {
+			variable, ok = class.StaticVariables[name]
+		}
+		if ok {
+			variable.Type = typeName
+			if access != "" {
+				variable.AccessModifier = access
+			}
+			variable.Annotations = annotations
+		}
+
+	case OP_CLASS_ANNOTATIONS:
+		annCount := int(frame.ReadByte())
+		annotations := vm.popAnnotations(annCount)
+		class := vm.peek(0).(*interpreter.Class)
+		class.Annotations = annotations
+
 	case OP_CLASS_CONST:
 		name := frame.ReadConstant().(*interpreter.String).Value
 		value := vm.pop()
@@ -1163,6 +1258,33 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 			return fmt.Errorf("实例没有属性: %s", name)
 		}
 
+		if ev, ok := obj.(*interpreter.EnumValue); ok {
+			// 字段访问（复杂枚举）
+			if value, ok := ev.Fields[name]; ok {
+				vm.push(value)
+				return nil
+			}
+			// 方法引用
+			if method, ok := ev.Enum.GetMethod(name); ok && !method.IsStatic {
+				if closure, ok := method.Body.(*Closure); ok {
+					vm.push(&BoundMethod{Receiver: ev, Method: closure})
+					return nil
+				}
+			}
+			switch name {
+			case "name", "ordinal", "value":
+				vm.push(&interpreter.Builtin{Fn: func(args ...interpreter.Object) interpreter.Object {
+					result, err := vm.enumBuiltinMethod(ev, name)
+					if err != nil {
+						return &interpreter.Error{Message: err.Error()}
+					}
+					return result
+				}})
+				return nil
+			}
+			return fmt.Errorf("枚举值没有成员: %s", name)
+		}
+
 		return fmt.Errorf("只能访问实例的属性")
 
 	case OP_SET_PROPERTY:
@@ -1196,13 +1318,22 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 		}
 
 		if class, ok := obj.(*interpreter.Class); ok {
-			if value, ok := class.StaticFields[name]; ok {
+			if value, ok := vm.getStaticField(class, name); ok {
 				vm.push(value)
 				return nil
 			}
 			return fmt.Errorf("类 %s 没有静态字段: %s", class.Name, name)
 		}
 
+		// 枚举成员访问 Color::Red
+		if enum, ok := obj.(*interpreter.Enum); ok {
+			if member, ok := enum.GetMember(name); ok {
+				vm.push(member)
+				return nil
+			}
+			return fmt.Errorf("枚举 %s 没有成员 %s", enum.Name, name)
+		}
+
 		return fmt.Errorf("只能访问类的静态字段")
 
 	case OP_SET_STATIC_FIELD:
@@ -1221,7 +1352,7 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 		}
 
 		if class, ok := obj.(*interpreter.Class); ok {
-			class.StaticFields[name] = value
+			vm.setStaticField(class, name, value)
 			vm.push(value)
 			return nil
 		}
@@ -1245,6 +1376,17 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 	case OP_NEW:
 		argCount := int(frame.ReadByte())
 		classObj := vm.peek(argCount)
+
+		// 内置并发类型（Channel、WaitGroup、Mutex、Atomic）
+		if builtinType, ok := classObj.(*BuiltinType); ok {
+			instance, err := newBuiltinInstance(builtinType.Name, vm.popArgs(argCount))
+			if err != nil {
+				return err
+			}
+			vm.push(instance)
+			return nil
+		}
+
 		class, ok := classObj.(*interpreter.Class)
 		if !ok {
 			return fmt.Errorf("OP_NEW: 期望 CLASS 类型，但得到 %s", classObj.Type())
@@ -1334,6 +1476,26 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 			return fmt.Errorf("父类必须是一个类")
 		}
 
+	case OP_IMPLEMENT:
+		iface, ok := vm.pop().(*interpreter.Interface)
+		if !ok {
+			return fmt.Errorf("只能实现接口")
+		}
+		switch target := vm.peek(0).(type) {
+		case *interpreter.Class:
+			target.Interfaces = append(target.Interfaces, iface)
+		case *interpreter.Enum:
+			// 枚举是常量池对象，重复执行声明时不重复记录
+			for _, existing := range target.Interfaces {
+				if existing == iface {
+					return nil
+				}
+			}
+			target.Interfaces = append(target.Interfaces, iface)
+		default:
+			return fmt.Errorf("只有类和枚举可以实现接口")
+		}
+
 	case OP_INSTANCE_OF:
 		class := vm.pop()
 		obj := vm.pop()
@@ -1387,6 +1549,10 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 			vm.push(result)
 		}
 
+	case OP_MATCH_FAIL:
+		value := vm.pop()
+		return fmt.Errorf("match 表达式未匹配到任何分支，值: %s", value.Inspect())
+
 	case OP_GET_SUPER:
 		name := frame.ReadConstant().(*interpreter.String).Value
 		superclass := vm.pop().(*interpreter.Class)
@@ -1469,6 +1635,7 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 	// 异常处理
 	case OP_THROW:
 		exception := vm.pop()
+		vm.recordStackTrace(exception)
 		vm.exception = exception
 		return &VMError{Message: "异常抛出", Exception: exception}
 
@@ -1476,23 +1643,44 @@ func (vm *VM) executeInstruction(op Opcode, frame *Frame) error {
 		catchOffset := frame.ReadUint16()
 		vm.pushTry(frame.ip+int(catchOffset), vm.sp, vm.frameCount)
 
+	case OP_SETUP_FINALLY:
+		finallyOffset := frame.ReadUint16()
+		vm.pushFinally(frame.ip+int(finallyOffset), vm.sp, vm.frameCount)
+
 	case OP_POP_TRY:
 		vm.popTry()
 
 	// 协程
 	case OP_GO:
 		argCount := int(frame.ReadByte())
+		returnsTask := frame.ReadByte() == 1
 		fn := vm.peek(argCount)
 		if closure, ok := fn.(*Closure); ok {
-			// 创建新的虚拟机实例执行协程
-			go vm.runGoroutine(closure, argCount)
-			// 弹出函数和参数
+			// 复制参数，在共享程序状态的新虚拟机实例中执行协程
+			args := make([]interpreter.Object, argCount)
+			copy(args, vm.stack[vm.sp-argCount:vm.sp])
+			var task *interpreter.TaskObject
+			if returnsTask {
+				task = interpreter.NewTask()
+			}
+			routine := vm.newGoroutineVM()
+			go routine.runGoroutine(closure.detached(), args, task)
+			// 弹出函数和参数，go 表达式留下 Task
 			vm.sp -= argCount + 1
-			vm.push(&interpreter.Null{})
+			if task != nil {
+				vm.push(task)
+			}
 		} else {
 			return fmt.Errorf("go 只能用于函数")
 		}
 
+	case OP_SELECT:
+		caseCount := int(frame.ReadByte())
+		hasDefault := frame.ReadByte() == 1
+		if err := vm.executeSelect(caseCount, hasDefault); err != nil {
+			return err
+		}
+
 	// 其他
 	case OP_POP:
 		vm.pop()
@@ -1649,9 +1837,25 @@ func (vm *VM) pushTry(catchTarget, stackDepth, frameIndex int) {
 		panic("try 嵌套过深")
 	}
 	vm.tryStack[vm.tryCount] = &TryState{
-		CatchTarget: catchTarget,
-		StackDepth:  stackDepth,
-		FrameIndex:  frameIndex,
+		CatchTarget:   catchTarget,
+		FinallyTarget: -1,
+		StackDepth:    stackDepth,
+		FrameIndex:    frameIndex,
+	}
+	vm.tryCount++
+}
+
+// pushFinally 压入 finally 处理器
+// 异常发生时跳到 finally 异常路径，执行完 finally 后重新抛出
+func (vm *VM) pushFinally(finallyTarget, stackDepth, frameIndex int) {
+	if vm.tryCount >= MaxTryDepth {
+		panic("try 嵌套过深")
+	}
+	vm.tryStack[vm.tryCount] = &TryState{
+		CatchTarget:   -1,
+		FinallyTarget: finallyTarget,
+		StackDepth:    stackDepth,
+		FrameIndex:    frameIndex,
 	}
 	vm.tryCount++
 }
@@ -1672,6 +1876,20 @@ func (vm *VM) handleException(err error) bool {
 	tryState := vm.tryStack[vm.tryCount-1]
 	vm.tryCount--
 
+	// 在展开调用栈之前确定异常对象，以便记录完整的堆栈跟踪
+	var exceptionObj interpreter.Object
+	if vmErr, ok := err.(*VMError); ok && vmErr.Exception != nil {
+		exceptionObj = vmErr.Exception
+	} else {
+		// 对于非 VMError 类型的错误，创建一个 RuntimeException 实例
+		exceptionObj = vm.createRuntimeException(err.Error())
+		if vmErr, ok := err.(*VMError); ok && vmErr.Trace != nil {
+			vm.setStackTrace(exceptionObj, vmErr.Trace)
+		} else {
+			vm.recordStackTrace(exceptionObj)
+		}
+	}
+
 	// 恢复栈和帧
 	vm.sp = tryState.StackDepth
 	for vm.frameCount > tryState.FrameIndex {
@@ -1679,17 +1897,15 @@ func (vm *VM) handleException(err error) bool {
 	}
 
 	// 压入异常对象
-	if vmErr, ok := err.(*VMError); ok && vmErr.Exception != nil {
-		vm.push(vmErr.Exception)
+	vm.push(exceptionObj)
+
+	// 跳转到 catch 块（或 finally 异常路径）
+	if tryState.CatchTarget >= 0 {
+		vm.currentFrame().ip = tryState.CatchTarget
 	} else {
-		// 对于非 VMError 类型的错误，创建一个 RuntimeException 实例
-		exceptionObj := vm.createRuntimeException(err.Error())
-		vm.push(exceptionObj)
+		vm.currentFrame().ip = tryState.FinallyTarget
 	}
 
-	// 跳转到 catch 块
-	vm.currentFrame().ip = tryState.CatchTarget
-
 	return true
 }
 
@@ -1702,7 +1918,8 @@ func (vm *VM) createRuntimeException(message string) interpreter.Object {
 		exceptionClass, ok := vm.getClassByName("System.Exception")
 		if !ok {
 			// 尝试从全局查找
-			if cls, ok := vm.globals["Exception"].(*interpreter.Class); ok {
+			global, _ := vm.getGlobal("Exception")
+			if cls, isClass := global.(*interpreter.Class); isClass {
 				exceptionClass = cls
 				ok = true
 			}
@@ -1730,6 +1947,90 @@ func (vm *VM) createRuntimeException(message string) interpreter.Object {
 	return instance
 }
 
+// captureStackTrace 捕获当前调用栈信息（最近的调用在最前面）
+func (vm *VM) captureStackTrace() []string {
+	traces := make([]string, 0, vm.frameCount)
+	for idx := vm.frameCount - 1; idx >= 0; idx-- {
+		frame := vm.frames[idx]
+		fn := frame.closure.Fn
+		// 已执行完毕的顶层代码帧（入口点在其之上调用）不计入堆栈
+		if idx == 0 && frame.ip >= len(frame.Instructions()) {
+			continue
+		}
+
+		name := fn.Name
+		if name == "" {
+			name = "<anonymous>"
+		}
+		if fn.ClassName != "" {
+			name = fn.ClassName + "." + name
+		}
+
+		fileName := fn.Bytecode.FileName
+		if fileName == "" {
+			fileName = "<unknown>"
+		}
+		traces = append(traces, fmt.Sprintf("    at %s(%s:%d)", name, fileName, frame.GetLine()))
+	}
+
+	if len(traces) == 0 {
+		traces = append(traces, "    at <main>")
+	}
+	return traces
+}
+
+// recordStackTrace 将当前调用栈记录到异常实例中
+// 已经记录过堆栈的异常（如 catch 中重新抛出）保留原始堆栈
+func (vm *VM) recordStackTrace(exception interpreter.Object) {
+	instance, ok := exception.(*interpreter.Instance)
+	if !ok {
+		return
+	}
+	if trace, ok := instance.Fields["stackTrace"].(*interpreter.Array); ok && len(trace.Elements) > 0 {
+		return
+	}
+	vm.setStackTrace(instance, vm.captureStackTrace())
+
+	// 记录抛出位置（与解释器一致，使用 file/line 字段）
+	if vm.frameCount > 0 {
+		frame := vm.currentFrame()
+		instance.Fields["file"] = &interpreter.String{Value: frame.closure.Fn.Bytecode.FileName}
+		instance.Fields["line"] = &interpreter.Integer{Value: int64(frame.GetLine())}
+	}
+}
+
+// setStackTrace 设置异常实例的 stackTrace 字段
+func (vm *VM) setStackTrace(exception interpreter.Object, traces []string) {
+	instance, ok := exception.(*interpreter.Instance)
+	if !ok {
+		return
+	}
+	elements := make([]interpreter.Object, len(traces))
+	for idx, trace := range traces {
+		elements[idx] = &interpreter.String{Value: trace}
+	}
+	instance.Fields["stackTrace"] = &interpreter.Array{Elements: elements, ElementType: "string"}
+}
+
+// withStackTrace 为未被捕获的错误附加堆栈信息
+// 异常实例的堆栈已在抛出时记录；内置运行时错误在这里捕获当前调用栈
+// 同时记录出错位置（最内层帧的文件和行号），用于输出诊断信息
+func (vm *VM) withStackTrace(err error) error {
+	vmErr, ok := err.(*VMError)
+	if !ok {
+		vmErr = &VMError{Message: err.Error()}
+	}
+	if vmErr.Exception == nil && vmErr.Trace == nil {
+		vmErr.Trace = vm.captureStackTrace()
+	}
+	if vmErr.Line == 0 && vm.frameCount > 0 {
+		frame := vm.currentFrame()
+		vmErr.File = frame.closure.Fn.Bytecode.FileName
+		vmErr.Line = frame.GetLine()
+	}
+	return vmErr
+}
+
 // ========== VMError ==========
 
 // VMError 虚拟机错误
@@ -1737,6 +2038,8 @@ type VMError struct {
 	Message   string
 	Exception interpreter.Object
 	Line      int
+	File      string   // 出错的源文件
+	Trace     []string // 内置运行时错误的堆栈跟踪（异常实例的堆栈保存在实例中）
 }
 
 func (e *VMError) Error() string {
@@ -1755,6 +2058,63 @@ func (e *VMError) Error() string {
 	return e.Message
 }
 
+// StackTrace 返回完整的错误描述：异常类型、消息、堆栈跟踪以及异常链（Caused by）
+func (e *VMError) StackTrace() string {
+	if instance, ok := e.Exception.(*interpreter.Instance); ok {
+		return formatExceptionTrace(instance)
+	}
+	var sb strings.Builder
+	sb.WriteString(e.Error())
+	for _, trace := range e.Trace {
+		sb.WriteString("\n")
+		sb.WriteString(trace)
+	}
+	return sb.String()
+}
+
+// Diagnostic 转换为诊断信息：位置为出错的源码行，堆栈跟踪和异常链作为附加信息
+func (e *VMError) Diagnostic() *diagnostic.Diagnostic {
+	file, line := e.File, e.Line
+	// 异常实例使用最初抛出的位置（可能经过 finally 或 catch 重新抛出）
+	if instance, ok := e.Exception.(*interpreter.Instance); ok {
+		if f, ok := instance.Fields["file"].(*interpreter.String); ok {
+			if l, ok := instance.Fields["line"].(*interpreter.Integer); ok && l.Value > 0 {
+				file, line = f.Value, int(l.Value)
+			}
+		}
+	}
+
+	lines := strings.Split(e.StackTrace(), "\n")
+	d := diagnostic.New(file, line, 0, "运行时错误: %s", lines[0])
+	d.Details = lines[1:]
+	return d
+}
+
+// formatExceptionTrace 格式化异常实例及其异常链
+func formatExceptionTrace(instance *interpreter.Instance) string {
+	var sb strings.Builder
+	for depth := 0; instance != nil && depth < 32; depth++ {
+		if depth > 0 {
+			sb.WriteString("\nCaused by: ")
+		}
+		sb.WriteString(instance.Class.Name)
+		if msg, ok := instance.Fields["message"].(*interpreter.String); ok {
+			sb.WriteString(": ")
+			sb.WriteString(msg.Value)
+		}
+		if trace, ok := instance.Fields["stackTrace"].(*interpreter.Array); ok {
+			for _, elem := range trace.Elements {
+				if str, ok := elem.(*interpreter.String); ok {
+					sb.WriteString("\n")
+					sb.WriteString(str.Value)
+				}
+			}
+		}
+		instance, _ = instance.Fields["cause"].(*interpreter.Instance)
+	}
+	return sb.String()
+}
+
 // ========== 类型断言辅助函数 ==========
 
 // assertType 执行类型断言
@@ -1870,6 +2230,14 @@ func (vm *VM) assertMapType(value interpreter.Object, targetTypeName string) (in
 
 // assertClassType 断言类/接口类型
 func (vm *VM) assertClassType(value interpreter.Object, targetTypeName string) (interpreter.Object, bool) {
+	// 枚举值：匹配枚举本身或其实现的接口
+	if enumValue, ok := value.(*interpreter.EnumValue); ok {
+		if enumValue.Enum.Implements(targetTypeName) {
+			return enumValue, true
+		}
+		return nil, false
+	}
+
 	instance, ok := value.(*interpreter.Instance)
 	if !ok {
 		return nil, false
@@ -7,11 +7,15 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/tangzhangming/longlang/internal/checker"
 	"github.com/tangzhangming/longlang/internal/compiler"
 	"github.com/tangzhangming/longlang/internal/config"
+	"github.com/tangzhangming/longlang/internal/diagnostic"
 	"github.com/tangzhangming/longlang/internal/interpreter"
 	"github.com/tangzhangming/longlang/internal/lexer"
 	"github.com/tangzhangming/longlang/internal/parser"
+	"github.com/tangzhangming/longlang/internal/repl"
+	"github.com/tangzhangming/longlang/internal/testrunner"
 	"github.com/tangzhangming/longlang/internal/vm"
 )
 
@@ -36,26 +40,27 @@ func main() {
 	case "run":
 		// 使用虚拟机运行（默认）
 		if len(os.Args) < 3 {
-			fmt.Fprintf(os.Stderr, "用法: %s run <文件路径> [--debug]\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "用法: %s run <文件路径> [--debug] [--check]\n", os.Args[0])
 			os.Exit(1)
 		}
-		debug := len(os.Args) >= 4 && os.Args[3] == "--debug"
-		cmdVMRun(os.Args[2], debug)
+		debug, check := parseRunFlags(os.Args[3:])
+		cmdVMRun(os.Args[2], debug, check)
 	case "interpret":
 		// 使用解释器运行（保留的旧方式）
 		if len(os.Args) < 3 {
-			fmt.Fprintf(os.Stderr, "用法: %s interpret <文件路径>\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "用法: %s interpret <文件路径> [--check]\n", os.Args[0])
 			os.Exit(1)
 		}
-		cmdInterpret(os.Args[2])
+		_, check := parseRunFlags(os.Args[3:])
+		cmdInterpret(os.Args[2], check)
 	case "vm":
 		// 使用虚拟机运行（别名，与 run 相同）
 		if len(os.Args) < 3 {
-			fmt.Fprintf(os.Stderr, "用法: %s vm <文件路径> [--debug]\n", os.Args[0])
+			fmt.Fprintf(os.Stderr, "用法: %s vm <文件路径> [--debug] [--check]\n", os.Args[0])
 			os.Exit(1)
 		}
-		debug := len(os.Args) >= 4 && os.Args[3] == "--debug"
-		cmdVMRun(os.Args[2], debug)
+		debug, check := parseRunFlags(os.Args[3:])
+		cmdVMRun(os.Args[2], debug, check)
 	case "build":
 		if len(os.Args) < 3 {
 			fmt.Fprintf(os.Stderr, "用法: %s build <文件路径> [-o <输出目录>]\n", os.Args[0])
@@ -66,6 +71,43 @@ func main() {
 			outputDir = os.Args[4]
 		}
 		cmdBuild(os.Args[2], outputDir)
+	case "compile":
+		// 编译为字节码文件（.longc）
+		if len(os.Args) < 3 {
+			fmt.Fprintf(os.Stderr, "用法: %s compile <文件路径> [-o <输出文件>]\n", os.Args[0])
+			os.Exit(1)
+		}
+		output := ""
+		if len(os.Args) >= 5 && os.Args[3] == "-o" {
+			output = os.Args[4]
+		}
+		cmdCompile(os.Args[2], output)
+	case "test":
+		// 运行测试目录（默认当前目录）中的 @Test 方法
+		dir := "."
+		junitPath := ""
+		for i := 2; i < len(os.Args); i++ {
+			if os.Args[i] == "--junit" {
+				if i+1 >= len(os.Args) {
+					fmt.Fprintf(os.Stderr, "用法: %s test [目录] [--junit <报告文件>]\n", os.Args[0])
+					os.Exit(1)
+				}
+				junitPath = os.Args[i+1]
+				i++
+				continue
+			}
+			dir = os.Args[i]
+		}
+		cmdTest(dir, junitPath)
+	case "check":
+		// 静态类型检查（默认检查当前目录）
+		paths := os.Args[2:]
+		if len(paths) == 0 {
+			paths = []string{"."}
+		}
+		cmdCheck(paths)
+	case "repl":
+		cmdRepl()
 	case "new":
 		if len(os.Args) < 3 {
 			fmt.Fprintf(os.Stderr, "用法: %s new <项目名称>\n", os.Args[0])
@@ -90,10 +132,14 @@ func printUsage() {
 	fmt.Println()
 	fmt.Println("命令:")
 	fmt.Println("  version       显示版本信息")
-	fmt.Println("  run <file>    运行指定的 .long 文件（使用字节码虚拟机）")
+	fmt.Println("  run <file>    运行指定的 .long 或 .longc 文件（使用字节码虚拟机，--check 先做类型检查）")
 	fmt.Println("  interpret <file>  运行指定的 .long 文件（使用 AST 解释器）")
 	fmt.Println("  vm <file>     运行指定的 .long 文件（与 run 相同）")
 	fmt.Println("  build <file> [-o <dir>]  编译 .long 文件为 Go 程序")
+	fmt.Println("  compile <file> [-o <out.longc>]  编译 .long 文件为字节码文件（可用 run 直接运行）")
+	fmt.Println("  test [dir] [--junit <file>]  运行目录中的测试（@Test 方法）")
+	fmt.Println("  check [path...]  静态类型检查（文件或目录，默认当前目录）")
+	fmt.Println("  repl          启动交互式解释器")
 	fmt.Println("  new <name>    创建一个新项目")
 	fmt.Println("  help          显示帮助信息")
 	fmt.Println()
@@ -101,7 +147,12 @@ func printUsage() {
 	fmt.Println("  longlang version")
 	fmt.Println("  longlang run main.long")
 	fmt.Println("  longlang run main.long --debug")
+	fmt.Println("  longlang run main.long --check")
 	fmt.Println("  longlang interpret main.long")
+	fmt.Println("  longlang compile main.long -o main.longc")
+	fmt.Println("  longlang run main.longc")
+	fmt.Println("  longlang test tests --junit report.xml")
+	fmt.Println("  longlang check src")
 	fmt.Println("  longlang new myproject")
 }
 
@@ -118,10 +169,27 @@ func cmdVersion() {
 	fmt.Println("  • 字节码虚拟机执行")
 }
 
-// cmdVMRun 使用虚拟机运行指定的文件
-func cmdVMRun(filename string, debug bool) {
+// parseRunFlags 解析 run / vm / interpret 命令的选项
+func parseRunFlags(args []string) (debug bool, check bool) {
+	for _, arg := range args {
+		switch arg {
+		case "--debug":
+			debug = true
+		case "--check":
+			check = true
+		}
+	}
+	return debug, check
+}
+
+// cmdVMRun 使用虚拟机运行指定的文件（.long 源文件或 compile 生成的 .longc 字节码文件）
+// check 为 true 时先进行静态类型检查，有错误则不执行
+// 源文件及其通过 use 加载的依赖会使用字节码缓存（见 vm.DefaultBytecodeCacheDir）
+func cmdVMRun(filename string, debug bool, check bool) {
+	compiled := strings.HasSuffix(filename, ".longc")
+
 	// 检查文件扩展名
-	if !strings.HasSuffix(filename, ".long") {
+	if !compiled && !strings.HasSuffix(filename, ".long") {
 		fmt.Fprintf(os.Stderr, "警告: 文件 %s 不是 .long 文件\n", filename)
 	}
 
@@ -143,44 +211,45 @@ func cmdVMRun(filename string, debug bool) {
 		os.Exit(1)
 	}
 
-	// 词法分析
-	l := lexer.New(string(input))
-
-	// 语法分析
-	p := parser.New(l)
-	program := p.ParseProgram()
+	// 设置标准库路径（相对于可执行文件）
+	exePath, _ := os.Executable()
+	stdlibPath := filepath.Join(filepath.Dir(exePath), "stdlib")
+	// 如果不存在，尝试当前目录
+	if _, statErr := os.Stat(stdlibPath); os.IsNotExist(statErr) {
+		stdlibPath = "stdlib"
+	}
 
-	// 检查语法错误
-	if len(p.Errors()) != 0 {
-		fmt.Fprintf(os.Stderr, "语法错误:\n")
-		for _, msg := range p.Errors() {
-			fmt.Fprintf(os.Stderr, "\t%s\n", msg)
+	// 执行前的静态类型检查（.longc 文件没有源码，不做检查）
+	if check && !compiled {
+		if diags := checker.New(stdlibPath, projectRoot, projectConfig).CheckFiles([]string{filename}); len(diags) != 0 {
+			fmt.Fprintln(os.Stderr, diags.Format())
+			os.Exit(1)
 		}
-		os.Exit(1)
 	}
 
 	// 创建虚拟机
 	virtualMachine := vm.NewVM()
 	virtualMachine.SetDebug(debug)
 	virtualMachine.SetProjectConfig(projectRoot, projectConfig)
-
-	// 设置标准库路径（相对于可执行文件）
-	exePath, _ := os.Executable()
-	stdlibPath := filepath.Join(filepath.Dir(exePath), "stdlib")
-	// 如果不存在，尝试当前目录
-	if _, statErr := os.Stat(stdlibPath); os.IsNotExist(statErr) {
-		stdlibPath = "stdlib"
-	}
 	virtualMachine.SetStdlibPath(stdlibPath)
+	if cacheDir := vm.DefaultBytecodeCacheDir(); cacheDir != "" {
+		virtualMachine.SetBytecodeCache(vm.NewBytecodeCache(cacheDir, Version))
+	}
 
-	// 创建编译器并关联虚拟机
-	comp := vm.NewCompiler()
-	comp.SetVM(virtualMachine)
-
-	// 编译为字节码
-	bytecode, err := comp.Compile(program)
+	// 编译为字节码（.longc 文件直接加载，并重放其中记录的 namespace / use 等编译期指令）
+	var bytecode *vm.Bytecode
+	if compiled {
+		bytecode, err = vm.UnmarshalBytecode(input, Version)
+		if err != nil {
+			err = fmt.Errorf("%s: %s", filename, err)
+		} else {
+			err = virtualMachine.ApplyDirectives(bytecode)
+		}
+	} else {
+		bytecode, err = virtualMachine.CompileFile(filename, string(input))
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "编译错误: %s\n", err)
+		fmt.Fprintln(os.Stderr, diagnostic.FormatError(err))
 		os.Exit(1)
 	}
 
@@ -194,7 +263,12 @@ func cmdVMRun(filename string, debug bool) {
 	// 运行
 	result, err := virtualMachine.Run(bytecode)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "运行时错误: %s\n", err)
+		if vmErr, ok := err.(*vm.VMError); ok {
+			// 输出出错位置、异常类型、消息、完整堆栈以及异常链
+			fmt.Fprintln(os.Stderr, vmErr.Diagnostic().Format())
+		} else {
+			fmt.Fprintf(os.Stderr, "运行时错误: %s\n", diagnostic.FormatError(err))
+		}
 		os.Exit(1)
 	}
 
@@ -202,10 +276,16 @@ func cmdVMRun(filename string, debug bool) {
 	if debug && result != nil {
 		fmt.Printf("=== 结果 ===\n%s\n", result.Inspect())
 	}
+
+	// 协程中未捕获的异常已在发生时输出，这里只决定退出码
+	if len(virtualMachine.GoroutineErrors()) > 0 {
+		os.Exit(1)
+	}
 }
 
 // cmdInterpret 使用解释器运行指定的文件（保留的旧方式）
-func cmdInterpret(filename string) {
+// check 为 true 时先进行静态类型检查，有错误则不执行
+func cmdInterpret(filename string, check bool) {
 	// 检查文件扩展名
 	if !strings.HasSuffix(filename, ".long") {
 		fmt.Fprintf(os.Stderr, "警告: 文件 %s 不是 .long 文件\n", filename)
@@ -231,26 +311,18 @@ func cmdInterpret(filename string) {
 
 	// 词法分析：将源代码转换为 token 流
 	l := lexer.New(string(input))
+	l.SetFileName(filename)
 
 	// 语法分析：将 token 流转换为 AST
 	p := parser.New(l)
 	program := p.ParseProgram()
 
-	// 检查语法错误
-	if len(p.Errors()) != 0 {
-		fmt.Fprintf(os.Stderr, "语法错误:\n")
-		for _, msg := range p.Errors() {
-			fmt.Fprintf(os.Stderr, "\t%s\n", msg)
-		}
+	// 检查语法错误（编译器风格输出 file:line:col）
+	if diags := p.Diagnostics(); len(diags) != 0 {
+		fmt.Fprintln(os.Stderr, diags.Format())
 		os.Exit(1)
 	}
 
-	// 解释执行：执行 AST
-	interp := interpreter.New()
-
-	// 设置项目配置
-	interp.SetProjectConfig(projectRoot, projectConfig)
-
 	// 设置标准库路径（相对于可执行文件）
 	exePath, _ := os.Executable()
 	stdlibPath := filepath.Join(filepath.Dir(exePath), "stdlib")
@@ -258,6 +330,20 @@ func cmdInterpret(filename string) {
 	if _, err := os.Stat(stdlibPath); os.IsNotExist(err) {
 		stdlibPath = "stdlib"
 	}
+
+	// 执行前的静态类型检查
+	if check {
+		if diags := checker.New(stdlibPath, projectRoot, projectConfig).CheckFiles([]string{filename}); len(diags) != 0 {
+			fmt.Fprintln(os.Stderr, diags.Format())
+			os.Exit(1)
+		}
+	}
+
+	// 解释执行：执行 AST
+	interp := interpreter.New()
+
+	// 设置项目配置
+	interp.SetProjectConfig(projectRoot, projectConfig)
 	interp.SetStdlibPath(stdlibPath)
 
 	result := interp.Eval(program)
@@ -286,6 +372,193 @@ func cmdInterpret(filename string) {
 	}
 }
 
+// cmdTest 运行目录中的测试，有失败或错误时以非零状态退出
+func cmdTest(dir string, junitPath string) {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		fmt.Fprintf(os.Stderr, "测试目录不存在: %s\n", dir)
+		os.Exit(1)
+	}
+
+	// 获取项目根目录并加载项目配置
+	absDir, _ := filepath.Abs(dir)
+	projectRoot := findProjectRoot(absDir)
+	projectConfig, err := config.LoadProjectConfig(projectRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载项目配置错误: %s\n", err)
+		os.Exit(1)
+	}
+
+	// 查找测试
+	suites, err := testrunner.Discover(dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, diagnostic.FormatError(err))
+		os.Exit(1)
+	}
+	if len(suites) == 0 {
+		fmt.Printf("在 %s 中没有找到测试（使用 @Test 注解标记测试方法）\n", dir)
+		return
+	}
+
+	// 设置标准库路径（相对于可执行文件）
+	exePath, _ := os.Executable()
+	stdlibPath := filepath.Join(filepath.Dir(exePath), "stdlib")
+	// 如果不存在，尝试当前目录
+	if _, statErr := os.Stat(stdlibPath); os.IsNotExist(statErr) {
+		stdlibPath = "stdlib"
+	}
+
+	// 运行测试
+	runner := &testrunner.Runner{
+		StdlibPath:    stdlibPath,
+		ProjectRoot:   projectRoot,
+		ProjectConfig: projectConfig,
+		Out:           os.Stdout,
+	}
+	report := runner.Run(suites)
+
+	// 输出 JUnit 报告
+	if junitPath != "" {
+		if err := testrunner.WriteJUnit(report, junitPath); err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if !report.OK() {
+		os.Exit(1)
+	}
+}
+
+// cmdCheck 对文件或目录中的 .long 文件进行静态类型检查，有错误时以非零状态退出
+func cmdCheck(paths []string) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "文件或目录不存在: %s\n", path)
+			os.Exit(1)
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		// 递归查找 .long 文件（跳过以 . 开头的目录）
+		err = filepath.Walk(path, func(file string, fi os.FileInfo, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if fi.IsDir() {
+				if file != path && strings.HasPrefix(fi.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(file, ".long") {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "读取目录错误: %s\n", err)
+			os.Exit(1)
+		}
+	}
+	if len(files) == 0 {
+		fmt.Println("没有找到 .long 文件")
+		return
+	}
+
+	// 获取项目根目录并加载项目配置
+	absPath, _ := filepath.Abs(files[0])
+	projectRoot := findProjectRoot(filepath.Dir(absPath))
+	projectConfig, err := config.LoadProjectConfig(projectRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载项目配置错误: %s\n", err)
+		os.Exit(1)
+	}
+
+	// 设置标准库路径（相对于可执行文件）
+	exePath, _ := os.Executable()
+	stdlibPath := filepath.Join(filepath.Dir(exePath), "stdlib")
+	// 如果不存在，尝试当前目录
+	if _, statErr := os.Stat(stdlibPath); os.IsNotExist(statErr) {
+		stdlibPath = "stdlib"
+	}
+
+	diags := checker.New(stdlibPath, projectRoot, projectConfig).CheckFiles(files)
+	if len(diags) != 0 {
+		fmt.Fprintln(os.Stderr, diags.Format())
+		fmt.Fprintf(os.Stderr, "\n检查了 %d 个文件，发现 %d 个错误\n", len(files), len(diags))
+		os.Exit(1)
+	}
+	fmt.Printf("检查了 %d 个文件，没有发现错误\n", len(files))
+}
+
+// cmdRepl 启动交互式解释器（基于字节码虚拟机）
+func cmdRepl() {
+	// 获取项目根目录并加载项目配置
+	cwd, _ := os.Getwd()
+	projectRoot := findProjectRoot(cwd)
+	projectConfig, err := config.LoadProjectConfig(projectRoot)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载项目配置错误: %s\n", err)
+		os.Exit(1)
+	}
+
+	// 设置标准库路径（相对于可执行文件）
+	exePath, _ := os.Executable()
+	stdlibPath := filepath.Join(filepath.Dir(exePath), "stdlib")
+	// 如果不存在，尝试当前目录
+	if _, statErr := os.Stat(stdlibPath); os.IsNotExist(statErr) {
+		stdlibPath = "stdlib"
+	}
+
+	fmt.Printf("LongLang %s 交互式解释器（输入 :help 查看命令，:quit 退出）\n", Version)
+	repl.New(stdlibPath, projectRoot, projectConfig).Run(os.Stdin, os.Stdout, os.Stderr)
+}
+
+// cmdCompile 将指定的文件编译为字节码文件（.longc）
+// 编译时不执行 use 导入，依赖在运行 .longc 时按记录的 use 语句加载
+func cmdCompile(filename string, output string) {
+	// 检查文件扩展名
+	if !strings.HasSuffix(filename, ".long") {
+		fmt.Fprintf(os.Stderr, "警告: 文件 %s 不是 .long 文件\n", filename)
+	}
+	if output == "" {
+		output = strings.TrimSuffix(filename, ".long") + ".longc"
+	}
+
+	// 读取源文件
+	input, err := ioutil.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "读取文件错误: %s\n", err)
+		os.Exit(1)
+	}
+
+	// 语法分析
+	p := parser.New(lexer.NewFromFile(string(input), filename))
+	program := p.ParseProgram()
+	if diags := p.Diagnostics(); len(diags) != 0 {
+		fmt.Fprintln(os.Stderr, diags.Format())
+		os.Exit(1)
+	}
+
+	// 编译为字节码（不关联虚拟机）
+	comp := vm.NewCompiler()
+	comp.SetFileName(filename)
+	bytecode, err := comp.Compile(program)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, diagnostic.FormatError(err))
+		os.Exit(1)
+	}
+
+	if err := vm.WriteBytecodeFile(output, bytecode, Version); err != nil {
+		fmt.Fprintf(os.Stderr, "写入字节码文件错误: %s\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("已编译: %s -> %s\n", filename, output)
+}
+
 // cmdBuild 编译指定的文件
 func cmdBuild(filename string, outputDir string) {
 	// 检查文件扩展名
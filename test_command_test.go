@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ========== longlang test 命令 ==========
+
+// TestTestCommandExitCode 检查 longlang test 的退出状态：有失败或错误时非零
+func TestTestCommandExitCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string // 为空时不创建测试文件
+		exit   int
+		stdout string // 期望 stdout 包含的内容
+	}{
+		{
+			name: "全部通过",
+			source: `use System.Test.Assert
+
+class PassingTest {
+    @Test
+    public function testPass() {
+        Assert::true(1 < 2)
+    }
+}
+`,
+			exit:   0,
+			stdout: "共 1 个测试：通过 1，失败 0，错误 0",
+		},
+		{
+			name: "断言失败",
+			source: `use System.Test.Assert
+
+class FailingTest {
+    @Test
+    public function testPass() {
+    }
+
+    @Test
+    public function testFail() {
+        Assert::equals(1, 2)
+    }
+}
+`,
+			exit:   1,
+			stdout: "共 2 个测试：通过 1，失败 1，错误 0",
+		},
+		{
+			name: "运行时错误",
+			source: `use System.Exception
+
+class ErroringTest {
+    @Test
+    public function testThrow() {
+        throw new Exception("boom")
+    }
+}
+`,
+			exit:   1,
+			stdout: "共 1 个测试：通过 0，失败 0，错误 1",
+		},
+		{
+			name:   "没有测试",
+			exit:   0,
+			stdout: "没有找到测试",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.source != "" {
+				if err := os.WriteFile(filepath.Join(dir, "case_test.long"), []byte(tt.source), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := runEngine(t, "test", dir)
+			if got.Exit != tt.exit {
+				t.Errorf("退出状态: 期望 %d，实际 %d\n%s%s", tt.exit, got.Exit, got.Stdout, got.Stderr)
+			}
+			if !strings.Contains(got.Stdout, tt.stdout) {
+				t.Errorf("stdout 缺少 %q:\n%s", tt.stdout, got.Stdout)
+			}
+		})
+	}
+
+	t.Run("目录不存在", func(t *testing.T) {
+		got := runEngine(t, "test", filepath.Join(t.TempDir(), "missing"))
+		if got.Exit != 1 || !strings.Contains(got.Stderr, "测试目录不存在") {
+			t.Errorf("期望以状态 1 退出并报告目录不存在，实际 %d: %s", got.Exit, got.Stderr)
+		}
+	})
+}